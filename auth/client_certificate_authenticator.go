@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/numbatx/gn-coval-index"
+)
+
+// ArgsClientCertificateAuthenticator holds all input dependencies required by client certificate authenticator
+// in order to create a new instance. If AllowedCommonNames is empty, any certificate signed by ClientCAs is accepted
+type ArgsClientCertificateAuthenticator struct {
+	ClientCAs          *x509.CertPool
+	AllowedCommonNames []string
+}
+
+type clientCertificateAuthenticator struct {
+	clientCAs          *x509.CertPool
+	allowedCommonNames map[string]struct{}
+}
+
+// NewClientCertificateAuthenticator creates a new instance of an authenticator which accepts connection requests
+// made over mutual TLS, with a client certificate signed by one of the provided certificate authorities
+func NewClientCertificateAuthenticator(args *ArgsClientCertificateAuthenticator) (*clientCertificateAuthenticator, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if args.ClientCAs == nil {
+		return nil, covalent.ErrNilCertificatePool
+	}
+
+	allowedCommonNames := make(map[string]struct{}, len(args.AllowedCommonNames))
+	for _, commonName := range args.AllowedCommonNames {
+		allowedCommonNames[commonName] = struct{}{}
+	}
+
+	return &clientCertificateAuthenticator{
+		clientCAs:          args.ClientCAs,
+		allowedCommonNames: allowedCommonNames,
+	}, nil
+}
+
+// Authenticate checks that the request was made with a valid, allowed, client certificate
+func (cca *clientCertificateAuthenticator) Authenticate(request *http.Request) error {
+	if request.TLS == nil || len(request.TLS.PeerCertificates) == 0 {
+		return covalent.ErrMissingCredentials
+	}
+
+	clientCertificate := request.TLS.PeerCertificates[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range request.TLS.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := clientCertificate.Verify(x509.VerifyOptions{
+		Roots:         cca.clientCAs,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		return covalent.ErrInvalidCredentials
+	}
+
+	if len(cca.allowedCommonNames) == 0 {
+		return nil
+	}
+	_, allowed := cca.allowedCommonNames[clientCertificate.Subject.CommonName]
+	if !allowed {
+		return covalent.ErrClientCertificateNotAllowed
+	}
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (cca *clientCertificateAuthenticator) IsInterfaceNil() bool {
+	return cca == nil
+}
@@ -0,0 +1,122 @@
+package auth_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/auth"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClientCertificateAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	cca, err := auth.NewClientCertificateAuthenticator(nil)
+	require.Equal(t, covalent.ErrNilArguments, err)
+	require.True(t, check.IfNil(cca))
+
+	cca, err = auth.NewClientCertificateAuthenticator(&auth.ArgsClientCertificateAuthenticator{})
+	require.Equal(t, covalent.ErrNilCertificatePool, err)
+	require.True(t, check.IfNil(cca))
+
+	cca, err = auth.NewClientCertificateAuthenticator(&auth.ArgsClientCertificateAuthenticator{
+		ClientCAs: x509.NewCertPool(),
+	})
+	require.Nil(t, err)
+	require.False(t, check.IfNil(cca))
+}
+
+func TestClientCertificateAuthenticator_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	caCert, caKey := generateCertificate(t, "ca", nil, nil)
+	clientCert, _ := generateCertificate(t, "covalent", caCert, caKey)
+	otherClientCert, _ := generateCertificate(t, "other", caCert, caKey)
+	untrustedCACert, untrustedCAKey := generateCertificate(t, "untrusted ca", nil, nil)
+	untrustedClientCert, _ := generateCertificate(t, "covalent", untrustedCACert, untrustedCAKey)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(caCert)
+	cca, _ := auth.NewClientCertificateAuthenticator(&auth.ArgsClientCertificateAuthenticator{
+		ClientCAs:          clientCAs,
+		AllowedCommonNames: []string{"covalent"},
+	})
+
+	tests := []struct {
+		connState   *tls.ConnectionState
+		expectedErr error
+	}{
+		{
+			connState:   nil,
+			expectedErr: covalent.ErrMissingCredentials,
+		},
+		{
+			connState:   &tls.ConnectionState{},
+			expectedErr: covalent.ErrMissingCredentials,
+		},
+		{
+			connState:   &tls.ConnectionState{PeerCertificates: []*x509.Certificate{untrustedClientCert}},
+			expectedErr: covalent.ErrInvalidCredentials,
+		},
+		{
+			connState:   &tls.ConnectionState{PeerCertificates: []*x509.Certificate{otherClientCert}},
+			expectedErr: covalent.ErrClientCertificateNotAllowed,
+		},
+		{
+			connState:   &tls.ConnectionState{PeerCertificates: []*x509.Certificate{clientCert}},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		request := httptest.NewRequest("GET", "/block", nil)
+		request.TLS = currTest.connState
+
+		require.Equal(t, currTest.expectedErr, cca.Authenticate(request))
+	}
+}
+
+// generateCertificate creates a self signed certificate authority if no parent is provided,
+// otherwise a client certificate signed by the parent
+func generateCertificate(
+	t *testing.T,
+	commonName string,
+	parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey,
+) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		parent = template
+		parentKey = key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.Nil(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+
+	return cert, key
+}
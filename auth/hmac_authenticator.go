@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+)
+
+const (
+	// TimestampHeader holds the unix time, in seconds, when the connection request was signed
+	TimestampHeader = "X-Covalent-Timestamp"
+	// SignatureHeader holds the hex encoded hmac-sha256 signature of the connection request
+	SignatureHeader = "X-Covalent-Signature"
+)
+
+// ArgsHMACAuthenticator holds all input dependencies required by hmac authenticator in order to create a new instance
+type ArgsHMACAuthenticator struct {
+	Secret       []byte
+	MaxClockSkew time.Duration
+}
+
+type hmacAuthenticator struct {
+	secret       []byte
+	maxClockSkew time.Duration
+	getTime      func() time.Time
+}
+
+// NewHMACAuthenticator creates a new instance of an authenticator which accepts connection requests signed with
+// a shared secret. A request is accepted only if its timestamp is within MaxClockSkew from the local time
+func NewHMACAuthenticator(args *ArgsHMACAuthenticator) (*hmacAuthenticator, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if len(args.Secret) == 0 {
+		return nil, covalent.ErrEmptyHMACSecret
+	}
+	if args.MaxClockSkew <= 0 {
+		return nil, covalent.ErrInvalidMaxClockSkew
+	}
+
+	return &hmacAuthenticator{
+		secret:       args.Secret,
+		maxClockSkew: args.MaxClockSkew,
+		getTime:      time.Now,
+	}, nil
+}
+
+// Authenticate checks that the request holds a recent timestamp and a valid signature for it
+func (ha *hmacAuthenticator) Authenticate(request *http.Request) error {
+	timestampHeader := request.Header.Get(TimestampHeader)
+	signatureHeader := request.Header.Get(SignatureHeader)
+	if len(timestampHeader) == 0 || len(signatureHeader) == 0 {
+		return covalent.ErrMissingCredentials
+	}
+
+	timestamp, err := strconv.ParseInt(timestampHeader, 10, 64)
+	if err != nil {
+		return covalent.ErrInvalidCredentials
+	}
+
+	skew := ha.getTime().Sub(time.Unix(timestamp, 0))
+	if skew > ha.maxClockSkew || skew < -ha.maxClockSkew {
+		return covalent.ErrTimestampOutOfRange
+	}
+
+	signature, err := hex.DecodeString(signatureHeader)
+	if err != nil {
+		return covalent.ErrInvalidCredentials
+	}
+	if !hmac.Equal(signature, ComputeSignature(ha.secret, request.Method, request.URL.Path, timestampHeader)) {
+		return covalent.ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// ComputeSignature returns the hmac-sha256 signature of a connection request, as expected by hmac authenticator.
+// The signed message is: method + "\n" + path + "\n" + timestamp
+func ComputeSignature(secret []byte, method string, path string, timestamp string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(method + "\n" + path + "\n" + timestamp))
+
+	return mac.Sum(nil)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ha *hmacAuthenticator) IsInterfaceNil() bool {
+	return ha == nil
+}
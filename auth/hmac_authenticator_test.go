@@ -0,0 +1,110 @@
+package auth_test
+
+import (
+	"encoding/hex"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/auth"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHMACAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        *auth.ArgsHMACAuthenticator
+		expectedErr error
+	}{
+		{
+			args:        nil,
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args:        &auth.ArgsHMACAuthenticator{Secret: nil, MaxClockSkew: time.Second},
+			expectedErr: covalent.ErrEmptyHMACSecret,
+		},
+		{
+			args:        &auth.ArgsHMACAuthenticator{Secret: []byte("secret"), MaxClockSkew: 0},
+			expectedErr: covalent.ErrInvalidMaxClockSkew,
+		},
+		{
+			args:        &auth.ArgsHMACAuthenticator{Secret: []byte("secret"), MaxClockSkew: time.Second},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		ha, err := auth.NewHMACAuthenticator(currTest.args)
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(ha))
+	}
+}
+
+func TestHMACAuthenticator_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	secret := []byte("secret")
+	ha, _ := auth.NewHMACAuthenticator(&auth.ArgsHMACAuthenticator{
+		Secret:       secret,
+		MaxClockSkew: time.Minute,
+	})
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	validSignature := hex.EncodeToString(auth.ComputeSignature(secret, "GET", "/block", now))
+
+	tests := []struct {
+		timestamp   string
+		signature   string
+		expectedErr error
+	}{
+		{
+			timestamp:   "",
+			signature:   validSignature,
+			expectedErr: covalent.ErrMissingCredentials,
+		},
+		{
+			timestamp:   now,
+			signature:   "",
+			expectedErr: covalent.ErrMissingCredentials,
+		},
+		{
+			timestamp:   "not a number",
+			signature:   validSignature,
+			expectedErr: covalent.ErrInvalidCredentials,
+		},
+		{
+			timestamp:   old,
+			signature:   hex.EncodeToString(auth.ComputeSignature(secret, "GET", "/block", old)),
+			expectedErr: covalent.ErrTimestampOutOfRange,
+		},
+		{
+			timestamp:   now,
+			signature:   hex.EncodeToString(auth.ComputeSignature([]byte("wrong secret"), "GET", "/block", now)),
+			expectedErr: covalent.ErrInvalidCredentials,
+		},
+		{
+			timestamp:   now,
+			signature:   hex.EncodeToString(auth.ComputeSignature(secret, "GET", "/other", now)),
+			expectedErr: covalent.ErrInvalidCredentials,
+		},
+		{
+			timestamp:   now,
+			signature:   validSignature,
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		request := httptest.NewRequest("GET", "/block", nil)
+		request.Header.Set(auth.TimestampHeader, currTest.timestamp)
+		request.Header.Set(auth.SignatureHeader, currTest.signature)
+
+		require.Equal(t, currTest.expectedErr, ha.Authenticate(request))
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/numbatx/gn-coval-index"
+)
+
+const bearerPrefix = "Bearer "
+
+type tokenAuthenticator struct {
+	token []byte
+}
+
+// NewTokenAuthenticator creates a new instance of an authenticator which accepts connection requests
+// holding the static token in their "Authorization: Bearer <token>" header
+func NewTokenAuthenticator(token string) (*tokenAuthenticator, error) {
+	if len(token) == 0 {
+		return nil, covalent.ErrEmptyAuthenticationToken
+	}
+
+	return &tokenAuthenticator{
+		token: []byte(token),
+	}, nil
+}
+
+// Authenticate checks that the request holds the expected bearer token
+func (ta *tokenAuthenticator) Authenticate(request *http.Request) error {
+	header := request.Header.Get("Authorization")
+	if !strings.HasPrefix(header, bearerPrefix) {
+		return covalent.ErrMissingCredentials
+	}
+
+	receivedToken := []byte(strings.TrimPrefix(header, bearerPrefix))
+	if subtle.ConstantTimeCompare(receivedToken, ta.token) != 1 {
+		return covalent.ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ta *tokenAuthenticator) IsInterfaceNil() bool {
+	return ta == nil
+}
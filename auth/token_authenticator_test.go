@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/auth"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTokenAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	ta, err := auth.NewTokenAuthenticator("")
+	require.Equal(t, covalent.ErrEmptyAuthenticationToken, err)
+	require.True(t, check.IfNil(ta))
+
+	ta, err = auth.NewTokenAuthenticator("token")
+	require.Nil(t, err)
+	require.False(t, check.IfNil(ta))
+}
+
+func TestTokenAuthenticator_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	ta, _ := auth.NewTokenAuthenticator("secret-token")
+
+	tests := []struct {
+		header      string
+		expectedErr error
+	}{
+		{
+			header:      "",
+			expectedErr: covalent.ErrMissingCredentials,
+		},
+		{
+			header:      "Basic secret-token",
+			expectedErr: covalent.ErrMissingCredentials,
+		},
+		{
+			header:      "Bearer wrong-token",
+			expectedErr: covalent.ErrInvalidCredentials,
+		},
+		{
+			header:      "Bearer secret-token",
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		request := httptest.NewRequest("GET", "/block", nil)
+		if len(currTest.header) != 0 {
+			request.Header.Set("Authorization", currTest.header)
+		}
+
+		require.Equal(t, currTest.expectedErr, ta.Authenticate(request))
+	}
+}
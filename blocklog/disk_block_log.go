@@ -0,0 +1,340 @@
+package blocklog
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/segment"
+	logger "github.com/numbatx/gn-logger"
+)
+
+var log = logger.GetOrCreate("covalent/blocklog")
+
+const (
+	// DefaultMaxSegmentSize is the segment size used if none is provided
+	DefaultMaxSegmentSize = 64 * 1024 * 1024
+	// DefaultMaxBlocks is the number of retained block results if neither MaxBlocks nor MaxAge is provided
+	DefaultMaxBlocks = 10000
+)
+
+// ArgsDiskBlockLog holds all input dependencies required by disk block log in order to create a new instance.
+// Block results are retained until there are more than MaxBlocks of them or they are older than MaxAge,
+// a zero value disabling the respective limit. DefaultMaxBlocks is used if no limit is provided
+type ArgsDiskBlockLog struct {
+	Directory      string
+	MaxSegmentSize int64
+	MaxBlocks      int
+	MaxAge         time.Duration
+}
+
+type entryIndex struct {
+	position  uint64
+	nonce     uint64
+	hash      []byte
+	timestamp int64
+	segment   *segment.Segment
+	offset    int64
+}
+
+type diskBlockLog struct {
+	mut            sync.RWMutex
+	directory      string
+	maxSegmentSize int64
+	maxBlocks      int
+	maxAge         time.Duration
+	segments       []*segment.Segment
+	entries        []*entryIndex
+	nextPosition   uint64
+	closed         bool
+}
+
+// NewDiskBlockLog creates a new sink which retains all published block results in segment files stored in the
+// provided directory, so that consumers can resume from any retained block. Retained entries are loaded back
+// after a restart
+func NewDiskBlockLog(args *ArgsDiskBlockLog) (*diskBlockLog, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if len(args.Directory) == 0 {
+		return nil, covalent.ErrEmptyBlockLogDirectory
+	}
+	if args.MaxSegmentSize < 0 {
+		return nil, covalent.ErrInvalidBlockLogSegmentSize
+	}
+	if args.MaxBlocks < 0 || args.MaxAge < 0 {
+		return nil, covalent.ErrInvalidBlockLogRetention
+	}
+
+	maxSegmentSize := args.MaxSegmentSize
+	if maxSegmentSize == 0 {
+		maxSegmentSize = DefaultMaxSegmentSize
+	}
+	maxBlocks := args.MaxBlocks
+	if maxBlocks == 0 && args.MaxAge == 0 {
+		maxBlocks = DefaultMaxBlocks
+	}
+
+	err := os.MkdirAll(args.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	bl := &diskBlockLog{
+		directory:      args.Directory,
+		maxSegmentSize: maxSegmentSize,
+		maxBlocks:      maxBlocks,
+		maxAge:         args.MaxAge,
+	}
+
+	err = bl.load()
+	if err != nil {
+		bl.closeSegments()
+		return nil, err
+	}
+
+	log.Debug("block log loaded", "directory", bl.directory, "retained blocks", len(bl.entries))
+	return bl, nil
+}
+
+func (bl *diskBlockLog) load() error {
+	firstPositions, err := segment.List(bl.directory, segmentFormat)
+	if err != nil {
+		return err
+	}
+
+	for _, firstPosition := range firstPositions {
+		seg, errOpen := segment.Open(bl.directory, segmentFormat, firstPosition)
+		if errOpen != nil {
+			return errOpen
+		}
+
+		bl.segments = append(bl.segments, seg)
+		bl.nextPosition = firstPosition
+
+		errScan := bl.scanSegment(seg)
+		if errScan != nil {
+			return errScan
+		}
+	}
+
+	if len(bl.segments) == 0 {
+		seg, errCreate := segment.Create(bl.directory, segmentFormat, bl.nextPosition)
+		if errCreate != nil {
+			return errCreate
+		}
+		bl.segments = append(bl.segments, seg)
+	}
+
+	return bl.applyRetention()
+}
+
+// scanSegment indexes all entries from the segment. A partially written or corrupted record
+// (e.g. the node stopped while appending) ends the segment and is discarded
+func (bl *diskBlockLog) scanSegment(seg *segment.Segment) error {
+	offset := int64(0)
+	for offset < seg.Size() {
+		entry, recordSize, err := readEntry(seg, offset)
+		if err != nil {
+			log.Warn("discarding corrupted block log segment tail",
+				"segment", seg.Name(), "offset", offset, "error", err)
+			return seg.Truncate(offset)
+		}
+
+		bl.entries = append(bl.entries, &entryIndex{
+			position:  bl.nextPosition,
+			nonce:     entry.message.Nonce,
+			hash:      entry.message.Hash,
+			timestamp: entry.timestamp,
+			segment:   seg,
+			offset:    offset,
+		})
+		bl.nextPosition++
+
+		offset += recordSize
+	}
+
+	return nil
+}
+
+// Publish appends the message to the log, syncing it to disk, then drops the entries which are no longer retained
+func (bl *diskBlockLog) Publish(message *covalent.SinkMessage) error {
+	if message == nil {
+		return covalent.ErrNilSinkMessage
+	}
+
+	bl.mut.Lock()
+	defer bl.mut.Unlock()
+
+	if bl.closed {
+		return covalent.ErrSinkClosed
+	}
+
+	entry := &logEntry{
+		message:   message,
+		timestamp: time.Now().UnixNano(),
+	}
+	body := encodeEntry(entry)
+
+	active := bl.segments[len(bl.segments)-1]
+	if active.Size() > 0 && active.Size()+segment.RecordHeaderSize+int64(len(body)) > bl.maxSegmentSize {
+		seg, err := segment.Create(bl.directory, segmentFormat, bl.nextPosition)
+		if err != nil {
+			return err
+		}
+
+		bl.segments = append(bl.segments, seg)
+		active = seg
+	}
+
+	offset, err := active.Append(body)
+	if err != nil {
+		return err
+	}
+
+	bl.entries = append(bl.entries, &entryIndex{
+		position:  bl.nextPosition,
+		nonce:     message.Nonce,
+		hash:      message.Hash,
+		timestamp: entry.timestamp,
+		segment:   active,
+		offset:    offset,
+	})
+	bl.nextPosition++
+
+	return bl.applyRetention()
+}
+
+// applyRetention drops the oldest entries exceeding the retention limits and deletes the segments, except the
+// active one, holding only dropped entries
+func (bl *diskBlockLog) applyRetention() error {
+	oldestRetained := time.Now().Add(-bl.maxAge).UnixNano()
+	for len(bl.entries) > 0 {
+		tooMany := bl.maxBlocks > 0 && len(bl.entries) > bl.maxBlocks
+		tooOld := bl.maxAge > 0 && bl.entries[0].timestamp < oldestRetained
+		if !tooMany && !tooOld {
+			break
+		}
+
+		bl.entries[0] = nil
+		bl.entries = bl.entries[1:]
+	}
+
+	firstRetained := bl.nextPosition
+	if len(bl.entries) > 0 {
+		firstRetained = bl.entries[0].position
+	}
+	for len(bl.segments) > 1 && bl.segments[1].First() <= firstRetained {
+		err := bl.segments[0].Remove()
+		if err != nil {
+			return err
+		}
+
+		bl.segments[0] = nil
+		bl.segments = bl.segments[1:]
+	}
+
+	return nil
+}
+
+// ReadAfter returns, in order, all retained messages published after the block the consumer resumes from.
+// Records which do not carry a block hash(e.g. rounds info or finality records) are published with the nonce of the
+// last saved block, so resuming from a nonce replays those following the block itself, not only the next blocks.
+// A nonce position which is older than all retained blocks, but not followed by a gap, replays all of them.
+// It returns ErrResumePositionNotFound if the hash is not retained or blocks following the nonce were dropped.
+// A hash published more than once, e.g. by a block which was reverted and saved again, resumes after its oldest
+// retained occurrence, so that the revert is not missed
+func (bl *diskBlockLog) ReadAfter(position *covalent.ResumePosition) ([]*covalent.SinkMessage, error) {
+	if position == nil {
+		return nil, covalent.ErrNilResumePosition
+	}
+
+	bl.mut.RLock()
+	defer bl.mut.RUnlock()
+
+	if bl.closed {
+		return nil, covalent.ErrSinkClosed
+	}
+
+	first, err := bl.firstAfter(position)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*covalent.SinkMessage, 0, len(bl.entries)-first)
+	for _, index := range bl.entries[first:] {
+		entry, _, errRead := readEntry(index.segment, index.offset)
+		if errRead != nil {
+			return nil, errRead
+		}
+
+		messages = append(messages, entry.message)
+	}
+
+	return messages, nil
+}
+
+// firstAfter returns the index of the first entry following the resume position
+func (bl *diskBlockLog) firstAfter(position *covalent.ResumePosition) (int, error) {
+	if len(position.Hash) > 0 {
+		for i := 0; i < len(bl.entries); i++ {
+			if bytes.Equal(bl.entries[i].hash, position.Hash) {
+				return i + 1, nil
+			}
+		}
+
+		return 0, covalent.ErrResumePositionNotFound
+	}
+
+	for i := len(bl.entries) - 1; i >= 0; i-- {
+		isBlock := len(bl.entries[i].hash) > 0
+		if isBlock && bl.entries[i].nonce <= position.Nonce {
+			return i + 1, nil
+		}
+	}
+	if len(bl.entries) > 0 && bl.entries[0].nonce > position.Nonce+1 {
+		return 0, covalent.ErrResumePositionNotFound
+	}
+
+	return 0, nil
+}
+
+// Len returns the number of retained block results
+func (bl *diskBlockLog) Len() int {
+	bl.mut.RLock()
+	defer bl.mut.RUnlock()
+
+	return len(bl.entries)
+}
+
+// Close closes all opened segment files. Retained entries are kept on disk
+func (bl *diskBlockLog) Close() error {
+	bl.mut.Lock()
+	defer bl.mut.Unlock()
+
+	if bl.closed {
+		return nil
+	}
+	bl.closed = true
+
+	return bl.closeSegments()
+}
+
+func (bl *diskBlockLog) closeSegments() error {
+	var lastErr error
+	for _, seg := range bl.segments {
+		err := seg.Close()
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (bl *diskBlockLog) IsInterfaceNil() bool {
+	return bl == nil
+}
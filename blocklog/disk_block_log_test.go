@@ -0,0 +1,362 @@
+package blocklog_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/blocklog"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiskBlockLog(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *blocklog.ArgsDiskBlockLog
+		expectedErr error
+	}{
+		{
+			args: func() *blocklog.ArgsDiskBlockLog {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args: func() *blocklog.ArgsDiskBlockLog {
+				return &blocklog.ArgsDiskBlockLog{Directory: ""}
+			},
+			expectedErr: covalent.ErrEmptyBlockLogDirectory,
+		},
+		{
+			args: func() *blocklog.ArgsDiskBlockLog {
+				return &blocklog.ArgsDiskBlockLog{Directory: t.TempDir(), MaxSegmentSize: -1}
+			},
+			expectedErr: covalent.ErrInvalidBlockLogSegmentSize,
+		},
+		{
+			args: func() *blocklog.ArgsDiskBlockLog {
+				return &blocklog.ArgsDiskBlockLog{Directory: t.TempDir(), MaxBlocks: -1}
+			},
+			expectedErr: covalent.ErrInvalidBlockLogRetention,
+		},
+		{
+			args: func() *blocklog.ArgsDiskBlockLog {
+				return &blocklog.ArgsDiskBlockLog{Directory: t.TempDir(), MaxAge: -time.Second}
+			},
+			expectedErr: covalent.ErrInvalidBlockLogRetention,
+		},
+		{
+			args: func() *blocklog.ArgsDiskBlockLog {
+				return &blocklog.ArgsDiskBlockLog{Directory: t.TempDir()}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := blocklog.NewDiskBlockLog(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(instance))
+		if err == nil {
+			require.Nil(t, instance.Close())
+		}
+	}
+}
+
+func TestDiskBlockLog_ReadAfter(t *testing.T) {
+	t.Parallel()
+
+	bl, _ := blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{Directory: t.TempDir()})
+	defer func() {
+		_ = bl.Close()
+	}()
+
+	messages := generateMessages(4, 100)
+	for _, message := range messages {
+		require.Nil(t, bl.Publish(message))
+	}
+
+	tests := []struct {
+		position         *covalent.ResumePosition
+		expectedMessages []*covalent.SinkMessage
+		expectedErr      error
+	}{
+		{
+			position:    nil,
+			expectedErr: covalent.ErrNilResumePosition,
+		},
+		{
+			position:         &covalent.ResumePosition{Hash: messages[1].Hash},
+			expectedMessages: messages[2:],
+		},
+		{
+			position:         &covalent.ResumePosition{Nonce: 101, Hash: messages[2].Hash},
+			expectedMessages: messages[3:],
+		},
+		{
+			position:    &covalent.ResumePosition{Hash: []byte("unknown hash")},
+			expectedErr: covalent.ErrResumePositionNotFound,
+		},
+		{
+			position:         &covalent.ResumePosition{Nonce: 101},
+			expectedMessages: messages[2:],
+		},
+		{
+			position:         &covalent.ResumePosition{Nonce: 99},
+			expectedMessages: messages,
+		},
+		{
+			position:         &covalent.ResumePosition{Nonce: 103},
+			expectedMessages: []*covalent.SinkMessage{},
+		},
+		{
+			position:    &covalent.ResumePosition{Nonce: 98},
+			expectedErr: covalent.ErrResumePositionNotFound,
+		},
+	}
+
+	for _, currTest := range tests {
+		replayed, err := bl.ReadAfter(currTest.position)
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, currTest.expectedMessages, replayed)
+	}
+}
+
+func TestDiskBlockLog_ReadAfter_RevertedBlockHash_ExpectRevertReplayed(t *testing.T) {
+	t.Parallel()
+
+	bl, _ := blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{Directory: t.TempDir()})
+	defer func() {
+		_ = bl.Close()
+	}()
+
+	messages := generateMessages(3, 100)
+	revert := &covalent.SinkMessage{
+		SequenceNumber: 3,
+		Nonce:          messages[2].Nonce,
+		Round:          messages[2].Round,
+		Epoch:          messages[2].Epoch,
+		Hash:           messages[2].Hash,
+		Data:           []byte("revert"),
+	}
+	for _, message := range append(messages, revert) {
+		require.Nil(t, bl.Publish(message))
+	}
+
+	replayed, err := bl.ReadAfter(&covalent.ResumePosition{Hash: messages[2].Hash})
+	require.Nil(t, err)
+	require.Equal(t, []*covalent.SinkMessage{revert}, replayed)
+}
+
+func TestDiskBlockLog_ReadAfter_RecordsWithoutHash_ExpectReplayedAfterTheirBlock(t *testing.T) {
+	t.Parallel()
+
+	bl, _ := blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{Directory: t.TempDir()})
+	defer func() {
+		_ = bl.Close()
+	}()
+
+	// records which do not refer to a block, e.g. rounds info or finality records, follow the last saved block
+	blocks := generateMessages(3, 100)
+	record := func(sequenceNumber uint64, nonce uint64) *covalent.SinkMessage {
+		return &covalent.SinkMessage{
+			SequenceNumber: sequenceNumber,
+			Nonce:          nonce,
+			Hash:           make([]byte, 0),
+			Data:           []byte("record" + strconv.Itoa(int(sequenceNumber))),
+		}
+	}
+	messages := []*covalent.SinkMessage{
+		blocks[0],
+		record(1, 100),
+		blocks[1],
+		record(3, 101),
+		record(4, 101),
+		blocks[2],
+	}
+	for _, message := range messages {
+		require.Nil(t, bl.Publish(message))
+	}
+
+	tests := []struct {
+		position         *covalent.ResumePosition
+		expectedMessages []*covalent.SinkMessage
+	}{
+		{
+			position:         &covalent.ResumePosition{Nonce: 99},
+			expectedMessages: messages,
+		},
+		{
+			position:         &covalent.ResumePosition{Nonce: 100},
+			expectedMessages: messages[1:],
+		},
+		{
+			position:         &covalent.ResumePosition{Nonce: 101},
+			expectedMessages: messages[3:],
+		},
+		{
+			position:         &covalent.ResumePosition{Hash: blocks[1].Hash},
+			expectedMessages: messages[3:],
+		},
+		{
+			position:         &covalent.ResumePosition{Nonce: 102},
+			expectedMessages: []*covalent.SinkMessage{},
+		},
+	}
+
+	for _, currTest := range tests {
+		replayed, err := bl.ReadAfter(currTest.position)
+		require.Nil(t, err)
+		require.Equal(t, currTest.expectedMessages, replayed)
+	}
+}
+
+func TestDiskBlockLog_MaxBlocks_ExpectOldestDroppedAndSegmentsRemoved(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	bl, _ := blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{
+		Directory:      dir,
+		MaxSegmentSize: 100,
+		MaxBlocks:      2,
+	})
+	defer func() {
+		_ = bl.Close()
+	}()
+
+	// Each entry has more than 50 bytes, so every segment holds only one entry
+	messages := generateMessages(5, 100)
+	for _, message := range messages {
+		require.Nil(t, bl.Publish(message))
+	}
+
+	require.Equal(t, 2, bl.Len())
+	require.Equal(t, 2, countSegments(t, dir))
+
+	_, err := bl.ReadAfter(&covalent.ResumePosition{Hash: messages[2].Hash})
+	require.Equal(t, covalent.ErrResumePositionNotFound, err)
+	_, err = bl.ReadAfter(&covalent.ResumePosition{Nonce: 101})
+	require.Equal(t, covalent.ErrResumePositionNotFound, err)
+
+	replayed, err := bl.ReadAfter(&covalent.ResumePosition{Nonce: 102})
+	require.Nil(t, err)
+	require.Equal(t, messages[3:], replayed)
+}
+
+func TestDiskBlockLog_MaxAge_ExpectExpiredBlocksDropped(t *testing.T) {
+	t.Parallel()
+
+	bl, _ := blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{
+		Directory: t.TempDir(),
+		MaxAge:    time.Millisecond * 200,
+	})
+	defer func() {
+		_ = bl.Close()
+	}()
+
+	messages := generateMessages(3, 100)
+	require.Nil(t, bl.Publish(messages[0]))
+	require.Nil(t, bl.Publish(messages[1]))
+	time.Sleep(time.Millisecond * 300)
+	require.Nil(t, bl.Publish(messages[2]))
+
+	require.Equal(t, 1, bl.Len())
+	replayed, err := bl.ReadAfter(&covalent.ResumePosition{Nonce: 101})
+	require.Nil(t, err)
+	require.Equal(t, messages[2:], replayed)
+}
+
+func TestDiskBlockLog_Restart_ExpectRetainedBlocksLoaded(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	bl, _ := blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{Directory: dir, MaxSegmentSize: 100})
+
+	messages := generateMessages(4, 100)
+	for _, message := range messages[:3] {
+		require.Nil(t, bl.Publish(message))
+	}
+	require.Nil(t, bl.Close())
+
+	bl, _ = blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{Directory: dir, MaxSegmentSize: 100, MaxBlocks: 3})
+	defer func() {
+		_ = bl.Close()
+	}()
+	require.Equal(t, 3, bl.Len())
+
+	require.Nil(t, bl.Publish(messages[3]))
+	require.Equal(t, 3, bl.Len())
+	require.Equal(t, 3, countSegments(t, dir))
+
+	replayed, err := bl.ReadAfter(&covalent.ResumePosition{Hash: messages[1].Hash})
+	require.Nil(t, err)
+	require.Equal(t, messages[2:], replayed)
+}
+
+func TestDiskBlockLog_PartiallyWrittenEntry_ExpectDiscardedAtRestart(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	bl, _ := blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{Directory: dir})
+
+	messages := generateMessages(2, 100)
+	for _, message := range messages {
+		require.Nil(t, bl.Publish(message))
+	}
+	require.Nil(t, bl.Close())
+
+	segmentPath := filepath.Join(dir, "blocks-00000000000000000000.log")
+	info, err := os.Stat(segmentPath)
+	require.Nil(t, err)
+	require.Nil(t, os.Truncate(segmentPath, info.Size()-3))
+
+	bl, _ = blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{Directory: dir})
+	defer func() {
+		_ = bl.Close()
+	}()
+
+	require.Equal(t, 1, bl.Len())
+	replayed, err := bl.ReadAfter(&covalent.ResumePosition{Nonce: 99})
+	require.Nil(t, err)
+	require.Equal(t, messages[:1], replayed)
+}
+
+func TestDiskBlockLog_Closed_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	bl, _ := blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{Directory: t.TempDir()})
+	require.Nil(t, bl.Close())
+
+	require.Equal(t, covalent.ErrSinkClosed, bl.Publish(generateMessages(1, 100)[0]))
+	_, err := bl.ReadAfter(&covalent.ResumePosition{})
+	require.Equal(t, covalent.ErrSinkClosed, err)
+}
+
+func generateMessages(n int, firstNonce uint64) []*covalent.SinkMessage {
+	messages := make([]*covalent.SinkMessage, n)
+
+	for i := 0; i < n; i++ {
+		messages[i] = &covalent.SinkMessage{
+			SequenceNumber: uint64(i),
+			Nonce:          firstNonce + uint64(i),
+			Round:          firstNonce + uint64(i) + 1,
+			Epoch:          2,
+			Hash:           []byte("hash" + strconv.Itoa(i)),
+			Data:           []byte("data" + strconv.Itoa(i)),
+		}
+	}
+
+	return messages
+}
+
+func countSegments(t *testing.T, dir string) int {
+	matches, err := filepath.Glob(filepath.Join(dir, "blocks-*.log"))
+	require.Nil(t, err)
+
+	return len(matches)
+}
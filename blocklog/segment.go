@@ -0,0 +1,77 @@
+package blocklog
+
+import (
+	"encoding/binary"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/segment"
+)
+
+// entryHeaderSize = 8 bytes sequence number + 8 bytes nonce + 8 bytes round + 4 bytes epoch +
+// 8 bytes timestamp(unix nanoseconds) + 4 bytes hash length
+const entryHeaderSize = 40
+
+// segmentFormat defines the segments holding consecutive log entries, named after the position of their first entry
+var segmentFormat = segment.Format{
+	FilePrefix:   "blocks-",
+	ErrCorrupted: covalent.ErrCorruptedBlockLogEntry,
+}
+
+// logEntry is a published message, together with the moment it was stored
+type logEntry struct {
+	message   *covalent.SinkMessage
+	timestamp int64
+}
+
+// readEntry returns the entry found at the given offset, together with the size of the whole record
+func readEntry(seg *segment.Segment, offset int64) (*logEntry, int64, error) {
+	body, recordSize, err := seg.Read(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entry, err := decodeEntry(body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entry, recordSize, nil
+}
+
+func encodeEntry(entry *logEntry) []byte {
+	message := entry.message
+	body := make([]byte, entryHeaderSize+len(message.Hash)+len(message.Data))
+	binary.BigEndian.PutUint64(body[0:8], message.SequenceNumber)
+	binary.BigEndian.PutUint64(body[8:16], message.Nonce)
+	binary.BigEndian.PutUint64(body[16:24], message.Round)
+	binary.BigEndian.PutUint32(body[24:28], message.Epoch)
+	binary.BigEndian.PutUint64(body[28:36], uint64(entry.timestamp))
+	binary.BigEndian.PutUint32(body[36:40], uint32(len(message.Hash)))
+	copy(body[entryHeaderSize:], message.Hash)
+	copy(body[entryHeaderSize+len(message.Hash):], message.Data)
+
+	return body
+}
+
+func decodeEntry(body []byte) (*logEntry, error) {
+	if len(body) < entryHeaderSize {
+		return nil, covalent.ErrCorruptedBlockLogEntry
+	}
+
+	hashLen := int(binary.BigEndian.Uint32(body[36:40]))
+	if len(body) < entryHeaderSize+hashLen {
+		return nil, covalent.ErrCorruptedBlockLogEntry
+	}
+
+	return &logEntry{
+		message: &covalent.SinkMessage{
+			SequenceNumber: binary.BigEndian.Uint64(body[0:8]),
+			Nonce:          binary.BigEndian.Uint64(body[8:16]),
+			Round:          binary.BigEndian.Uint64(body[16:24]),
+			Epoch:          binary.BigEndian.Uint32(body[24:28]),
+			Hash:           body[entryHeaderSize : entryHeaderSize+hashLen],
+			Data:           body[entryHeaderSize+hashLen:],
+		},
+		timestamp: int64(binary.BigEndian.Uint64(body[28:36])),
+	}, nil
+}
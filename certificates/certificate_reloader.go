@@ -0,0 +1,151 @@
+package certificates
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	logger "github.com/numbatx/gn-logger"
+)
+
+var log = logger.GetOrCreate("covalent/certificates")
+
+// DefaultCheckInterval is the interval used to check certificate files for changes, if none is provided
+const DefaultCheckInterval = time.Second * 10
+
+// ArgsCertificateReloader holds all input dependencies required by certificate reloader in order to create a new instance
+type ArgsCertificateReloader struct {
+	CertificateFile string
+	KeyFile         string
+	CheckInterval   time.Duration
+}
+
+type certificateReloader struct {
+	mut             sync.Mutex
+	certificateFile string
+	keyFile         string
+	checkInterval   time.Duration
+	certificate     *tls.Certificate
+	certModTime     time.Time
+	keyModTime      time.Time
+	lastCheck       time.Time
+}
+
+// NewCertificateReloader creates a new instance of certificate reloader, which loads a x509 key pair and reloads it
+// whenever the certificate or key file changes. This way, certificates can be rotated without restarting the node
+func NewCertificateReloader(args *ArgsCertificateReloader) (*certificateReloader, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if len(args.CertificateFile) == 0 {
+		return nil, covalent.ErrEmptyCertificateFile
+	}
+	if len(args.KeyFile) == 0 {
+		return nil, covalent.ErrEmptyKeyFile
+	}
+
+	checkInterval := args.CheckInterval
+	if checkInterval <= 0 {
+		checkInterval = DefaultCheckInterval
+	}
+
+	cr := &certificateReloader{
+		certificateFile: args.CertificateFile,
+		keyFile:         args.KeyFile,
+		checkInterval:   checkInterval,
+	}
+
+	err := cr.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return cr, nil
+}
+
+// GetCertificate returns the latest loaded certificate and can be used as tls.Config GetCertificate callback.
+// Certificate files are checked for changes at most once every check interval. If the changed files
+// can not be loaded (e.g. only one of them was replaced so far), the previous certificate is kept
+func (cr *certificateReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+
+	if time.Since(cr.lastCheck) < cr.checkInterval {
+		return cr.certificate, nil
+	}
+	cr.lastCheck = time.Now()
+
+	changed, err := cr.filesChanged()
+	if err != nil {
+		log.Warn("could not check certificate files, using the previous certificate", "error", err)
+		return cr.certificate, nil
+	}
+	if !changed {
+		return cr.certificate, nil
+	}
+
+	err = cr.reloadUnprotected()
+	if err != nil {
+		log.Warn("could not reload certificate, using the previous certificate", "error", err)
+		return cr.certificate, nil
+	}
+
+	log.Info("reloaded tls certificate", "certificate file", cr.certificateFile)
+	return cr.certificate, nil
+}
+
+func (cr *certificateReloader) reload() error {
+	cr.mut.Lock()
+	defer cr.mut.Unlock()
+
+	return cr.reloadUnprotected()
+}
+
+func (cr *certificateReloader) reloadUnprotected() error {
+	certModTime, keyModTime, err := cr.modTimes()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(cr.certificateFile, cr.keyFile)
+	if err != nil {
+		return err
+	}
+
+	cr.certificate = &certificate
+	cr.certModTime = certModTime
+	cr.keyModTime = keyModTime
+	cr.lastCheck = time.Now()
+
+	return nil
+}
+
+func (cr *certificateReloader) filesChanged() (bool, error) {
+	certModTime, keyModTime, err := cr.modTimes()
+	if err != nil {
+		return false, err
+	}
+
+	return !certModTime.Equal(cr.certModTime) || !keyModTime.Equal(cr.keyModTime), nil
+}
+
+func (cr *certificateReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(cr.certificateFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	keyInfo, err := os.Stat(cr.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (cr *certificateReloader) IsInterfaceNil() bool {
+	return cr == nil
+}
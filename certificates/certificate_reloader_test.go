@@ -0,0 +1,145 @@
+package certificates_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/certificates"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCertificateReloader(t *testing.T) {
+	t.Parallel()
+
+	certFile, keyFile := writeKeyPair(t, t.TempDir(), "localhost")
+
+	tests := []struct {
+		args        *certificates.ArgsCertificateReloader
+		expectedErr error
+	}{
+		{
+			args:        nil,
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args:        &certificates.ArgsCertificateReloader{KeyFile: keyFile},
+			expectedErr: covalent.ErrEmptyCertificateFile,
+		},
+		{
+			args:        &certificates.ArgsCertificateReloader{CertificateFile: certFile},
+			expectedErr: covalent.ErrEmptyKeyFile,
+		},
+		{
+			args:        &certificates.ArgsCertificateReloader{CertificateFile: certFile, KeyFile: keyFile},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		cr, err := certificates.NewCertificateReloader(currTest.args)
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(cr))
+	}
+}
+
+func TestNewCertificateReloader_MissingFiles_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	cr, err := certificates.NewCertificateReloader(&certificates.ArgsCertificateReloader{
+		CertificateFile: filepath.Join(dir, "missing.crt"),
+		KeyFile:         filepath.Join(dir, "missing.key"),
+	})
+	require.NotNil(t, err)
+	require.True(t, check.IfNil(cr))
+}
+
+func TestCertificateReloader_GetCertificate_FilesChanged_ExpectNewCertificate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile, keyFile := writeKeyPair(t, dir, "first")
+
+	cr, err := certificates.NewCertificateReloader(&certificates.ArgsCertificateReloader{
+		CertificateFile: certFile,
+		KeyFile:         keyFile,
+		CheckInterval:   time.Nanosecond,
+	})
+	require.Nil(t, err)
+
+	cert, err := cr.GetCertificate(nil)
+	require.Nil(t, err)
+	require.Equal(t, "first", parseCommonName(t, cert.Certificate[0]))
+
+	writeKeyPair(t, dir, "second")
+	future := time.Now().Add(time.Minute)
+	require.Nil(t, os.Chtimes(certFile, future, future))
+	require.Nil(t, os.Chtimes(keyFile, future, future))
+
+	cert, err = cr.GetCertificate(nil)
+	require.Nil(t, err)
+	require.Equal(t, "second", parseCommonName(t, cert.Certificate[0]))
+}
+
+func TestCertificateReloader_GetCertificate_InvalidNewFiles_ExpectPreviousCertificate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certFile, keyFile := writeKeyPair(t, dir, "first")
+
+	cr, _ := certificates.NewCertificateReloader(&certificates.ArgsCertificateReloader{
+		CertificateFile: certFile,
+		KeyFile:         keyFile,
+		CheckInterval:   time.Nanosecond,
+	})
+
+	require.Nil(t, os.WriteFile(certFile, []byte("invalid certificate"), 0600))
+	future := time.Now().Add(time.Minute)
+	require.Nil(t, os.Chtimes(certFile, future, future))
+
+	cert, err := cr.GetCertificate(nil)
+	require.Nil(t, err)
+	require.Equal(t, "first", parseCommonName(t, cert.Certificate[0]))
+}
+
+func writeKeyPair(t *testing.T, dir string, commonName string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.Nil(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.Nil(t, err)
+
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	require.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+
+	return certFile, keyFile
+}
+
+func parseCommonName(t *testing.T, der []byte) string {
+	cert, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+
+	return cert.Subject.CommonName
+}
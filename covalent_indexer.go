@@ -2,16 +2,24 @@ package covalent
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/numbatx/gn-coval-index/metrics"
 	"github.com/numbatx/gn-coval-index/process"
 	"github.com/numbatx/gn-coval-index/process/utility"
+	"github.com/numbatx/gn-core/core/check"
 	"github.com/numbatx/gn-core/data"
 	"github.com/numbatx/gn-core/data/indexer"
 	logger "github.com/numbatx/gn-logger"
+	"github.com/elodina/go-avro"
 	"github.com/gorilla/websocket"
 )
 
@@ -19,15 +27,110 @@ var log = logger.GetOrCreate("covalent")
 
 const RetrialTimeoutMS = 50
 
+// DefaultShutdownTimeout is the time given to the server to gracefully shut down, if no drain timeout is provided
+const DefaultShutdownTimeout = 5 * time.Second
+
+// ArgsCovalentDataIndexer holds all input dependencies required by covalent data indexer in order to create
+// a new instance. Outbox is optional: if not provided, SaveBlock waits until covalent acknowledges each block.
+// SendWindowSize is the maximum number of blocks sent without being acknowledged and can only be used with an outbox.
+// ChainID and ShardID are written in the envelope of each sent block result.
+// Block results are published to all provided Sinks, in order, and then to the websocket sink, which may block until
+// covalent acknowledges them. If DisableWebSocketSink is set, Server, Outbox and SendWindowSize are not used and at
+// least one other sink is required.
+// FailurePolicy defines how blocks which can not be processed or encoded are handled, Quarantine being required
+// only by FailurePolicyQuarantine.
+// DrainTimeout is the maximum time Close waits for blocks which were not yet acknowledged to be delivered, before
+// stopping all retries. No block is waited for if it is zero.
+// RetryPolicy defines how blocks which were not acknowledged are sent again. If not provided, blocks are sent again
+// every RetrialTimeoutMS until acknowledged. SpillSink, required only by RetryExhaustedSpill, receives blocks which
+// could not be delivered within the retries allowed by the policy. Outbox entries which were not spilled are kept
+// and sent again, with a new backoff.
+// StuckThreshold is the time blocks can wait to be acknowledged before the indexer is reported as not ready.
+// DefaultStuckThreshold is used if it is zero. Metrics is optional.
+// WriteTimeout and AckTimeout are the maximum times a block is written to covalent and its acknowledge is waited
+// for, no deadline being set if they are zero. A websocket which times out is closed and the block is sent again,
+// as allowed by the retry policy, once covalent reconnects.
+// If SendQueueSize is provided, SaveBlock only processes blocks and queues them, a dedicated sender encoding and
+// delivering them, in order. QueueFullPolicy defines what happens when the queue is full, SpillSink being required
+// by QueueFullSpill.
+// FinalityIndexSize is the number of recently saved blocks kept in memory, so that they can be notified as
+// finalized. DefaultFinalityIndexSize is used if it is zero
+type ArgsCovalentDataIndexer struct {
+	Processor            DataHandler
+	Server               *http.Server
+	Outbox               Outbox
+	SendWindowSize       int
+	ChainID              []byte
+	ShardID              uint32
+	Sinks                []Sink
+	DisableWebSocketSink bool
+	FailurePolicy        FailurePolicy
+	Quarantine           Quarantine
+	DrainTimeout         time.Duration
+	RetryPolicy          RetryPolicy
+	SpillSink            Sink
+	StuckThreshold       time.Duration
+	Metrics              MetricsHandler
+	WriteTimeout         time.Duration
+	AckTimeout           time.Duration
+	SendQueueSize        int
+	QueueFullPolicy      QueueFullPolicy
+	FinalityIndexSize    int
+}
+
+// outgoingRecord is a processed record, together with the block it refers to, waiting to be encoded and delivered.
+// The save block arguments are only set for block results, so that encoding failures apply the failure policy
+type outgoingRecord struct {
+	args   *indexer.ArgsSaveBlockData
+	record avro.AvroRecord
+	hash   []byte
+	nonce  uint64
+	round  uint64
+	epoch  uint32
+}
+
 type covalentIndexer struct {
-	processor        DataHandler
-	server           *http.Server
-	wss              process.WSConn
-	mutWSS           sync.RWMutex
-	wsr              process.WSConn
-	mutWSR           sync.RWMutex
-	newConnectionWSR chan struct{}
-	newConnectionWSS chan struct{}
+	processor         DataHandler
+	server            *http.Server
+	outbox            Outbox
+	sendWindowSize    int
+	chainID           []byte
+	shardID           uint32
+	sequenceNumber    uint64
+	mutSend           sync.Mutex
+	sinks             []Sink
+	failurePolicy     FailurePolicy
+	quarantine        Quarantine
+	drainTimeout      time.Duration
+	retryPolicy       RetryPolicy
+	spillSink         Sink
+	stuckThreshold    time.Duration
+	delivery          deliveryState
+	metrics           MetricsHandler
+	writeTimeout      time.Duration
+	ackTimeout        time.Duration
+	sendQueue         chan *outgoingRecord
+	queueFullPolicy   QueueFullPolicy
+	queuedBlocks      int64
+	sendQueueLoopDone chan struct{}
+	sendQueueHalted   chan struct{}
+	sendQueueErr      error
+	finalityIndex     *finalityIndex
+	lastSavedNonce    uint64
+	pendingSends      int64
+	newOutboxEntry    chan struct{}
+	ctx               context.Context
+	cancel            context.CancelFunc
+	outboxLoopDone    chan struct{}
+	closeOnce         sync.Once
+	wss               process.WSConn
+	wssFailed         bool
+	mutWSS            sync.RWMutex
+	wsr               process.WSConn
+	wsrFailed         bool
+	mutWSR            sync.RWMutex
+	newConnectionWSR  chan struct{}
+	newConnectionWSS  chan struct{}
 }
 
 // NewCovalentDataIndexer creates a new instance of covalent data indexer, which implements Driver interface and
@@ -35,47 +138,240 @@ type covalentIndexer struct {
 // TODO should refactor as to avoid using *http.Server here. For testing purposes we should use httptest.Server
 // Reason: all unit tests might fail, if for example, the machine that the tests run onto can not open the hardcoded port
 // written in the tests (might have it already open by another process)
-func NewCovalentDataIndexer(processor DataHandler, server *http.Server) (*covalentIndexer, error) {
-	if processor == nil {
+func NewCovalentDataIndexer(args *ArgsCovalentDataIndexer) (*covalentIndexer, error) {
+	if args == nil {
+		return nil, ErrNilArguments
+	}
+	if args.Processor == nil {
 		return nil, ErrNilDataHandler
 	}
-	if server == nil {
+	for _, sink := range args.Sinks {
+		if check.IfNil(sink) {
+			return nil, ErrNilSink
+		}
+	}
+	if !IsFailurePolicySupported(args.FailurePolicy) {
+		return nil, ErrUnsupportedFailurePolicy
+	}
+	if args.FailurePolicy == FailurePolicyQuarantine && check.IfNil(args.Quarantine) {
+		return nil, ErrNilQuarantine
+	}
+	if args.DrainTimeout < 0 {
+		return nil, ErrInvalidDrainTimeout
+	}
+	if args.StuckThreshold < 0 {
+		return nil, ErrInvalidStuckThreshold
+	}
+	if args.WriteTimeout < 0 {
+		return nil, ErrInvalidWriteTimeout
+	}
+	if args.AckTimeout < 0 {
+		return nil, ErrInvalidAckTimeout
+	}
+	err := checkSendQueueArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if args.FinalityIndexSize < 0 {
+		return nil, ErrInvalidFinalityIndexSize
+	}
+	if args.DisableWebSocketSink {
+		return newIndexerWithoutWebSocketSink(args)
+	}
+
+	retryPolicy, err := createRetryPolicy(args)
+	if err != nil {
+		return nil, err
+	}
+	if args.Server == nil {
 		return nil, ErrNilHTTPServer
 	}
+	if args.SendWindowSize < 0 {
+		return nil, ErrInvalidSendWindowSize
+	}
+	if args.SendWindowSize > 1 && check.IfNil(args.Outbox) {
+		return nil, ErrSendWindowWithoutOutbox
+	}
 	ci := &covalentIndexer{
-		processor: processor,
-		server:    server,
+		processor:      args.Processor,
+		server:         args.Server,
+		sendWindowSize: args.SendWindowSize,
+		chainID:        args.ChainID,
+		shardID:        args.ShardID,
+		failurePolicy:  args.FailurePolicy,
+		quarantine:     args.Quarantine,
+		drainTimeout:   args.DrainTimeout,
+		retryPolicy:    retryPolicy,
+		spillSink:      args.SpillSink,
+		stuckThreshold: args.StuckThreshold,
+		metrics:        createMetrics(args),
+		writeTimeout:   args.WriteTimeout,
+		ackTimeout:     args.AckTimeout,
+		finalityIndex:  newFinalityIndex(args.FinalityIndexSize),
 	}
-	ci.newConnectionWSR = make(chan struct{})
-	ci.newConnectionWSS = make(chan struct{})
+	if ci.stuckThreshold == 0 {
+		ci.stuckThreshold = DefaultStuckThreshold
+	}
+	ci.newConnectionWSR = make(chan struct{}, 1)
+	ci.newConnectionWSS = make(chan struct{}, 1)
+	ci.ctx, ci.cancel = context.WithCancel(context.Background())
+	ci.sinks = append(make([]Sink, 0, len(args.Sinks)+1), args.Sinks...)
 
 	go ci.start()
 
+	if !check.IfNil(args.Outbox) {
+		ci.outbox = args.Outbox
+		ci.newOutboxEntry = make(chan struct{}, 1)
+		ci.outboxLoopDone = make(chan struct{})
+		go ci.processOutbox()
+	} else {
+		// the websocket sink is the last one, since it may block until covalent acknowledges the record
+		ci.sinks = append(ci.sinks, &websocketSink{ci: ci})
+	}
+	ci.startSendQueue(args)
+
 	return ci, nil
 }
 
+// createRetryPolicy returns the provided retry policy or, if none, one which never gives up
+func createRetryPolicy(args *ArgsCovalentDataIndexer) (RetryPolicy, error) {
+	if check.IfNil(args.RetryPolicy) {
+		return NewRetryPolicy(&ArgsRetryPolicy{
+			InitialDelay: time.Millisecond * RetrialTimeoutMS,
+			Multiplier:   1,
+		})
+	}
+	if args.RetryPolicy.OnExhausted() == RetryExhaustedSpill && check.IfNil(args.SpillSink) {
+		return nil, ErrNilSpillSink
+	}
+
+	return args.RetryPolicy, nil
+}
+
+func createMetrics(args *ArgsCovalentDataIndexer) MetricsHandler {
+	if check.IfNil(args.Metrics) {
+		return metrics.NewDisabledMetrics()
+	}
+
+	return args.Metrics
+}
+
+func newIndexerWithoutWebSocketSink(args *ArgsCovalentDataIndexer) (*covalentIndexer, error) {
+	if len(args.Sinks) == 0 {
+		return nil, ErrNoSinkProvided
+	}
+	if !check.IfNil(args.Outbox) {
+		return nil, ErrOutboxWithoutWebSocketSink
+	}
+
+	ci := &covalentIndexer{
+		processor:     args.Processor,
+		chainID:       args.ChainID,
+		shardID:       args.ShardID,
+		sinks:         args.Sinks,
+		failurePolicy: args.FailurePolicy,
+		quarantine:    args.Quarantine,
+		drainTimeout:  args.DrainTimeout,
+		metrics:       createMetrics(args),
+		finalityIndex: newFinalityIndex(args.FinalityIndexSize),
+	}
+	ci.newConnectionWSR = make(chan struct{}, 1)
+	ci.newConnectionWSS = make(chan struct{}, 1)
+	ci.ctx, ci.cancel = context.WithCancel(context.Background())
+	ci.startSendQueue(args)
+
+	return ci, nil
+}
+
+// SetWSSender sets the websocket used to send data to covalent, closing the previous one(if it exists). A websocket
+// set after the indexer was closed is closed right away
 func (ci *covalentIndexer) SetWSSender(wss process.WSConn) {
 	ci.mutWSS.Lock()
-	if ci.wss != nil {
-		err := ci.wss.Close()
-		log.LogIfError(err)
+	if ci.ctx.Err() != nil {
+		ci.mutWSS.Unlock()
+		closeConnection(wss)
+		return
+	}
+	if ci.wss != nil || ci.wssFailed {
+		ci.metrics.IncrementReconnects(metrics.SocketSender)
 	}
+	closeConnection(ci.wss)
 	ci.wss = wss
+	ci.wssFailed = false
 	ci.mutWSS.Unlock()
 
-	ci.newConnectionWSS <- struct{}{}
+	notifyNewConnection(ci.newConnectionWSS)
 }
 
+// SetWSReceiver sets the websocket used to receive acknowledge data from covalent, closing the previous one(if it
+// exists). A websocket set after the indexer was closed is closed right away
 func (ci *covalentIndexer) SetWSReceiver(wsr process.WSConn) {
 	ci.mutWSR.Lock()
-	if ci.wsr != nil {
-		err := ci.wsr.Close()
-		log.LogIfError(err)
+	if ci.ctx.Err() != nil {
+		ci.mutWSR.Unlock()
+		closeConnection(wsr)
+		return
 	}
+	if ci.wsr != nil || ci.wsrFailed {
+		ci.metrics.IncrementReconnects(metrics.SocketReceiver)
+	}
+	closeConnection(ci.wsr)
 	ci.wsr = wsr
+	ci.wsrFailed = false
 	ci.mutWSR.Unlock()
 
-	ci.newConnectionWSR <- struct{}{}
+	notifyNewConnection(ci.newConnectionWSR)
+}
+
+// SetWSConnection sets a single websocket used both to send data and to receive acknowledge data from covalent,
+// closing the previous ones(if they exist). This way, sending and acknowledging can not go out of sync. A websocket
+// set after the indexer was closed is closed right away
+func (ci *covalentIndexer) SetWSConnection(ws process.WSConn) {
+	ci.mutWSS.Lock()
+	ci.mutWSR.Lock()
+	if ci.ctx.Err() != nil {
+		ci.mutWSR.Unlock()
+		ci.mutWSS.Unlock()
+		closeConnection(ws)
+		return
+	}
+	if ci.wss != nil || ci.wssFailed {
+		ci.metrics.IncrementReconnects(metrics.SocketSender)
+	}
+	if ci.wsr != nil || ci.wsrFailed {
+		ci.metrics.IncrementReconnects(metrics.SocketReceiver)
+	}
+	closeConnection(ci.wss)
+	if ci.wsr != ci.wss {
+		closeConnection(ci.wsr)
+	}
+	ci.wss = ws
+	ci.wsr = ws
+	ci.wssFailed = false
+	ci.wsrFailed = false
+	ci.mutWSR.Unlock()
+	ci.mutWSS.Unlock()
+
+	notifyNewConnection(ci.newConnectionWSS)
+	notifyNewConnection(ci.newConnectionWSR)
+}
+
+func closeConnection(ws process.WSConn) {
+	if ws == nil {
+		return
+	}
+
+	err := ws.Close()
+	log.LogIfError(err)
+}
+
+// notifyNewConnection signals a new connection without blocking. Multiple connections set before anyone
+// waits for them result in a single notification, since only the latest connection is used
+func notifyNewConnection(newConnection chan struct{}) {
+	select {
+	case newConnection <- struct{}{}:
+	default:
+	}
 }
 
 func (ci *covalentIndexer) getWSS() process.WSConn {
@@ -92,157 +388,608 @@ func (ci *covalentIndexer) getWSR() process.WSConn {
 	return ci.wsr
 }
 
-func (ci *covalentIndexer) waitForWSSConnection() {
-	for {
-		select {
-		case <-ci.newConnectionWSS:
-			return
-		}
+func (ci *covalentIndexer) start() {
+	var err error
+	if ci.server.TLSConfig != nil {
+		// certificate and key are provided by the server's TLS config
+		err = ci.server.ListenAndServeTLS("", "")
+	} else {
+		err = ci.server.ListenAndServe()
+	}
+	if err != nil && err != http.ErrServerClosed {
+		log.Error("could not initialize webserver", "error", err)
 	}
 }
 
-func (ci *covalentIndexer) waitForWSRConnection() {
+// sendWithRetrial sends the data until covalent acknowledges it, as allowed by the retry policy. Each time there is
+// no usable websocket connection counts as a failed attempt, so that the policy also gives up while covalent is
+// disconnected. A new connection ends the wait for the next attempt early. It returns ErrIndexerClosed if the indexer
+// was closed before the data was acknowledged and ErrRetriesExhausted if the policy gave up
+func (ci *covalentIndexer) sendWithRetrial(data []byte, ackData []byte) error {
+	ci.blockQueued(atomic.AddInt64(&ci.pendingSends, 1) == 1)
+	defer atomic.AddInt64(&ci.pendingSends, -1)
+
+	backoff := ci.retryPolicy.NewBackoff()
 	for {
+		wss, wsr := ci.getConnections()
+		connected := wss != nil && wsr != nil
+		if connected {
+			dataSent := ci.sendDataWithAcknowledge(data, ackData, wss, wsr)
+			if dataSent {
+				return nil
+			}
+		}
+
+		delay, canRetry := backoff.NextDelay()
+		if !canRetry {
+			log.Warn("could not send block data to covalent, retries exhausted",
+				"retries", backoff.Retries(),
+				"connected", connected,
+				"action", ci.retryPolicy.OnExhausted())
+			return ErrRetriesExhausted
+		}
+		log.Debug("block data not acknowledged, retrying",
+			"retry", backoff.Retries(), "delay", delay, "connected", connected)
+		ci.metrics.IncrementRetries()
+
 		select {
+		case <-time.After(delay):
+		case <-ci.newConnectionWSS:
 		case <-ci.newConnectionWSR:
-			return
+		case <-ci.ctx.Done():
+			return ErrIndexerClosed
 		}
 	}
 }
 
-func (ci *covalentIndexer) start() {
-	err := ci.server.ListenAndServe()
-	if err != nil {
-		log.Error("could not initialize webserver", "error", err)
+// getConnections returns the sender and receiver websockets, nil instead of any which is missing or failed
+func (ci *covalentIndexer) getConnections() (process.WSConn, process.WSConn) {
+	ci.mutWSS.RLock()
+	wss := ci.wss
+	if ci.wssFailed {
+		wss = nil
 	}
-}
-
-func (ci *covalentIndexer) sendWithRetrial(data []byte, ackData []byte) {
-	wss := ci.getWSS()
-	wsr := ci.getWSR()
+	ci.mutWSS.RUnlock()
 
-	if wss == nil {
-		ci.waitForWSSConnection()
-	}
-	if wsr == nil {
-		ci.waitForWSRConnection()
+	ci.mutWSR.RLock()
+	wsr := ci.wsr
+	if ci.wsrFailed {
+		wsr = nil
 	}
+	ci.mutWSR.RUnlock()
 
-	ticker := time.NewTicker(time.Millisecond * RetrialTimeoutMS)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ticker.C:
-			wss = ci.getWSS()
-			wsr = ci.getWSR()
-
-			if wss != nil && wsr != nil {
-				dataSent := ci.sendDataWithAcknowledge(data, ackData, wss, wsr)
-				if dataSent {
-					return
-				}
-			}
-		}
-	}
+	return wss, wsr
 }
 
+// sendDataWithAcknowledge writes the data and reads its acknowledge once. A failed write or read marks the websocket
+// as disconnected, so that the next attempt waits for a new connection
 func (ci *covalentIndexer) sendDataWithAcknowledge(
 	data []byte,
 	ackData []byte,
 	wss process.WSConn,
 	wsr process.WSConn,
 ) bool {
-	errSend := wss.WriteMessage(websocket.BinaryMessage, data)
+	errSend := ci.writeData(wss, data)
 	if errSend != nil {
-		log.Warn("could not send block data to covalent, waiting for new connection", "error", errSend)
-		ci.waitForWSSConnection()
+		log.Warn("could not send block data to covalent", "error", errSend)
+		return false
 	}
 
-	msgType, receivedData, errReadData := wsr.ReadMessage()
+	msgType, receivedData, errReadData := ci.readAcknowledge(wsr)
 	if errReadData != nil {
-		log.Warn("could not receive acknowledge data from covalent, waiting for new connection", "error", errReadData)
-		ci.waitForWSRConnection()
+		log.Warn("could not receive acknowledge data from covalent", "error", errReadData)
+		return false
 	}
 
-	if errSend == nil && errReadData == nil && msgType == websocket.BinaryMessage {
-		if bytes.Equal(receivedData, ackData) {
-			return true
-		}
+	return msgType == websocket.BinaryMessage && bytes.Equal(receivedData, ackData)
+}
+
+// writeData writes the data to covalent, within the write timeout if one is set. A failed write marks the
+// websocket as disconnected
+func (ci *covalentIndexer) writeData(wss process.WSConn, data []byte) error {
+	err := setDeadline(wss.SetWriteDeadline, ci.writeTimeout)
+	if err == nil {
+		err = wss.WriteMessage(websocket.BinaryMessage, data)
+	}
+	if err != nil {
+		ci.deliveryFailed(wss, metrics.OperationWrite, err)
+		return err
+	}
+
+	ci.metrics.IncrementSent()
+	return nil
+}
+
+// readAcknowledge reads the next acknowledge data from covalent, within the acknowledge timeout if one is set.
+// A failed read marks the websocket as disconnected
+func (ci *covalentIndexer) readAcknowledge(wsr process.WSConn) (int, []byte, error) {
+	err := setDeadline(wsr.SetReadDeadline, ci.ackTimeout)
+	if err != nil {
+		ci.deliveryFailed(wsr, metrics.OperationAck, err)
+		return 0, nil, err
+	}
+
+	msgType, ackData, err := wsr.ReadMessage()
+	if err != nil {
+		ci.deliveryFailed(wsr, metrics.OperationAck, err)
+		return 0, nil, err
+	}
+
+	return msgType, ackData, nil
+}
+
+// deliveryFailed counts the failed operation and marks the websocket as disconnected. A websocket which timed out
+// is also closed, since it can not be used anymore and covalent has to reconnect, even if the connection is half-open
+func (ci *covalentIndexer) deliveryFailed(ws process.WSConn, operation string, err error) {
+	if !isTimeout(err) {
+		ci.metrics.IncrementDeliveryErrors(operation)
+		ci.connectionFailed(ws)
+		return
+	}
+
+	log.Warn("covalent websocket timed out, closing connection", "operation", operation)
+	ci.metrics.IncrementTimeouts(operation)
+	ci.dropConnection(ws)
+}
+
+// setDeadline sets the deadline after the given timeout, if it is not zero
+func setDeadline(setDeadlineHandler func(t time.Time) error, timeout time.Duration) error {
+	if timeout == 0 {
+		return nil
 	}
 
-	return false
+	return setDeadlineHandler(time.Now().Add(timeout))
 }
 
-// SaveBlock saves the block info and converts it in order to be sent to covalent
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// SaveBlock saves the block info and converts it in order to be sent to covalent. A block which can not be
+// processed or encoded is handled according to the failure policy
 func (ci *covalentIndexer) SaveBlock(args *indexer.ArgsSaveBlockData) error {
+	err := ci.saveBlock(args)
+	if isHaltError(err) {
+		panic(err.Error())
+	}
+
+	return err
+}
+
+func (ci *covalentIndexer) saveBlock(args *indexer.ArgsSaveBlockData) error {
 	blockResult, err := ci.processor.ProcessData(args)
 	if err != nil {
-		log.Error("SaveBlock failed. Could not process block",
-			"error", err, "headerHash", hex.EncodeToString(args.HeaderHash))
-		panic("could not process block, check log")
+		return ci.handleFailure(args, processStage, err, ci.sendRecord)
 	}
 
-	dataToSend, err := utility.Encode(blockResult)
-	if err != nil {
-		log.Error("could not encode block result to binary data", "error", err)
-		panic("could not encode block result, check log")
+	ci.metrics.SetSavedNonce(uint64(blockResult.Block.Nonce))
+	atomic.StoreUint64(&ci.lastSavedNonce, uint64(blockResult.Block.Nonce))
+	block := blockResult.Block
+	ci.finalityIndex.add(block)
+	return ci.sendRecord(&outgoingRecord{
+		args:   args,
+		record: blockResult,
+		hash:   block.Hash,
+		nonce:  uint64(block.Nonce),
+		round:  uint64(block.Round),
+		epoch:  uint32(block.Epoch),
+	})
+}
+
+// sendRecord queues the record, if a send queue is used, otherwise sends it right away
+func (ci *covalentIndexer) sendRecord(item *outgoingRecord) error {
+	if ci.sendQueue != nil {
+		return ci.enqueue(item)
 	}
 
-	ci.sendWithRetrial(dataToSend, blockResult.Block.Hash)
+	return ci.send(item)
+}
+
+// send encodes the processed record and publishes it to all sinks. Messages are created and published under the
+// send lock, so that concurrent producers never share a sequence number nor publish to the sinks at the same time
+func (ci *covalentIndexer) send(item *outgoingRecord) error {
+	ci.mutSend.Lock()
+	message, err := ci.createMessage(item.record, item.hash, item.nonce, item.round, item.epoch)
+	if err != nil {
+		ci.mutSend.Unlock()
+		return ci.encodeFailed(item, err, ci.send)
+	}
 
 	// TODO next PRs - remove the retrial, it is done by the node
-	return nil
+	err = ci.publish(message)
+	ci.mutSend.Unlock()
+
+	return err
 }
 
-// RevertIndexedBlock returns nil
-func (ci *covalentIndexer) RevertIndexedBlock(data.HeaderHandler, data.BodyHandler) error {
-	return nil
+// encodeFailed handles a record which could not be encoded. A saved block is handled according to the failure
+// policy, its failure marker being delivered instead of it, while other records only return the error
+func (ci *covalentIndexer) encodeFailed(item *outgoingRecord, err error, deliver func(item *outgoingRecord) error) error {
+	if item.args == nil {
+		log.Error("could not encode record", "error", err, "schema", item.record.Schema().GetName(), "nonce", item.nonce)
+		return err
+	}
+
+	return ci.handleFailure(item.args, encodeStage, err, deliver)
 }
 
-// SaveRoundsInfo returns nil
-func (ci *covalentIndexer) SaveRoundsInfo(_ []*indexer.RoundInfo) error {
-	return nil
+// createMessage wraps the record in a stream message, within an envelope having the next sequence number. Messages
+// which are streamed must be created and published under the send lock
+func (ci *covalentIndexer) createMessage(
+	record avro.AvroRecord,
+	hash []byte,
+	nonce uint64,
+	round uint64,
+	epoch uint32,
+) (*SinkMessage, error) {
+	sequenceNumber := ci.nextSequenceNumber()
+	data, err := utility.EncodeStreamMessageWithEnvelope(record, sequenceNumber, ci.chainID, ci.shardID)
+	if err != nil {
+		return nil, err
+	}
+	ci.metrics.ObservePayloadSize(len(data))
+
+	return &SinkMessage{
+		SequenceNumber: sequenceNumber,
+		Nonce:          nonce,
+		Round:          round,
+		Epoch:          epoch,
+		Hash:           hash,
+		Record:         record,
+		Data:           data,
+	}, nil
 }
 
-// SaveValidatorsPubKeys returns nil
-func (ci *covalentIndexer) SaveValidatorsPubKeys(map[uint32][][]byte, uint32) error {
-	return nil
+// publish sends the message to all sinks, in order. A failing sink does not stop the message from being
+// published to the remaining ones, the last error being returned. If an outbox is used, the message is first stored
+// in it and, if it could not be, it is not published to any sink: its sequence number is the id of the next outbox
+// entry, which is only used once an entry is stored, so that it is not published again with another message
+func (ci *covalentIndexer) publish(message *SinkMessage) error {
+	if ci.outbox != nil {
+		err := ci.addToOutbox(message)
+		if err != nil {
+			return err
+		}
+	}
+
+	var lastErr error
+	for _, sink := range ci.sinks {
+		err := sink.Publish(message)
+		if err != nil {
+			log.Error("could not publish record",
+				"sink", fmt.Sprintf("%T", sink), "nonce", message.Nonce, "error", err)
+			lastErr = err
+		}
+	}
+	atomic.AddUint64(&ci.sequenceNumber, 1)
+
+	return lastErr
 }
 
-// SaveValidatorsRating returns nil
-func (ci *covalentIndexer) SaveValidatorsRating(string, []*indexer.ValidatorRatingInfo) error {
+// nextSequenceNumber returns the sequence number of the next sent block result. If an outbox is used, it is the
+// id of the next outbox entry, so that sequence numbers keep increasing after a restart
+func (ci *covalentIndexer) nextSequenceNumber() uint64 {
+	if ci.outbox != nil {
+		return ci.outbox.NextID()
+	}
+
+	return atomic.LoadUint64(&ci.sequenceNumber)
+}
+
+func (ci *covalentIndexer) addToOutbox(message *SinkMessage) error {
+	err := ci.outbox.Append(&OutboxEntry{
+		Nonce:   message.Nonce,
+		AckData: acknowledgeData(message),
+		Payload: message.Data,
+	})
+	if err != nil {
+		log.Error("could not store record in outbox", "error", err, "nonce", message.Nonce)
+		return err
+	}
+	ci.blockQueued(ci.outbox.Len() == 1)
+
+	select {
+	case ci.newOutboxEntry <- struct{}{}:
+	default:
+	}
+
 	return nil
 }
 
-// SaveAccounts returns nil
-func (ci *covalentIndexer) SaveAccounts(uint64, []data.UserAccountHandler) error {
+// spill publishes data which could not be delivered to covalent to the spill sink, if the retry policy requires it.
+// It returns ErrRetriesExhausted otherwise
+func (ci *covalentIndexer) spill(message *SinkMessage) error {
+	if ci.retryPolicy.OnExhausted() != RetryExhaustedSpill {
+		return ErrRetriesExhausted
+	}
+
+	err := ci.spillSink.Publish(message)
+	if err != nil {
+		log.Error("could not spill block data", "error", err, "nonce", message.Nonce)
+		return err
+	}
+
+	log.Warn("block data spilled", "nonce", message.Nonce, "sequence number", message.SequenceNumber)
 	return nil
 }
 
-// FinalizedBlock returns nil
-func (ci *covalentIndexer) FinalizedBlock(_ []byte) error {
+// spillOutboxEntry spills an outbox entry which could not be delivered. It returns false if the entry was not
+// spilled and must be kept in outbox
+func (ci *covalentIndexer) spillOutboxEntry(entry *OutboxEntry) bool {
+	err := ci.spill(&SinkMessage{
+		SequenceNumber: entry.ID,
+		Nonce:          entry.Nonce,
+		Hash:           entry.AckData,
+		Data:           entry.Payload,
+	})
+
+	return err == nil
+}
+
+// processOutbox sends outbox entries to covalent, in order, removing each of them only after it was acknowledged
+func (ci *covalentIndexer) processOutbox() {
+	defer close(ci.outboxLoopDone)
+
+	if ci.sendWindowSize > 1 {
+		ci.processOutboxWithWindow()
+		return
+	}
+
+	for {
+		if ci.outbox.Len() == 0 {
+			select {
+			case <-ci.newOutboxEntry:
+				continue
+			case <-ci.ctx.Done():
+				return
+			}
+		}
+
+		entry, err := ci.outbox.Get(0)
+		if err != nil {
+			log.Error("could not read entry from outbox", "error", err)
+			select {
+			case <-time.After(time.Millisecond * RetrialTimeoutMS):
+				continue
+			case <-ci.ctx.Done():
+				return
+			}
+		}
+
+		err = ci.sendWithRetrial(entry.Payload, entry.AckData)
+		switch {
+		case err == nil:
+			log.Trace("outbox entry acknowledged", "id", entry.ID, "nonce", entry.Nonce)
+			ci.blockAcknowledged(entry.AckData, entry.Nonce)
+		case err == ErrIndexerClosed:
+			return
+		case !ci.spillOutboxEntry(entry):
+			// retries exhausted, the entry is kept in outbox and sent again
+			continue
+		}
+
+		err = ci.outbox.RemoveHead()
+		if err != nil {
+			log.Error("could not remove delivered entry from outbox", "error", err, "id", entry.ID)
+		}
+	}
+}
+
+// RevertIndexedBlock notifies covalent that a previously saved block is no longer canonical, by sending a
+// schema.BlockRevert record in order with the saved blocks, so that the data ingested from it can be undone. The
+// record is acknowledged by its sequence number, since the hash acknowledges the reverted block itself
+func (ci *covalentIndexer) RevertIndexedBlock(header data.HeaderHandler, _ data.BodyHandler) error {
+	blockRevert, err := ci.processor.ProcessRevert(header)
+	if err != nil {
+		log.Error("could not process reverted block", "error", err)
+		return err
+	}
+
+	log.Debug("indexed block reverted", "nonce", blockRevert.Nonce, "hash", hex.EncodeToString(blockRevert.Hash))
+	ci.finalityIndex.take(blockRevert.Hash)
+	return ci.sendRecord(&outgoingRecord{
+		record: blockRevert,
+		nonce:  uint64(blockRevert.Nonce),
+		round:  uint64(blockRevert.Round),
+		epoch:  uint32(blockRevert.Epoch),
+	})
+}
+
+// SaveRoundsInfo sends the rounds info, as a single schema.RoundsInfo record, in order with the saved blocks. The
+// record is published with the nonce of the last saved block and is acknowledged by its sequence number
+func (ci *covalentIndexer) SaveRoundsInfo(roundsInfos []*indexer.RoundInfo) error {
+	roundsInfo := ci.processor.ProcessRoundsInfo(roundsInfos)
+	if roundsInfo == nil || len(roundsInfo.Rounds) == 0 {
+		return nil
+	}
+
+	lastRound := roundsInfo.Rounds[len(roundsInfo.Rounds)-1]
+	return ci.sendRecord(&outgoingRecord{
+		record: roundsInfo,
+		nonce:  atomic.LoadUint64(&ci.lastSavedNonce),
+		round:  uint64(lastRound.Round),
+		epoch:  uint32(lastRound.Epoch),
+	})
+}
+
+// SaveValidatorsPubKeys sends the validators public keys of the epoch, as one schema.EpochValidators record for each
+// shard, in order with the saved blocks. The records are published with the nonce of the last saved block and are
+// acknowledged by their sequence numbers
+func (ci *covalentIndexer) SaveValidatorsPubKeys(validatorsPubKeys map[uint32][][]byte, epoch uint32) error {
+	epochValidators := ci.processor.ProcessValidatorsPubKeys(validatorsPubKeys, epoch)
+	for _, shardValidators := range epochValidators {
+		err := ci.sendRecord(&outgoingRecord{
+			record: shardValidators,
+			nonce:  atomic.LoadUint64(&ci.lastSavedNonce),
+			epoch:  epoch,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// Close closes websocket connections(if they exist) as well as the server which listens for new connections
+// SaveValidatorsRating sends the validators ratings, as a single schema.ValidatorsRating record, in order with the
+// saved blocks. The record is published with the nonce of the last saved block and is acknowledged by its sequence
+// number
+func (ci *covalentIndexer) SaveValidatorsRating(indexID string, infoRating []*indexer.ValidatorRatingInfo) error {
+	validatorsRating, err := ci.processor.ProcessValidatorsRating(indexID, infoRating)
+	if err != nil {
+		log.Error("could not process validators rating", "indexID", indexID, "error", err)
+		return err
+	}
+	if validatorsRating == nil || len(validatorsRating.Ratings) == 0 {
+		return nil
+	}
+
+	return ci.sendRecord(&outgoingRecord{
+		record: validatorsRating,
+		nonce:  atomic.LoadUint64(&ci.lastSavedNonce),
+		epoch:  uint32(validatorsRating.Ratings[0].Epoch),
+	})
+}
+
+// SaveAccounts sends the state of the saved accounts, as a single schema.AccountsSnapshot record, in order with the
+// saved blocks. The record is published with the nonce of the last saved block and is acknowledged by its sequence
+// number
+func (ci *covalentIndexer) SaveAccounts(blockTimestamp uint64, accounts []data.UserAccountHandler) error {
+	accountsSnapshot := ci.processor.ProcessAccounts(blockTimestamp, accounts)
+	if accountsSnapshot == nil || len(accountsSnapshot.Accounts) == 0 {
+		return nil
+	}
+
+	return ci.sendRecord(&outgoingRecord{
+		record: accountsSnapshot,
+		nonce:  atomic.LoadUint64(&ci.lastSavedNonce),
+	})
+}
+
+// FinalizedBlock notifies covalent that a previously saved block is irreversible, by sending a
+// schema.BlockFinalized record in order with the saved blocks. Only blocks found in the finality index are
+// notified, blocks which were saved before a restart, evicted or already finalized being skipped. The record is
+// published with the nonce of the last saved block, so that consumers resuming from a nonce do not skip blocks, and
+// is acknowledged by its sequence number, since the hash acknowledges the finalized block itself
+func (ci *covalentIndexer) FinalizedBlock(headerHash []byte) error {
+	blockFinalized, found := ci.finalityIndex.take(headerHash)
+	if !found {
+		log.Debug("finalized block not found in finality index, skipped", "hash", hex.EncodeToString(headerHash))
+		return nil
+	}
+
+	return ci.sendRecord(&outgoingRecord{
+		record: blockFinalized,
+		nonce:  atomic.LoadUint64(&ci.lastSavedNonce),
+		round:  uint64(blockFinalized.Round),
+		epoch:  uint32(blockFinalized.Epoch),
+	})
+}
+
+// Close waits, at most the drain timeout, for all blocks to be acknowledged, then stops all retries and closes all
+// sinks, websocket connections(if they exist), the outbox(if used) as well as the server which listens for new
+// connections, gracefully. Outbox entries which were not yet acknowledged are kept and sent after the next start
 func (ci *covalentIndexer) Close() error {
+	var err error
+	ci.closeOnce.Do(func() {
+		err = ci.close()
+	})
+
+	return err
+}
+
+func (ci *covalentIndexer) close() error {
+	unsentBlocks := ci.drain()
+	if unsentBlocks > 0 {
+		log.Warn("covalent indexer closed with unsent blocks", "unsent blocks", unsentBlocks, "outbox", ci.outbox != nil)
+	} else {
+		log.Debug("covalent indexer closed, all blocks were sent")
+	}
+
+	ci.cancel()
+	// closing the websockets unblocks a delivery waiting for an acknowledge which never comes. No websocket can be set
+	// anymore, once the indexer is closed
 	wss := ci.getWSS()
 	wsr := ci.getWSR()
 
-	if wss != nil {
-		err := wss.Close()
+	closeConnection(wss)
+	if wsr != wss {
+		closeConnection(wsr)
+	}
+
+	if ci.sendQueue != nil {
+		// a block taken out of the queue is published to the sinks until the sender stops
+		<-ci.sendQueueLoopDone
+	}
+
+	if ci.outbox != nil {
+		// outbox entries are used by the delivery loop until it stops
+		<-ci.outboxLoopDone
+		err := ci.outbox.Close()
 		log.LogIfError(err)
 	}
-	if wsr != nil {
-		err := wsr.Close()
+
+	for _, sink := range ci.sinks {
+		err := sink.Close()
+		log.LogIfError(err)
+	}
+	if !check.IfNil(ci.spillSink) {
+		err := ci.spillSink.Close()
 		log.LogIfError(err)
 	}
 
-	if ci.server != nil {
-		return ci.server.Close()
+	if ci.server == nil {
+		return nil
 	}
-	return nil
+
+	shutdownTimeout := ci.drainTimeout
+	if shutdownTimeout == 0 {
+		shutdownTimeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	return ci.server.Shutdown(ctx)
+}
+
+// drain waits until all blocks are acknowledged or the drain timeout expires. It returns the number of blocks
+// which were not acknowledged
+func (ci *covalentIndexer) drain() int {
+	if ci.drainTimeout == 0 {
+		return ci.unsentBlocks()
+	}
+
+	timeout := time.After(ci.drainTimeout)
+	ticker := time.NewTicker(time.Millisecond * RetrialTimeoutMS)
+	defer ticker.Stop()
+
+	for {
+		unsentBlocks := ci.unsentBlocks()
+		if unsentBlocks == 0 {
+			return 0
+		}
+
+		select {
+		case <-ticker.C:
+		case <-timeout:
+			return unsentBlocks
+		}
+	}
+}
+
+// unsentBlocks returns the number of blocks waiting to be acknowledged
+func (ci *covalentIndexer) unsentBlocks() int {
+	queuedBlocks := int(atomic.LoadInt64(&ci.queuedBlocks))
+	if ci.outbox != nil {
+		return queuedBlocks + ci.outbox.Len()
+	}
+	if ci.sendQueue != nil {
+		// blocks sent without an outbox are taken out of the queue only after being acknowledged
+		return queuedBlocks
+	}
+
+	return int(atomic.LoadInt64(&ci.pendingSends))
 }
 
 // IsInterfaceNil returns true if there is no value under the interface
@@ -1,46 +1,211 @@
 package covalent_test
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/metrics"
+	"github.com/numbatx/gn-coval-index/outbox"
+	"github.com/numbatx/gn-coval-index/process/utility"
 	"github.com/numbatx/gn-coval-index/schema"
 	"github.com/numbatx/gn-coval-index/testscommon"
 	"github.com/numbatx/gn-coval-index/testscommon/mock"
 	"github.com/numbatx/gn-core/core/atomic"
 	"github.com/numbatx/gn-core/core/check"
+	"github.com/numbatx/gn-core/data"
+	"github.com/numbatx/gn-core/data/block"
 	"github.com/numbatx/gn-core/data/indexer"
 	"github.com/gorilla/websocket"
-	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func TestNewCovalentDataIndexer(t *testing.T) {
 	tests := []struct {
-		args        func() (processor covalent.DataHandler, server *http.Server)
+		args        func() *covalent.ArgsCovalentDataIndexer
 		expectedErr error
 		isNil       bool
 	}{
 		{
-			args: func() (processor covalent.DataHandler, server *http.Server) {
-				return nil, &http.Server{Addr: "localhost:22111"}
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor: nil,
+					Server:    &http.Server{Addr: "localhost:22111"},
+				}
 			},
 			expectedErr: covalent.ErrNilDataHandler,
 			isNil:       true,
 		},
 		{
-			args: func() (processor covalent.DataHandler, server *http.Server) {
-				return &mock.DataHandlerStub{}, nil
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor: &mock.DataHandlerStub{},
+					Server:    nil,
+				}
 			},
 			expectedErr: covalent.ErrNilHTTPServer,
 			isNil:       true,
 		},
 		{
-			args: func() (processor covalent.DataHandler, server *http.Server) {
-				return &mock.DataHandlerStub{}, &http.Server{Addr: "localhost:22112"}
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:      &mock.DataHandlerStub{},
+					Server:         &http.Server{Addr: "localhost:22111"},
+					SendWindowSize: -1,
+				}
+			},
+			expectedErr: covalent.ErrInvalidSendWindowSize,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:      &mock.DataHandlerStub{},
+					Server:         &http.Server{Addr: "localhost:22111"},
+					SendWindowSize: 2,
+				}
+			},
+			expectedErr: covalent.ErrSendWindowWithoutOutbox,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor: &mock.DataHandlerStub{},
+					Server:    &http.Server{Addr: "localhost:22111"},
+					Sinks:     []covalent.Sink{&mock.SinkStub{}, nil},
+				}
+			},
+			expectedErr: covalent.ErrNilSink,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:     &mock.DataHandlerStub{},
+					Server:        &http.Server{Addr: "localhost:22111"},
+					FailurePolicy: "retry",
+				}
+			},
+			expectedErr: covalent.ErrUnsupportedFailurePolicy,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:     &mock.DataHandlerStub{},
+					Server:        &http.Server{Addr: "localhost:22111"},
+					FailurePolicy: covalent.FailurePolicyQuarantine,
+				}
+			},
+			expectedErr: covalent.ErrNilQuarantine,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:    &mock.DataHandlerStub{},
+					Server:       &http.Server{Addr: "localhost:22111"},
+					DrainTimeout: -time.Second,
+				}
+			},
+			expectedErr: covalent.ErrInvalidDrainTimeout,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:    &mock.DataHandlerStub{},
+					Server:       &http.Server{Addr: "localhost:22111"},
+					WriteTimeout: -time.Second,
+				}
+			},
+			expectedErr: covalent.ErrInvalidWriteTimeout,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:  &mock.DataHandlerStub{},
+					Server:     &http.Server{Addr: "localhost:22111"},
+					AckTimeout: -time.Second,
+				}
+			},
+			expectedErr: covalent.ErrInvalidAckTimeout,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				retryPolicy, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+					InitialDelay: time.Millisecond,
+					Multiplier:   1,
+					OnExhausted:  covalent.RetryExhaustedSpill,
+				})
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:   &mock.DataHandlerStub{},
+					Server:      &http.Server{Addr: "localhost:22111"},
+					RetryPolicy: retryPolicy,
+				}
+			},
+			expectedErr: covalent.ErrNilSpillSink,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:            &mock.DataHandlerStub{},
+					DisableWebSocketSink: true,
+				}
+			},
+			expectedErr: covalent.ErrNoSinkProvided,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				blocksOutbox, _ := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:            &mock.DataHandlerStub{},
+					Outbox:               blocksOutbox,
+					Sinks:                []covalent.Sink{&mock.SinkStub{}},
+					DisableWebSocketSink: true,
+				}
+			},
+			expectedErr: covalent.ErrOutboxWithoutWebSocketSink,
+			isNil:       true,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor:            &mock.DataHandlerStub{},
+					Sinks:                []covalent.Sink{&mock.SinkStub{}},
+					DisableWebSocketSink: true,
+				}
+			},
+			expectedErr: nil,
+			isNil:       false,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				return &covalent.ArgsCovalentDataIndexer{
+					Processor: &mock.DataHandlerStub{},
+					Server:    &http.Server{Addr: "localhost:22112"},
+				}
 			},
 			expectedErr: nil,
 			isNil:       false,
@@ -56,11 +221,12 @@ func TestNewCovalentDataIndexer(t *testing.T) {
 
 func TestCovalentIndexer_SetWSSender_SetTwoConsecutiveWebSockets_ExpectFirstOneClosed(t *testing.T) {
 	ci, _ := covalent.NewCovalentDataIndexer(
-		&mock.DataHandlerStub{},
-		&http.Server{
-			Addr: "localhost:21119",
-		},
-	)
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
 	defer func() {
 		_ = ci.Close()
 	}()
@@ -100,11 +266,12 @@ func TestCovalentIndexer_SetWSSender_SetTwoConsecutiveWebSockets_ExpectFirstOneC
 
 func TestCovalentIndexer_SetWSReceiver_SetTwoConsecutiveWebSockets_ExpectFirstOneClosed(t *testing.T) {
 	ci, _ := covalent.NewCovalentDataIndexer(
-		&mock.DataHandlerStub{},
-		&http.Server{
-			Addr: "localhost:21119",
-		},
-	)
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
 	defer func() {
 		_ = ci.Close()
 	}()
@@ -142,17 +309,103 @@ func TestCovalentIndexer_SetWSReceiver_SetTwoConsecutiveWebSockets_ExpectFirstOn
 	require.False(t, called2.IsSet())
 }
 
-func TestCovalentIndexer_SaveBlock_ErrorProcessingData_ExpectPanic(t *testing.T) {
+func TestCovalentIndexer_SetWSConnection_ExpectPreviousConnectionsClosedOnce(t *testing.T) {
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	closedCt1 := atomic.Counter{}
+	ws1 := &mock.WSConnStub{
+		CloseCalled: func() error {
+			closedCt1.Increment()
+			return nil
+		},
+	}
+
+	closedCt2 := atomic.Counter{}
+	ws2 := &mock.WSConnStub{
+		CloseCalled: func() error {
+			closedCt2.Increment()
+			return nil
+		},
+	}
+
+	ci.SetWSConnection(ws1)
+	require.Equal(t, int64(0), closedCt1.Get())
+
+	ci.SetWSConnection(ws2)
+	require.Equal(t, int64(1), closedCt1.Get())
+	require.Equal(t, int64(0), closedCt2.Get())
+}
+
+func TestCovalentIndexer_SaveBlock_SingleConnection_ExpectSuccess(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+
 	ci, _ := covalent.NewCovalentDataIndexer(
-		&mock.DataHandlerStub{
-			ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
-				return nil, errors.New("local error")
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
 			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	writeCt := atomic.Counter{}
+	readCt := atomic.Counter{}
+	ws := &mock.WSConnStub{
+		WriteMessageCalled: func(messageType int, data []byte) error {
+			writeCt.Increment()
+			return nil
 		},
-		&http.Server{
-			Addr: "localhost:3333",
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			readCt.Increment()
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
 		},
-	)
+	}
+
+	saveBlockDone := atomic.Flag{}
+	go func() {
+		err := ci.SaveBlock(nil)
+		require.Nil(t, err)
+		_ = saveBlockDone.SetReturningPrevious()
+	}()
+
+	time.Sleep(time.Millisecond * 200)
+	require.False(t, saveBlockDone.IsSet())
+
+	go ci.SetWSConnection(ws)
+	time.Sleep(time.Millisecond * 200)
+
+	require.True(t, saveBlockDone.IsSet())
+	require.Equal(t, int64(1), writeCt.Get())
+	require.Equal(t, int64(1), readCt.Get())
+}
+
+func TestCovalentIndexer_SaveBlock_ErrorProcessingData_ExpectPanic(t *testing.T) {
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return nil, errors.New("local error")
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:3333",
+			},
+		})
 	defer func() {
 		_ = ci.Close()
 	}()
@@ -162,15 +415,16 @@ func TestCovalentIndexer_SaveBlock_ErrorProcessingData_ExpectPanic(t *testing.T)
 
 func TestCovalentIndexer_SaveBlock_ErrorEncodingBlockRes_ExpectPanic(t *testing.T) {
 	ci, _ := covalent.NewCovalentDataIndexer(
-		&mock.DataHandlerStub{
-			ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
-				return nil, nil
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return nil, nil
+				},
 			},
-		},
-		&http.Server{
-			Addr: "localhost:21119",
-		},
-	)
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
 	defer func() {
 		_ = ci.Close()
 	}()
@@ -178,17 +432,93 @@ func TestCovalentIndexer_SaveBlock_ErrorEncodingBlockRes_ExpectPanic(t *testing.
 	require.Panics(t, func() { _ = ci.SaveBlock(nil) })
 }
 
+func TestCovalentIndexer_SaveBlock_ErrorProcessingData_FailurePolicies(t *testing.T) {
+	errProcess := errors.New("error processing data")
+	args := &indexer.ArgsSaveBlockData{
+		HeaderHash: []byte("hash"),
+		Header:     &block.Header{Nonce: 4, Round: 5, Epoch: 6, ShardID: 1},
+	}
+
+	createIndexer := func(policy covalent.FailurePolicy, sink covalent.Sink, quarantine covalent.Quarantine) covalent.Driver {
+		ci, err := covalent.NewCovalentDataIndexer(
+			&covalent.ArgsCovalentDataIndexer{
+				Processor: &mock.DataHandlerStub{
+					ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+						return nil, errProcess
+					},
+				},
+				Sinks:                []covalent.Sink{sink},
+				DisableWebSocketSink: true,
+				FailurePolicy:        policy,
+				Quarantine:           quarantine,
+			})
+		require.Nil(t, err)
+
+		return ci
+	}
+
+	publishedMessages := make([]*covalent.SinkMessage, 0)
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			publishedMessages = append(publishedMessages, message)
+			return nil
+		},
+	}
+	quarantinedBlocks := make([]*indexer.ArgsSaveBlockData, 0)
+	quarantine := &mock.QuarantineStub{
+		StoreCalled: func(args *indexer.ArgsSaveBlockData, cause error) error {
+			require.Equal(t, errProcess, cause)
+			quarantinedBlocks = append(quarantinedBlocks, args)
+			return nil
+		},
+	}
+
+	ci := createIndexer(covalent.FailurePolicyHalt, sink, quarantine)
+	require.Panics(t, func() { _ = ci.SaveBlock(args) })
+
+	ci = createIndexer(covalent.FailurePolicyError, sink, quarantine)
+	require.Equal(t, errProcess, ci.SaveBlock(args))
+
+	ci = createIndexer(covalent.FailurePolicyQuarantine, sink, quarantine)
+	require.Nil(t, ci.SaveBlock(args))
+	require.Equal(t, []*indexer.ArgsSaveBlockData{args}, quarantinedBlocks)
+	require.Empty(t, publishedMessages)
+
+	ci = createIndexer(covalent.FailurePolicySkip, sink, quarantine)
+	require.Nil(t, ci.SaveBlock(args))
+	require.Len(t, publishedMessages, 1)
+
+	expectedMarker := &schema.BlockProcessingFailed{
+		Hash:    []byte("hash"),
+		Nonce:   4,
+		Round:   5,
+		Epoch:   6,
+		ShardID: 1,
+		Stage:   "process",
+		Error:   errProcess.Error(),
+	}
+	require.Equal(t, expectedMarker, publishedMessages[0].Record)
+	require.Equal(t, []byte("hash"), publishedMessages[0].Hash)
+	require.Equal(t, uint64(4), publishedMessages[0].Nonce)
+
+	record, _, err := utility.DecodeStreamMessageWithEnvelope(publishedMessages[0].Data)
+	require.Nil(t, err)
+	require.Equal(t, expectedMarker, record)
+}
+
 func TestCovalentIndexer_SaveBlock_ExpectSuccess(t *testing.T) {
 	blockRes := generateRandomValidBlockResult()
 
 	ci, _ := covalent.NewCovalentDataIndexer(
-		&mock.DataHandlerStub{
-			ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
-				return blockRes, nil
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
 			},
-		},
-		&http.Server{
-			Addr: "localhost:21119",
 		})
 	defer func() {
 		_ = ci.Close()
@@ -210,13 +540,9 @@ func TestCovalentIndexer_SaveBlock_ExpectSuccess(t *testing.T) {
 		},
 	}
 
+	saveBlockErr := make(chan error, 1)
 	go func() {
-		err := ci.SaveBlock(nil)
-
-		// Expect data is sent/received only after WSS & WSR are set
-		require.True(t, wssCalled.IsSet())
-		require.True(t, wsrCalled.IsSet())
-		require.Nil(t, err)
+		saveBlockErr <- ci.SaveBlock(nil)
 	}()
 
 	time.Sleep(time.Millisecond * 200)
@@ -227,19 +553,181 @@ func TestCovalentIndexer_SaveBlock_ExpectSuccess(t *testing.T) {
 	go ci.SetWSSender(wss)
 	go ci.SetWSReceiver(wsr)
 	time.Sleep(time.Millisecond * 200)
+
+	select {
+	case err := <-saveBlockErr:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "SaveBlock did not return")
+	}
+
+	// Expect data is sent/received only after WSS & WSR are set
+	require.True(t, wssCalled.IsSet())
+	require.True(t, wsrCalled.IsSet())
 }
 
-func TestCovalentIndexer_SaveBlock_WrongAcknowledgedDataFourTimes_ExpectSuccessAfterFourRetrials(t *testing.T) {
+func TestCovalentIndexer_SaveBlock_ExpectDataSentInEnvelope(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			ChainID: []byte("1"),
+			ShardID: 2,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	envelopes := make([]*schema.Envelope, 0)
+	wss := &mock.WSConnStub{
+		WriteMessageCalled: func(messageType int, data []byte) error {
+			_, envelope, err := utility.DecodeStreamMessageWithEnvelope(data)
+			require.Nil(t, err)
+
+			envelopes = append(envelopes, envelope)
+			return nil
+		},
+	}
+	wsr := &mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
+		},
+	}
+
+	ci.SetWSSender(wss)
+	ci.SetWSReceiver(wsr)
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.SaveBlock(nil))
+
+	require.Len(t, envelopes, 2)
+	for idx, envelope := range envelopes {
+		require.Equal(t, int64(idx), envelope.SequenceNumber)
+		require.Equal(t, []byte("1"), envelope.ChainID)
+		require.Equal(t, int32(2), envelope.ShardID)
+	}
+}
+
+func TestCovalentIndexer_SaveBlock_WebSocketSinkDisabled_ExpectPublishedToAllSinks(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+	errPublish := errors.New("publish error")
+
+	publishedMessages := make([]*covalent.SinkMessage, 0)
+	sinkClosedCt := atomic.Counter{}
+	failingSink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			return errPublish
+		},
+		CloseCalled: func() error {
+			sinkClosedCt.Increment()
+			return nil
+		},
+	}
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			publishedMessages = append(publishedMessages, message)
+			return nil
+		},
+		CloseCalled: func() error {
+			sinkClosedCt.Increment()
+			return nil
+		},
+	}
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Sinks:                []covalent.Sink{failingSink, sink},
+			DisableWebSocketSink: true,
+		})
+
+	require.Equal(t, errPublish, ci.SaveBlock(nil))
+	require.Equal(t, errPublish, ci.SaveBlock(nil))
+
+	require.Len(t, publishedMessages, 2)
+	for idx, message := range publishedMessages {
+		require.Equal(t, uint64(idx), message.SequenceNumber)
+		require.Equal(t, blockRes, message.Record)
+		require.Equal(t, blockRes.Block.Hash, message.Hash)
+
+		record, _, err := utility.DecodeStreamMessageWithEnvelope(message.Data)
+		require.Nil(t, err)
+		require.IsType(t, &schema.BlockResult{}, record)
+		decodedBlockRes := record.(*schema.BlockResult)
+		require.Equal(t, blockRes.Block.Hash, decodedBlockRes.Block.Hash)
+	}
+
+	require.Nil(t, ci.Close())
+	require.Equal(t, int64(2), sinkClosedCt.Get())
+}
+
+func TestCovalentIndexer_SaveBlock_WebSocketNotConnected_ExpectPublishedToOtherSinksFirst(t *testing.T) {
 	blockRes := generateRandomValidBlockResult()
 
+	published := make(chan *covalent.SinkMessage, 1)
 	ci, _ := covalent.NewCovalentDataIndexer(
-		&mock.DataHandlerStub{
-			ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
-				return blockRes, nil
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
 			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Sinks: []covalent.Sink{&mock.SinkStub{
+				PublishCalled: func(message *covalent.SinkMessage) error {
+					published <- message
+					return nil
+				},
+			}},
+		})
+
+	saved := make(chan error)
+	go func() {
+		saved <- ci.SaveBlock(nil)
+	}()
+
+	select {
+	case message := <-published:
+		require.Equal(t, blockRes.Block.Hash, message.Hash)
+	case <-time.After(time.Second):
+		require.Fail(t, "sink was blocked by the websocket sink waiting for covalent")
+	}
+
+	ci.SetWSConnection(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
 		},
-		&http.Server{
-			Addr: "localhost:21119",
+	})
+	require.Nil(t, <-saved)
+	require.Nil(t, ci.Close())
+}
+
+func TestCovalentIndexer_SaveBlock_WrongAcknowledgedDataFourTimes_ExpectSuccessAfterFourRetrials(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
 		})
 	defer func() {
 		_ = ci.Close()
@@ -265,13 +753,9 @@ func TestCovalentIndexer_SaveBlock_WrongAcknowledgedDataFourTimes_ExpectSuccessA
 		},
 	}
 
+	saveBlockErr := make(chan error, 1)
 	go func() {
-		err := ci.SaveBlock(nil)
-
-		// Expect data is sent/received 4 times (until a correct ack msg is sent) after WSS & WSR are set
-		require.Equal(t, wssCalledCt.Get(), int64(4))
-		require.Equal(t, wsrCalledCt.Get(), int64(4))
-		require.Nil(t, err)
+		saveBlockErr <- ci.SaveBlock(nil)
 	}()
 
 	time.Sleep(time.Millisecond * 200)
@@ -282,19 +766,32 @@ func TestCovalentIndexer_SaveBlock_WrongAcknowledgedDataFourTimes_ExpectSuccessA
 	go ci.SetWSSender(wss)
 	go ci.SetWSReceiver(wsr)
 	time.Sleep(time.Millisecond * 200)
+
+	select {
+	case err := <-saveBlockErr:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "SaveBlock did not return")
+	}
+
+	// Expect data is sent/received 4 times (until a correct ack msg is sent) after WSS & WSR are set
+	require.Equal(t, wssCalledCt.Get(), int64(4))
+	require.Equal(t, wsrCalledCt.Get(), int64(4))
 }
 
 func TestCovalentIndexer_SaveBlock_ErrorAcknowledgeData_ReconnectedWSR_ExpectMessageResent(t *testing.T) {
 	blockRes := generateRandomValidBlockResult()
 
 	ci, _ := covalent.NewCovalentDataIndexer(
-		&mock.DataHandlerStub{
-			ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
-				return blockRes, nil
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
 			},
-		},
-		&http.Server{
-			Addr: "localhost:21119",
 		})
 	defer func() {
 		_ = ci.Close()
@@ -317,13 +814,9 @@ func TestCovalentIndexer_SaveBlock_ErrorAcknowledgeData_ReconnectedWSR_ExpectMes
 	}
 
 	wsrReconnectedCalledCt := atomic.Counter{}
+	saveBlockErr := make(chan error, 1)
 	go func() {
-		err := ci.SaveBlock(nil)
-
-		require.Equal(t, int64(2), wssCalledCt.Get())
-		require.Equal(t, int64(1), wsrCalledCt.Get())
-		require.Equal(t, int64(1), wsrReconnectedCalledCt.Get())
-		require.Nil(t, err)
+		saveBlockErr <- ci.SaveBlock(nil)
 	}()
 
 	time.Sleep(time.Millisecond * 200)
@@ -344,19 +837,32 @@ func TestCovalentIndexer_SaveBlock_ErrorAcknowledgeData_ReconnectedWSR_ExpectMes
 
 	go ci.SetWSReceiver(wsrReconnected)
 	time.Sleep(time.Millisecond * 200)
+
+	select {
+	case err := <-saveBlockErr:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "SaveBlock did not return")
+	}
+
+	require.Equal(t, int64(2), wssCalledCt.Get())
+	require.Equal(t, int64(1), wsrCalledCt.Get())
+	require.Equal(t, int64(1), wsrReconnectedCalledCt.Get())
 }
 
 func TestCovalentIndexer_SaveBlock_WrongAcknowledgeThreeTimes_ErrorSendingBlockTwoTimes_ExpectSuccessAfterNewWSSConnection(t *testing.T) {
 	blockRes := generateRandomValidBlockResult()
 
 	ci, _ := covalent.NewCovalentDataIndexer(
-		&mock.DataHandlerStub{
-			ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
-				return blockRes, nil
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
 			},
-		},
-		&http.Server{
-			Addr: "localhost:21119",
 		})
 	defer func() {
 		_ = ci.Close()
@@ -386,13 +892,9 @@ func TestCovalentIndexer_SaveBlock_WrongAcknowledgeThreeTimes_ErrorSendingBlockT
 
 	wss2Called := atomic.Flag{}
 
+	saveBlockErr := make(chan error, 1)
 	go func() {
-		err := ci.SaveBlock(nil)
-
-		require.Equal(t, int64(2), wssCalledCt1.Get())
-		require.Equal(t, int64(3), wsrCalledCt1.Get())
-		require.True(t, wss2Called.IsSet())
-		require.Nil(t, err)
+		saveBlockErr <- ci.SaveBlock(nil)
 	}()
 
 	time.Sleep(time.Millisecond * 200)
@@ -413,33 +915,1314 @@ func TestCovalentIndexer_SaveBlock_WrongAcknowledgeThreeTimes_ErrorSendingBlockT
 
 	go ci.SetWSSender(wss2)
 	time.Sleep(time.Millisecond * 500)
-}
 
-func generateRandomValidBlockResult() *schema.BlockResult {
-	block := &schema.Block{
-		Hash:          testscommon.GenerateRandomFixedBytes(32),
-		StateRootHash: testscommon.GenerateRandomFixedBytes(32),
+	select {
+	case err := <-saveBlockErr:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "SaveBlock did not return")
 	}
 
-	return &schema.BlockResult{
-		Block: block,
-	}
+	require.Equal(t, int64(2), wssCalledCt1.Get())
+	require.Equal(t, int64(3), wsrCalledCt1.Get())
+	require.True(t, wss2Called.IsSet())
 }
 
-func TestCovalentDataIndexer_UnimplementedFunctions(t *testing.T) {
+func TestCovalentIndexer_SaveBlock_WithOutbox_ExpectReturnBeforeAcknowledgeAndDeliveredInOrder(t *testing.T) {
+	blockRes1 := generateRandomValidBlockResult()
+	blockRes2 := generateRandomValidBlockResult()
+	blockResults := []*schema.BlockResult{blockRes1, blockRes2}
+
+	blocksOutbox, err := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+	require.Nil(t, err)
+
+	processedCt := atomic.Counter{}
 	ci, _ := covalent.NewCovalentDataIndexer(
-		&mock.DataHandlerStub{},
-		&http.Server{
-			Addr: "localhost:21119",
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					blockRes := blockResults[processedCt.Get()]
+					processedCt.Increment()
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Outbox: blocksOutbox,
 		})
 	defer func() {
 		_ = ci.Close()
 	}()
 
-	assert.Nil(t, ci.RevertIndexedBlock(nil, nil))
-	assert.Nil(t, ci.SaveRoundsInfo(nil))
-	assert.Nil(t, ci.SaveValidatorsPubKeys(nil, 0))
-	assert.Nil(t, ci.SaveValidatorsRating("", nil))
-	assert.Nil(t, ci.SaveAccounts(0, nil))
-	assert.Nil(t, ci.FinalizedBlock(nil))
+	// No websocket is connected, but blocks are stored in outbox
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Equal(t, 2, blocksOutbox.Len())
+
+	lastSentData := make(chan []byte, 1)
+	sentBlocks := make([][]byte, 0)
+	wss := &mock.WSConnStub{
+		WriteMessageCalled: func(messageType int, data []byte) error {
+			lastSentData <- data
+			return nil
+		},
+	}
+	wsr := &mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			record, envelope, errDecode := utility.DecodeStreamMessageWithEnvelope(<-lastSentData)
+			require.Nil(t, errDecode)
+			require.IsType(t, &schema.BlockResult{}, record)
+			blockRes := record.(*schema.BlockResult)
+			require.Equal(t, int64(len(sentBlocks)), envelope.SequenceNumber)
+
+			sentBlocks = append(sentBlocks, blockRes.Block.Hash)
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
+		},
+	}
+
+	go ci.SetWSSender(wss)
+	go ci.SetWSReceiver(wsr)
+	time.Sleep(time.Millisecond * 200)
+
+	require.Equal(t, 0, blocksOutbox.Len())
+	require.Equal(t, [][]byte{blockRes1.Block.Hash, blockRes2.Block.Hash}, sentBlocks)
+}
+
+func TestCovalentIndexer_SaveBlock_WithOutbox_AppendFailed_ExpectNotPublishedAndSequenceNumberNotReused(t *testing.T) {
+	t.Parallel()
+
+	errAppend := errors.New("disk full")
+	appendCalledCt := 0
+	nextID := uint64(7)
+	blocksOutbox := &mock.OutboxStub{
+		AppendCalled: func(entry *covalent.OutboxEntry) error {
+			appendCalledCt++
+			if appendCalledCt == 1 {
+				return errAppend
+			}
+
+			entry.ID = nextID
+			nextID++
+			return nil
+		},
+		NextIDCalled: func() uint64 {
+			return nextID
+		},
+	}
+
+	publishedSequenceNumbers := make([]uint64, 0)
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return generateRandomValidBlockResult(), nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21126",
+			},
+			Outbox: blocksOutbox,
+			Sinks: []covalent.Sink{&mock.SinkStub{
+				PublishCalled: func(message *covalent.SinkMessage) error {
+					publishedSequenceNumbers = append(publishedSequenceNumbers, message.SequenceNumber)
+					return nil
+				},
+			}},
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Equal(t, errAppend, ci.SaveBlock(nil))
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.SaveBlock(nil))
+
+	require.Equal(t, []uint64{7, 8}, publishedSequenceNumbers)
+}
+
+func TestCovalentIndexer_SaveBlock_WithSendWindow_OutOfOrderAcknowledge_ExpectOnlyAffectedBlocksResent(t *testing.T) {
+	blockResults := []*schema.BlockResult{
+		generateRandomValidBlockResult(),
+		generateRandomValidBlockResult(),
+		generateRandomValidBlockResult(),
+	}
+
+	blocksOutbox, err := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+	require.Nil(t, err)
+
+	processedCt := atomic.Counter{}
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					blockRes := blockResults[processedCt.Get()]
+					processedCt.Increment()
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Outbox:         blocksOutbox,
+			SendWindowSize: 3,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	for range blockResults {
+		require.Nil(t, ci.SaveBlock(nil))
+	}
+
+	mutSentBlocks := sync.Mutex{}
+	sentBlocks := make([][]byte, 0)
+	wss := &mock.WSConnStub{
+		WriteMessageCalled: func(messageType int, data []byte) error {
+			record, _, errDecode := utility.DecodeStreamMessageWithEnvelope(data)
+			require.Nil(t, errDecode)
+			require.IsType(t, &schema.BlockResult{}, record)
+			blockRes := record.(*schema.BlockResult)
+
+			mutSentBlocks.Lock()
+			sentBlocks = append(sentBlocks, blockRes.Block.Hash)
+			mutSentBlocks.Unlock()
+			return nil
+		},
+	}
+
+	// Third block is acknowledged first, so the first two are considered lost
+	acks := make(chan []byte, 3)
+	acks <- blockResults[2].Block.Hash
+	acks <- blockResults[0].Block.Hash
+	acks <- blockResults[1].Block.Hash
+	readCt := atomic.Counter{}
+	wsr := &mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			readCt.Increment()
+			return websocket.BinaryMessage, <-acks, nil
+		},
+	}
+
+	go ci.SetWSSender(wss)
+	go ci.SetWSReceiver(wsr)
+	time.Sleep(time.Millisecond * 200)
+
+	expectedSentBlocks := [][]byte{
+		blockResults[0].Block.Hash,
+		blockResults[1].Block.Hash,
+		blockResults[2].Block.Hash,
+		blockResults[0].Block.Hash,
+		blockResults[1].Block.Hash,
+	}
+	mutSentBlocks.Lock()
+	require.Equal(t, expectedSentBlocks, sentBlocks)
+	mutSentBlocks.Unlock()
+	require.Equal(t, int64(3), readCt.Get())
+	require.Equal(t, 0, blocksOutbox.Len())
+}
+
+func TestCovalentIndexer_SaveBlock_WithSendWindow_AcknowledgePending_ExpectNewBlocksWrittenUntilWindowFull(t *testing.T) {
+	blocksOutbox, err := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+	require.Nil(t, err)
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return generateRandomValidBlockResult(), nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Outbox:         blocksOutbox,
+			SendWindowSize: 3,
+		})
+
+	writtenCt := atomic.Counter{}
+	released := make(chan struct{})
+	ci.SetWSConnection(&mock.WSConnStub{
+		WriteMessageCalled: func(messageType int, data []byte) error {
+			writtenCt.Increment()
+			return nil
+		},
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			// covalent is slow to acknowledge, no acknowledge arrives during the test
+			<-released
+			return 0, nil, errors.New("websocket closed")
+		},
+	})
+
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Eventually(t, func() bool {
+		return writtenCt.Get() == 1
+	}, time.Second, time.Millisecond*10)
+
+	// the acknowledge of the first block is awaited, next blocks are written until the window is full
+	for nonce := 2; nonce <= 4; nonce++ {
+		require.Nil(t, ci.SaveBlock(nil))
+	}
+	require.Eventually(t, func() bool {
+		return writtenCt.Get() == 3
+	}, time.Second, time.Millisecond*10)
+	time.Sleep(time.Millisecond * 100)
+	require.Equal(t, int64(3), writtenCt.Get())
+
+	close(released)
+	require.Nil(t, ci.Close())
+}
+
+func TestCovalentIndexer_SaveBlock_WithSendWindow_ReceiverFailed_ExpectNothingWrittenUntilReconnected(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+	blocksOutbox, err := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+	require.Nil(t, err)
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Outbox:         blocksOutbox,
+			SendWindowSize: 2,
+		})
+
+	writtenCt := atomic.Counter{}
+	ci.SetWSSender(&mock.WSConnStub{
+		WriteMessageCalled: func(messageType int, data []byte) error {
+			writtenCt.Increment()
+			return nil
+		},
+	})
+	ci.SetWSReceiver(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return 0, nil, errors.New("read error")
+		},
+	})
+
+	require.Nil(t, ci.SaveBlock(nil))
+	time.Sleep(time.Millisecond * 200)
+	require.Equal(t, int64(1), writtenCt.Get())
+	require.Equal(t, 1, blocksOutbox.Len())
+
+	ci.SetWSReceiver(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
+		},
+	})
+	require.Eventually(t, func() bool {
+		return blocksOutbox.Len() == 0
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, int64(2), writtenCt.Get())
+	require.Nil(t, ci.Close())
+}
+
+func TestCovalentIndexer_SaveBlock_RetriesExhausted(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+	wrongAck := &mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, []byte{0x1}, nil
+		},
+	}
+
+	t.Run("error action, expect error", func(t *testing.T) {
+		retryPolicy, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+			InitialDelay: time.Millisecond,
+			Multiplier:   2,
+			MaxAttempts:  3,
+			OnExhausted:  covalent.RetryExhaustedError,
+		})
+		ci, _ := covalent.NewCovalentDataIndexer(
+			&covalent.ArgsCovalentDataIndexer{
+				Processor: &mock.DataHandlerStub{
+					ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+						return blockRes, nil
+					},
+				},
+				Server: &http.Server{
+					Addr: "localhost:21119",
+				},
+				RetryPolicy: retryPolicy,
+			})
+		ci.SetWSConnection(wrongAck)
+
+		require.Equal(t, covalent.ErrRetriesExhausted, ci.SaveBlock(nil))
+		require.Equal(t, uint64(2), retryPolicy.Retries())
+		require.Equal(t, uint64(1), retryPolicy.ExhaustedRetries())
+		require.Nil(t, ci.Close())
+	})
+
+	t.Run("spill action, expect block published to spill sink", func(t *testing.T) {
+		retryPolicy, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+			InitialDelay: time.Millisecond,
+			Multiplier:   1,
+			MaxAttempts:  2,
+			OnExhausted:  covalent.RetryExhaustedSpill,
+		})
+		spilled := make([]*covalent.SinkMessage, 0)
+		spillSinkClosed := atomic.Flag{}
+		ci, _ := covalent.NewCovalentDataIndexer(
+			&covalent.ArgsCovalentDataIndexer{
+				Processor: &mock.DataHandlerStub{
+					ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+						return blockRes, nil
+					},
+				},
+				Server: &http.Server{
+					Addr: "localhost:21119",
+				},
+				RetryPolicy: retryPolicy,
+				SpillSink: &mock.SinkStub{
+					PublishCalled: func(message *covalent.SinkMessage) error {
+						spilled = append(spilled, message)
+						return nil
+					},
+					CloseCalled: func() error {
+						_ = spillSinkClosed.SetReturningPrevious()
+						return nil
+					},
+				},
+			})
+		ci.SetWSConnection(wrongAck)
+
+		require.Nil(t, ci.SaveBlock(nil))
+		require.Len(t, spilled, 1)
+		require.Equal(t, blockRes.Block.Hash, spilled[0].Hash)
+
+		require.Nil(t, ci.Close())
+		require.True(t, spillSinkClosed.IsSet())
+	})
+
+	t.Run("spill action with outbox, expect entry spilled and removed from outbox", func(t *testing.T) {
+		retryPolicy, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+			InitialDelay: time.Millisecond,
+			Multiplier:   1,
+			MaxAttempts:  2,
+			OnExhausted:  covalent.RetryExhaustedSpill,
+		})
+		blocksOutbox, err := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+		require.Nil(t, err)
+
+		spilledCt := atomic.Counter{}
+		ci, _ := covalent.NewCovalentDataIndexer(
+			&covalent.ArgsCovalentDataIndexer{
+				Processor: &mock.DataHandlerStub{
+					ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+						return blockRes, nil
+					},
+				},
+				Server: &http.Server{
+					Addr: "localhost:21119",
+				},
+				Outbox:      blocksOutbox,
+				RetryPolicy: retryPolicy,
+				SpillSink: &mock.SinkStub{
+					PublishCalled: func(message *covalent.SinkMessage) error {
+						require.Equal(t, blockRes.Block.Hash, message.Hash)
+						spilledCt.Increment()
+						return nil
+					},
+				},
+				DrainTimeout: time.Second,
+			})
+		ci.SetWSConnection(wrongAck)
+
+		require.Nil(t, ci.SaveBlock(nil))
+		require.Nil(t, ci.Close())
+		require.Equal(t, int64(1), spilledCt.Get())
+		require.Equal(t, 0, blocksOutbox.Len())
+	})
+
+	t.Run("error action while disconnected, expect error", func(t *testing.T) {
+		retryPolicy, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+			InitialDelay: time.Millisecond,
+			Multiplier:   1,
+			MaxAttempts:  3,
+			OnExhausted:  covalent.RetryExhaustedError,
+		})
+		ci, _ := covalent.NewCovalentDataIndexer(
+			&covalent.ArgsCovalentDataIndexer{
+				Processor: &mock.DataHandlerStub{
+					ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+						return blockRes, nil
+					},
+				},
+				Server: &http.Server{
+					Addr: "localhost:21119",
+				},
+				RetryPolicy: retryPolicy,
+			})
+
+		require.Equal(t, covalent.ErrRetriesExhausted, ci.SaveBlock(nil))
+		require.Equal(t, uint64(2), retryPolicy.Retries())
+		require.Equal(t, uint64(1), retryPolicy.ExhaustedRetries())
+		require.Nil(t, ci.Close())
+	})
+
+	t.Run("spill action after connection failed, expect block published to spill sink", func(t *testing.T) {
+		retryPolicy, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+			InitialDelay: time.Millisecond,
+			Multiplier:   1,
+			MaxAttempts:  3,
+			OnExhausted:  covalent.RetryExhaustedSpill,
+		})
+		spilled := make([]*covalent.SinkMessage, 0)
+		ci, _ := covalent.NewCovalentDataIndexer(
+			&covalent.ArgsCovalentDataIndexer{
+				Processor: &mock.DataHandlerStub{
+					ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+						return blockRes, nil
+					},
+				},
+				Server: &http.Server{
+					Addr: "localhost:21119",
+				},
+				RetryPolicy: retryPolicy,
+				SpillSink: &mock.SinkStub{
+					PublishCalled: func(message *covalent.SinkMessage) error {
+						spilled = append(spilled, message)
+						return nil
+					},
+				},
+			})
+
+		writeCt := atomic.Counter{}
+		ci.SetWSConnection(&mock.WSConnStub{
+			WriteMessageCalled: func(messageType int, data []byte) error {
+				writeCt.Increment()
+				return errors.New("connection reset")
+			},
+		})
+
+		require.Nil(t, ci.SaveBlock(nil))
+		require.Equal(t, int64(1), writeCt.Get())
+		require.Len(t, spilled, 1)
+		require.Equal(t, blockRes.Block.Hash, spilled[0].Hash)
+		require.Nil(t, ci.Close())
+	})
+}
+
+func TestCovalentIndexer_SaveBlock_ExpectMetricsRecorded(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+	pipelineMetrics := metrics.NewPipelineMetrics()
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Metrics: pipelineMetrics,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	readCt := atomic.Counter{}
+	ws := &mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			readCt.Increment()
+			if readCt.Get() == 1 {
+				return websocket.BinaryMessage, []byte{0x1}, nil
+			}
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
+		},
+	}
+	ci.SetWSConnection(&mock.WSConnStub{})
+	ci.SetWSConnection(ws)
+	require.Nil(t, ci.SaveBlock(nil))
+
+	buff := &bytes.Buffer{}
+	require.Nil(t, pipelineMetrics.Write(buff))
+	lines := strings.Split(buff.String(), "\n")
+
+	expectedLines := []string{
+		"covalent_payload_size_bytes_count 1",
+		"covalent_payloads_sent_total 2",
+		"covalent_payloads_acknowledged_total 1",
+		"covalent_send_retries_total 1",
+		`covalent_websocket_reconnects_total{socket="wss"} 1`,
+		`covalent_websocket_reconnects_total{socket="wsr"} 1`,
+		fmt.Sprintf("covalent_saved_nonce %d", blockRes.Block.Nonce),
+		fmt.Sprintf("covalent_acknowledged_nonce %d", blockRes.Block.Nonce),
+		"covalent_nonce_lag 0",
+	}
+	for _, expectedLine := range expectedLines {
+		require.Contains(t, lines, expectedLine)
+	}
+}
+
+func TestCovalentIndexer_SaveBlock_AcknowledgeTimeout_ExpectConnectionClosedAndBlockResent(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+	pipelineMetrics := metrics.NewPipelineMetrics()
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Metrics:      pipelineMetrics,
+			WriteTimeout: time.Second,
+			AckTimeout:   time.Millisecond * 100,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	writeDeadlines := atomic.Counter{}
+	readDeadlines := atomic.Counter{}
+	closeCt := atomic.Counter{}
+	ci.SetWSConnection(&mock.WSConnStub{
+		SetWriteDeadlineCalled: func(deadline time.Time) error {
+			writeDeadlines.Increment()
+			return nil
+		},
+		SetReadDeadlineCalled: func(deadline time.Time) error {
+			readDeadlines.Increment()
+			return nil
+		},
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return 0, nil, os.ErrDeadlineExceeded
+		},
+		CloseCalled: func() error {
+			closeCt.Increment()
+			return nil
+		},
+	})
+
+	saveBlockErr := make(chan error, 1)
+	go func() {
+		saveBlockErr <- ci.SaveBlock(nil)
+	}()
+
+	require.Eventually(t, func() bool {
+		return closeCt.Get() == 1
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, int64(1), writeDeadlines.Get())
+	require.Equal(t, int64(1), readDeadlines.Get())
+	require.False(t, ci.Status().ReceiverConnected)
+
+	ci.SetWSConnection(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
+		},
+	})
+	select {
+	case err := <-saveBlockErr:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "block not resent after reconnecting")
+	}
+	require.Equal(t, int64(1), closeCt.Get())
+
+	buff := &bytes.Buffer{}
+	require.Nil(t, pipelineMetrics.Write(buff))
+	lines := strings.Split(buff.String(), "\n")
+
+	expectedLines := []string{
+		`covalent_delivery_timeouts_total{operation="ack"} 1`,
+		`covalent_delivery_timeouts_total{operation="write"} 0`,
+		`covalent_delivery_errors_total{operation="ack"} 0`,
+		`covalent_websocket_reconnects_total{socket="wsr"} 1`,
+		"covalent_payloads_sent_total 2",
+	}
+	for _, expectedLine := range expectedLines {
+		require.Contains(t, lines, expectedLine)
+	}
+}
+
+func TestCovalentIndexer_Close_SaveBlockWaitingForConnection_ExpectUnblocked(t *testing.T) {
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return generateRandomValidBlockResult(), nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
+
+	saveBlockErr := make(chan error, 1)
+	go func() {
+		saveBlockErr <- ci.SaveBlock(nil)
+	}()
+
+	time.Sleep(time.Millisecond * 100)
+	require.Nil(t, ci.Close())
+
+	select {
+	case err := <-saveBlockErr:
+		require.Equal(t, covalent.ErrIndexerClosed, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "SaveBlock still blocked after Close")
+	}
+}
+
+func TestCovalentIndexer_SaveBlock_WithSendQueue_HaltPolicy_ExpectQueueHaltedAndErrorReturned(t *testing.T) {
+	t.Parallel()
+
+	args := &indexer.ArgsSaveBlockData{
+		HeaderHash: []byte("hash"),
+		Header:     &block.Header{Nonce: 2},
+	}
+	processedCt := 0
+	publishedNonces := make(chan uint64, 10)
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					processedCt++
+					blockRes := generateRandomValidBlockResult()
+					blockRes.Block.Nonce = int64(processedCt)
+					if processedCt == 2 {
+						// a block without hash can not be encoded
+						blockRes.Block.Hash = nil
+					}
+					return blockRes, nil
+				},
+			},
+			Sinks: []covalent.Sink{&mock.SinkStub{
+				PublishCalled: func(message *covalent.SinkMessage) error {
+					publishedNonces <- message.Nonce
+					return nil
+				},
+			}},
+			DisableWebSocketSink: true,
+			FailurePolicy:        covalent.FailurePolicyHalt,
+			SendQueueSize:        3,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Nil(t, ci.SaveBlock(args))
+	require.Nil(t, ci.SaveBlock(args))
+	require.Equal(t, uint64(1), <-publishedNonces)
+
+	var err error
+	require.Eventually(t, func() bool {
+		require.NotPanics(t, func() { err = ci.SaveBlock(args) })
+		return err != nil
+	}, time.Second, time.Millisecond*10)
+	require.True(t, errors.Is(err, covalent.ErrSendQueueHalted))
+	require.True(t, errors.Is(ci.SaveBlock(args), covalent.ErrSendQueueHalted))
+	require.Len(t, publishedNonces, 0)
+}
+
+func TestCovalentIndexer_Close_SendQueueWaitingForAcknowledge_ExpectUnblocked(t *testing.T) {
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return generateRandomValidBlockResult(), nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			SendQueueSize: 3,
+		})
+
+	closed := make(chan struct{})
+	var closeOnce sync.Once
+	readStarted := make(chan struct{}, 1)
+	ci.SetWSConnection(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			readStarted <- struct{}{}
+			// covalent never acknowledges, the read only returns once the websocket is closed
+			<-closed
+			return 0, nil, errors.New("websocket closed")
+		},
+		CloseCalled: func() error {
+			closeOnce.Do(func() { close(closed) })
+			return nil
+		},
+	})
+
+	require.Nil(t, ci.SaveBlock(nil))
+	select {
+	case <-readStarted:
+	case <-time.After(time.Second):
+		require.Fail(t, "queued block not sent")
+	}
+
+	closeErr := make(chan error, 1)
+	go func() {
+		closeErr <- ci.Close()
+	}()
+
+	select {
+	case err := <-closeErr:
+		require.Nil(t, err)
+	case <-time.After(time.Second * 2):
+		require.Fail(t, "Close blocked by the sender waiting for an acknowledge")
+	}
+}
+
+func TestCovalentIndexer_Close_WithDrainTimeout_ExpectPendingBlocksDelivered(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+
+	blocksOutbox, err := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+	require.Nil(t, err)
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Outbox:       blocksOutbox,
+			DrainTimeout: time.Second,
+		})
+
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.SaveBlock(nil))
+
+	ws := &mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
+		},
+	}
+	go func() {
+		time.Sleep(time.Millisecond * 100)
+		ci.SetWSConnection(ws)
+	}()
+
+	require.Nil(t, ci.Close())
+	require.Equal(t, 0, blocksOutbox.Len())
+}
+
+func TestCovalentIndexer_Close_DrainTimeoutExpired_ExpectUnsentBlocksKeptInOutbox(t *testing.T) {
+	dir := t.TempDir()
+	blocksOutbox, err := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: dir})
+	require.Nil(t, err)
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return generateRandomValidBlockResult(), nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Outbox:       blocksOutbox,
+			DrainTimeout: time.Millisecond * 200,
+		})
+
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.SaveBlock(nil))
+
+	start := time.Now()
+	require.Nil(t, ci.Close())
+	require.GreaterOrEqual(t, time.Since(start), time.Millisecond*200)
+	require.Less(t, time.Since(start), time.Second)
+
+	blocksOutbox, err = outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: dir})
+	require.Nil(t, err)
+	require.Equal(t, 2, blocksOutbox.Len())
+	require.Nil(t, blocksOutbox.Close())
+}
+
+func generateRandomValidBlockResult() *schema.BlockResult {
+	block := &schema.Block{
+		Hash:          testscommon.GenerateRandomFixedBytes(32),
+		StateRootHash: testscommon.GenerateRandomFixedBytes(32),
+	}
+
+	return &schema.BlockResult{
+		Block: block,
+	}
+}
+
+func TestCovalentIndexer_RevertIndexedBlock_ExpectRevertPublishedInOrder(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+	blockRevert := &schema.BlockRevert{
+		Hash:    blockRes.Block.Hash,
+		Nonce:   blockRes.Block.Nonce,
+		Round:   blockRes.Block.Round,
+		ShardID: blockRes.Block.ShardID,
+		Epoch:   blockRes.Block.Epoch,
+	}
+	header := &block.Header{Nonce: uint64(blockRes.Block.Nonce)}
+
+	publishedMessages := make([]*covalent.SinkMessage, 0)
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			publishedMessages = append(publishedMessages, message)
+			return nil
+		},
+	}
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+				ProcessRevertCalled: func(h data.HeaderHandler) (*schema.BlockRevert, error) {
+					require.Equal(t, header, h)
+					return blockRevert, nil
+				},
+			},
+			Sinks:                []covalent.Sink{sink},
+			DisableWebSocketSink: true,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.RevertIndexedBlock(header, nil))
+
+	require.Len(t, publishedMessages, 2)
+	revertMessage := publishedMessages[1]
+	require.Equal(t, uint64(1), revertMessage.SequenceNumber)
+	require.Equal(t, blockRevert, revertMessage.Record)
+	require.Empty(t, revertMessage.Hash)
+	require.Equal(t, uint64(blockRevert.Nonce), revertMessage.Nonce)
+
+	record, envelope, err := utility.DecodeStreamMessageWithEnvelope(revertMessage.Data)
+	require.Nil(t, err)
+	require.Equal(t, int64(1), envelope.SequenceNumber)
+	require.Equal(t, blockRevert, record)
+}
+
+func TestCovalentIndexer_RevertIndexedBlock_WithSendWindow_ExpectRevertAcknowledgedOnlyBySequenceNumber(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+	blocksOutbox, err := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+	require.Nil(t, err)
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+				ProcessRevertCalled: func(_ data.HeaderHandler) (*schema.BlockRevert, error) {
+					return &schema.BlockRevert{Hash: blockRes.Block.Hash, Nonce: blockRes.Block.Nonce}, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Outbox:         blocksOutbox,
+			SendWindowSize: 2,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.RevertIndexedBlock(&block.Header{}, nil))
+
+	revertAckData := make([]byte, 8)
+	binary.BigEndian.PutUint64(revertAckData, 1)
+	acks := make(chan []byte)
+	ci.SetWSConnection(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, <-acks, nil
+		},
+	})
+
+	// the block hash, received again, acknowledges neither the block a second time nor its revert
+	acks <- blockRes.Block.Hash
+	acks <- blockRes.Block.Hash
+	require.Eventually(t, func() bool {
+		return blocksOutbox.Len() == 1
+	}, time.Second, time.Millisecond*10)
+
+	acks <- revertAckData
+	require.Eventually(t, func() bool {
+		return blocksOutbox.Len() == 0
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestCovalentIndexer_RevertIndexedBlock_ErrorProcessingRevert_ExpectErrorAndNothingPublished(t *testing.T) {
+	errProcessRevert := errors.New("error processing revert")
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			require.Fail(t, "nothing should be published")
+			return nil
+		},
+	}
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessRevertCalled: func(_ data.HeaderHandler) (*schema.BlockRevert, error) {
+					return nil, errProcessRevert
+				},
+			},
+			Sinks:                []covalent.Sink{sink},
+			DisableWebSocketSink: true,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Equal(t, errProcessRevert, ci.RevertIndexedBlock(&block.Header{}, nil))
+}
+
+func TestCovalentIndexer_SaveRoundsInfo_ExpectRoundsPublishedAtLastSavedNonce(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+	blockRes.Block.Nonce = 7
+	roundsInfo := &schema.RoundsInfo{
+		Rounds: []*schema.RoundInfo{
+			{Round: 10, SignersIndexes: []int64{0, 1}, BlockWasProposed: true, ShardID: 1, Epoch: 2, Timestamp: 100},
+			{Round: 11, SignersIndexes: []int64{}, BlockWasProposed: false, ShardID: 1, Epoch: 3, Timestamp: 106},
+		},
+	}
+	inputRoundsInfo := []*indexer.RoundInfo{{Index: 10}, {Index: 11}}
+
+	publishedMessages := make([]*covalent.SinkMessage, 0)
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			publishedMessages = append(publishedMessages, message)
+			return nil
+		},
+	}
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+				ProcessRoundsInfoCalled: func(r []*indexer.RoundInfo) *schema.RoundsInfo {
+					require.Equal(t, inputRoundsInfo, r)
+					return roundsInfo
+				},
+			},
+			Sinks:                []covalent.Sink{sink},
+			DisableWebSocketSink: true,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.SaveRoundsInfo(inputRoundsInfo))
+
+	require.Len(t, publishedMessages, 2)
+	roundsMessage := publishedMessages[1]
+	require.Equal(t, uint64(1), roundsMessage.SequenceNumber)
+	require.Equal(t, uint64(7), roundsMessage.Nonce)
+	require.Equal(t, uint64(11), roundsMessage.Round)
+	require.Equal(t, uint32(3), roundsMessage.Epoch)
+	require.Empty(t, roundsMessage.Hash)
+	require.Equal(t, roundsInfo, roundsMessage.Record)
+
+	record, _, err := utility.DecodeStreamMessageWithEnvelope(roundsMessage.Data)
+	require.Nil(t, err)
+	require.Equal(t, roundsInfo, record)
+}
+
+func TestCovalentIndexer_ConcurrentProducers_ExpectUniqueSequenceNumbers(t *testing.T) {
+	t.Parallel()
+
+	mutSequenceNumbers := sync.Mutex{}
+	sequenceNumbers := make(map[uint64]struct{})
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			// a slow sink widens the window between creating and publishing a message
+			time.Sleep(time.Millisecond)
+			mutSequenceNumbers.Lock()
+			sequenceNumbers[message.SequenceNumber] = struct{}{}
+			mutSequenceNumbers.Unlock()
+			return nil
+		},
+	}
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessRoundsInfoCalled: func(_ []*indexer.RoundInfo) *schema.RoundsInfo {
+					return &schema.RoundsInfo{Rounds: []*schema.RoundInfo{{Round: 1, SignersIndexes: []int64{}}}}
+				},
+			},
+			Sinks:                []covalent.Sink{sink},
+			DisableWebSocketSink: true,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	numProducers, numRecords := 10, 20
+	wg := sync.WaitGroup{}
+	wg.Add(numProducers)
+	for i := 0; i < numProducers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := 0; j < numRecords; j++ {
+				require.Nil(t, ci.SaveRoundsInfo([]*indexer.RoundInfo{{Index: 1}}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, sequenceNumbers, numProducers*numRecords)
+	for sequenceNumber := 0; sequenceNumber < numProducers*numRecords; sequenceNumber++ {
+		require.Contains(t, sequenceNumbers, uint64(sequenceNumber))
+	}
+}
+
+func TestCovalentIndexer_SaveRoundsInfo_NoRounds_ExpectNothingPublished(t *testing.T) {
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			require.Fail(t, "nothing should be published")
+			return nil
+		},
+	}
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessRoundsInfoCalled: func(_ []*indexer.RoundInfo) *schema.RoundsInfo {
+					return &schema.RoundsInfo{Rounds: []*schema.RoundInfo{}}
+				},
+			},
+			Sinks:                []covalent.Sink{sink},
+			DisableWebSocketSink: true,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Nil(t, ci.SaveRoundsInfo(nil))
+}
+
+func TestCovalentIndexer_SaveRoundsInfo_ExpectAcknowledgedBySequenceNumber(t *testing.T) {
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessRoundsInfoCalled: func(_ []*indexer.RoundInfo) *schema.RoundsInfo {
+					return &schema.RoundsInfo{Rounds: []*schema.RoundInfo{{Round: 10, SignersIndexes: []int64{}}}}
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	sentData := make(chan []byte, 1)
+	wss := &mock.WSConnStub{
+		WriteMessageCalled: func(messageType int, data []byte) error {
+			sentData <- data
+			return nil
+		},
+	}
+	wsr := &mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			_, envelope, errDecode := utility.DecodeStreamMessageWithEnvelope(<-sentData)
+			if errDecode != nil {
+				return 0, nil, errDecode
+			}
+
+			ackData := make([]byte, 8)
+			binary.BigEndian.PutUint64(ackData, uint64(envelope.SequenceNumber))
+			return websocket.BinaryMessage, ackData, nil
+		},
+	}
+	ci.SetWSSender(wss)
+	ci.SetWSReceiver(wsr)
+
+	saveRoundsErr := make(chan error, 1)
+	go func() {
+		saveRoundsErr <- ci.SaveRoundsInfo([]*indexer.RoundInfo{{Index: 10}})
+	}()
+
+	select {
+	case err := <-saveRoundsErr:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "rounds info not acknowledged")
+	}
+}
+
+func TestCovalentIndexer_SaveValidatorsPubKeys_ExpectOneRecordPublishedPerShard(t *testing.T) {
+	validatorsPubKeys := map[uint32][][]byte{
+		0: {[]byte("key1"), []byte("key2")},
+		1: {[]byte("key3")},
+	}
+	epochValidators := []*schema.EpochValidators{
+		{Epoch: 4, ShardID: 0, PublicKeys: validatorsPubKeys[0]},
+		{Epoch: 4, ShardID: 1, PublicKeys: validatorsPubKeys[1]},
+	}
+
+	publishedMessages := make([]*covalent.SinkMessage, 0)
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			publishedMessages = append(publishedMessages, message)
+			return nil
+		},
+	}
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessValidatorsPubKeysCalled: func(pubKeys map[uint32][][]byte, epoch uint32) []*schema.EpochValidators {
+					require.Equal(t, validatorsPubKeys, pubKeys)
+					require.Equal(t, uint32(4), epoch)
+					return epochValidators
+				},
+			},
+			Sinks:                []covalent.Sink{sink},
+			DisableWebSocketSink: true,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Nil(t, ci.SaveValidatorsPubKeys(validatorsPubKeys, 4))
+
+	require.Len(t, publishedMessages, 2)
+	for idx, message := range publishedMessages {
+		require.Equal(t, uint64(idx), message.SequenceNumber)
+		require.Equal(t, uint32(4), message.Epoch)
+		require.Empty(t, message.Hash)
+		require.Equal(t, epochValidators[idx], message.Record)
+
+		record, _, err := utility.DecodeStreamMessageWithEnvelope(message.Data)
+		require.Nil(t, err)
+		require.Equal(t, epochValidators[idx], record)
+	}
+}
+
+func TestCovalentIndexer_SaveValidatorsRating(t *testing.T) {
+	validatorsRating := &schema.ValidatorsRating{
+		Ratings: []*schema.ValidatorRating{
+			{PublicKey: "key1", Rating: 50.5, ShardID: 1, Epoch: 20},
+			{PublicKey: "key2", Rating: 100, ShardID: 1, Epoch: 20},
+		},
+	}
+	errProcessRatings := errors.New("error processing ratings")
+
+	publishedMessages := make([]*covalent.SinkMessage, 0)
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			publishedMessages = append(publishedMessages, message)
+			return nil
+		},
+	}
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessValidatorsRatingCalled: func(indexID string, _ []*indexer.ValidatorRatingInfo) (*schema.ValidatorsRating, error) {
+					switch indexID {
+					case "1_20":
+						return validatorsRating, nil
+					case "1_21":
+						return &schema.ValidatorsRating{Ratings: []*schema.ValidatorRating{}}, nil
+					default:
+						return nil, errProcessRatings
+					}
+				},
+			},
+			Sinks:                []covalent.Sink{sink},
+			DisableWebSocketSink: true,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Equal(t, errProcessRatings, ci.SaveValidatorsRating("invalid", nil))
+	require.Nil(t, ci.SaveValidatorsRating("1_21", nil))
+	require.Empty(t, publishedMessages)
+
+	require.Nil(t, ci.SaveValidatorsRating("1_20", nil))
+	require.Len(t, publishedMessages, 1)
+	require.Equal(t, uint64(0), publishedMessages[0].SequenceNumber)
+	require.Equal(t, uint32(20), publishedMessages[0].Epoch)
+	require.Empty(t, publishedMessages[0].Hash)
+	require.Equal(t, validatorsRating, publishedMessages[0].Record)
+
+	record, _, err := utility.DecodeStreamMessageWithEnvelope(publishedMessages[0].Data)
+	require.Nil(t, err)
+	require.Equal(t, validatorsRating, record)
+}
+
+func TestCovalentIndexer_SaveAccounts_ExpectSnapshotPublishedAtLastSavedNonce(t *testing.T) {
+	accountsSnapshot := &schema.AccountsSnapshot{
+		Timestamp: 1234,
+		Accounts: []*schema.AccountSnapshot{
+			{
+				Address:         []byte("address"),
+				Balance:         big.NewInt(1000).Bytes(),
+				Nonce:           4,
+				UserName:        []byte("user"),
+				CodeHash:        []byte("code hash"),
+				RootHash:        []byte("root hash"),
+				Owner:           []byte("owner"),
+				DeveloperReward: big.NewInt(10).Bytes(),
+			},
+		},
+	}
+
+	publishedMessages := make([]*covalent.SinkMessage, 0)
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			publishedMessages = append(publishedMessages, message)
+			return nil
+		},
+	}
+
+	processor := newNonceProcessor()
+	processor.ProcessAccountsCalled = func(blockTimestamp uint64, accounts []data.UserAccountHandler) *schema.AccountsSnapshot {
+		if len(accounts) == 0 {
+			return &schema.AccountsSnapshot{Timestamp: int64(blockTimestamp), Accounts: []*schema.AccountSnapshot{}}
+		}
+		require.Equal(t, uint64(1234), blockTimestamp)
+		return accountsSnapshot
+	}
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor:            processor,
+			Sinks:                []covalent.Sink{sink},
+			DisableWebSocketSink: true,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Nil(t, ci.SaveAccounts(1234, nil))
+	require.Empty(t, publishedMessages)
+
+	require.Nil(t, ci.SaveBlock(createArgsSaveBlockWithNonce(7)))
+	require.Nil(t, ci.SaveAccounts(1234, []data.UserAccountHandler{&mock.UserAccountStub{}}))
+	require.Len(t, publishedMessages, 2)
+
+	message := publishedMessages[1]
+	require.Equal(t, uint64(1), message.SequenceNumber)
+	require.Equal(t, uint64(7), message.Nonce)
+	require.Empty(t, message.Hash)
+	require.Equal(t, accountsSnapshot, message.Record)
+
+	record, _, err := utility.DecodeStreamMessageWithEnvelope(message.Data)
+	require.Nil(t, err)
+	require.Equal(t, accountsSnapshot, record)
 }
@@ -6,6 +6,8 @@ import "errors"
 var ErrNilPubKeyConverter = errors.New("received nil input value: pub key converter")
 
 // ErrNilAccountsAdapter signals that an accounts adapter input parameter is nil
+//
+// Deprecated: the accounts adapter is not required anymore, so this error is never returned
 var ErrNilAccountsAdapter = errors.New("received nil input value: accounts adapter")
 
 // ErrBlockBodyAssertion signals that an error occurred when trying to assert BodyHandler interface of type block body
@@ -20,10 +22,15 @@ var ErrNilMarshaller = errors.New("received nil input value: marshaller")
 // ErrNilMiniBlockHandler signals that a nil mini block handler has been provided
 var ErrNilMiniBlockHandler = errors.New("received nil input value: mini block handler")
 
+// ErrNilValidatorsPubKeysHandler signals that a nil validators public keys handler has been provided
+var ErrNilValidatorsPubKeysHandler = errors.New("received nil input value: validators public keys handler")
+
 // ErrNilShardCoordinator signals that a shard coordinator input parameter is nil
 var ErrNilShardCoordinator = errors.New("received nil input value: shard coordinator")
 
 // ErrCannotCastAccountHandlerToUserAccount signals an error when trying to cast from AccountHandler to UserAccountHandler
+//
+// Deprecated: accounts are not loaded anymore, so this error is never returned
 var ErrCannotCastAccountHandlerToUserAccount = errors.New("cannot cast AccountHandler to UserAccountHandler")
 
 // ErrNilDataHandler signals that a nil data handler handler has been provided
@@ -31,3 +38,224 @@ var ErrNilDataHandler = errors.New("received nil input value: data handler")
 
 // ErrNilHTTPServer signals that a nil http server has been provided
 var ErrNilHTTPServer = errors.New("received nil input value: http server")
+
+// ErrNilArguments signals that nil arguments have been provided
+var ErrNilArguments = errors.New("received nil input value: arguments")
+
+// ErrEmptyOutboxDirectory signals that an empty outbox directory has been provided
+var ErrEmptyOutboxDirectory = errors.New("received empty outbox directory")
+
+// ErrInvalidOutboxSegmentSize signals that an invalid outbox segment size has been provided
+var ErrInvalidOutboxSegmentSize = errors.New("invalid outbox segment size")
+
+// ErrOutboxClosed signals that an operation was requested on a closed outbox
+var ErrOutboxClosed = errors.New("outbox is closed")
+
+// ErrOutboxIndexOutOfRange signals that an outbox entry was requested with an index out of range
+var ErrOutboxIndexOutOfRange = errors.New("outbox index out of range")
+
+// ErrCorruptedOutboxEntry signals that an outbox entry read from disk is corrupted
+var ErrCorruptedOutboxEntry = errors.New("corrupted outbox entry")
+
+// ErrInvalidSendWindowSize signals that an invalid send window size has been provided
+var ErrInvalidSendWindowSize = errors.New("invalid send window size")
+
+// ErrSendWindowWithoutOutbox signals that a send window greater than one block was requested without an outbox
+var ErrSendWindowWithoutOutbox = errors.New("send window greater than one block requires an outbox")
+
+// ErrEmptyAuthenticationToken signals that an empty authentication token has been provided
+var ErrEmptyAuthenticationToken = errors.New("received empty authentication token")
+
+// ErrEmptyHMACSecret signals that an empty hmac secret has been provided
+var ErrEmptyHMACSecret = errors.New("received empty hmac secret")
+
+// ErrInvalidMaxClockSkew signals that an invalid maximum clock skew has been provided
+var ErrInvalidMaxClockSkew = errors.New("invalid maximum clock skew")
+
+// ErrNilCertificatePool signals that a nil certificate pool has been provided
+var ErrNilCertificatePool = errors.New("received nil input value: certificate pool")
+
+// ErrMissingCredentials signals that a connection request does not contain any credentials
+var ErrMissingCredentials = errors.New("missing credentials")
+
+// ErrInvalidCredentials signals that a connection request contains invalid credentials
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrTimestampOutOfRange signals that a signed connection request has a timestamp too far from the current time
+var ErrTimestampOutOfRange = errors.New("request timestamp out of accepted range")
+
+// ErrClientCertificateNotAllowed signals that a client certificate is valid, but not allowed to connect
+var ErrClientCertificateNotAllowed = errors.New("client certificate not allowed")
+
+// ErrEmptyCertificateFile signals that an empty certificate file path has been provided
+var ErrEmptyCertificateFile = errors.New("received empty certificate file")
+
+// ErrEmptyKeyFile signals that an empty key file path has been provided
+var ErrEmptyKeyFile = errors.New("received empty key file")
+
+// ErrInvalidClientCAFile signals that the client certificate authorities file does not contain any valid certificate
+var ErrInvalidClientCAFile = errors.New("invalid client certificate authorities file")
+
+// ErrNoSinkProvided signals that the websocket sink is disabled and no other sink has been provided
+var ErrNoSinkProvided = errors.New("no sink provided")
+
+// ErrOutboxWithoutWebSocketSink signals that an outbox has been provided while the websocket sink is disabled
+var ErrOutboxWithoutWebSocketSink = errors.New("outbox can only be used by the websocket sink")
+
+// ErrNilSink signals that a nil sink has been provided
+var ErrNilSink = errors.New("received nil input value: sink")
+
+// ErrNilSinkMessage signals that a nil sink message has been published
+var ErrNilSinkMessage = errors.New("received nil input value: sink message")
+
+// ErrSinkClosed signals that a message has been published to a closed sink
+var ErrSinkClosed = errors.New("sink is closed")
+
+// ErrEmptySinkDirectory signals that an empty file sink directory has been provided
+var ErrEmptySinkDirectory = errors.New("received empty sink directory")
+
+// ErrInvalidSinkFileSize signals that an invalid maximum file sink file size has been provided
+var ErrInvalidSinkFileSize = errors.New("invalid sink file size")
+
+// ErrEmptySinkURL signals that an empty http sink url has been provided
+var ErrEmptySinkURL = errors.New("received empty sink url")
+
+// ErrInvalidMaxRetries signals that an invalid maximum number of retries has been provided
+var ErrInvalidMaxRetries = errors.New("invalid maximum number of retries")
+
+// ErrUnexpectedHTTPStatus signals that an http sink request was answered with a non successful status code
+var ErrUnexpectedHTTPStatus = errors.New("unexpected http status code")
+
+// ErrNilOutput signals that a nil output writer has been provided
+var ErrNilOutput = errors.New("received nil input value: output")
+
+// ErrNilInput signals that a nil input reader has been provided
+var ErrNilInput = errors.New("received nil input value: input")
+
+// ErrNilSchema signals that a nil avro schema has been provided
+var ErrNilSchema = errors.New("received nil input value: schema")
+
+// ErrUnsupportedCodec signals that an unsupported object container file codec has been provided
+var ErrUnsupportedCodec = errors.New("unsupported object container file codec")
+
+// ErrInvalidObjectContainerFile signals that an object container file has an invalid header or data block
+var ErrInvalidObjectContainerFile = errors.New("invalid object container file")
+
+// ErrInvalidSnappyData signals that snappy compressed data is corrupted
+var ErrInvalidSnappyData = errors.New("invalid snappy compressed data")
+
+// ErrInvalidRecordsPerBlock signals that an invalid number of records per object container file block has been provided
+var ErrInvalidRecordsPerBlock = errors.New("invalid number of records per block")
+
+// ErrUnsupportedFailurePolicy signals that an unsupported failure policy has been provided
+var ErrUnsupportedFailurePolicy = errors.New("unsupported failure policy")
+
+// ErrNilQuarantine signals that a nil quarantine has been provided
+var ErrNilQuarantine = errors.New("received nil input value: quarantine")
+
+// ErrEmptyQuarantineDirectory signals that an empty quarantine directory has been provided
+var ErrEmptyQuarantineDirectory = errors.New("received empty quarantine directory")
+
+// ErrInvalidDrainTimeout signals that an invalid drain timeout has been provided
+var ErrInvalidDrainTimeout = errors.New("invalid drain timeout")
+
+// ErrIndexerClosed signals that the indexer was closed before the data was delivered
+var ErrIndexerClosed = errors.New("indexer closed")
+
+// ErrInvalidRetryDelay signals that an invalid retry delay has been provided
+var ErrInvalidRetryDelay = errors.New("invalid retry delay")
+
+// ErrInvalidRetryMultiplier signals that an invalid retry delay multiplier has been provided
+var ErrInvalidRetryMultiplier = errors.New("invalid retry delay multiplier")
+
+// ErrInvalidRetryJitter signals that an invalid retry jitter has been provided
+var ErrInvalidRetryJitter = errors.New("invalid retry jitter")
+
+// ErrInvalidMaxAttempts signals that an invalid maximum number of attempts has been provided
+var ErrInvalidMaxAttempts = errors.New("invalid maximum number of attempts")
+
+// ErrInvalidMaxElapsedTime signals that an invalid maximum retry time has been provided
+var ErrInvalidMaxElapsedTime = errors.New("invalid maximum retry time")
+
+// ErrUnsupportedRetryExhaustedAction signals that an unknown retry exhausted action has been provided
+var ErrUnsupportedRetryExhaustedAction = errors.New("unsupported retry exhausted action")
+
+// ErrRetriesExhausted signals that data could not be delivered within the retries allowed by the retry policy
+var ErrRetriesExhausted = errors.New("retries exhausted")
+
+// ErrNilSpillSink signals that a nil spill sink has been provided
+var ErrNilSpillSink = errors.New("received nil input value: spill sink")
+
+// ErrInvalidStuckThreshold signals that an invalid stuck threshold has been provided
+var ErrInvalidStuckThreshold = errors.New("invalid stuck threshold")
+
+// ErrEmptyConsumerName signals that an empty consumer name has been provided
+var ErrEmptyConsumerName = errors.New("received empty consumer name")
+
+// ErrUnsupportedConsumerPolicy signals that an unknown consumer policy has been provided
+var ErrUnsupportedConsumerPolicy = errors.New("unsupported consumer policy")
+
+// ErrInvalidConsumerQueueSize signals that an invalid consumer queue size has been provided
+var ErrInvalidConsumerQueueSize = errors.New("invalid consumer queue size")
+
+// ErrDuplicatedConsumerName signals that two consumers with the same name have been provided
+var ErrDuplicatedConsumerName = errors.New("duplicated consumer name")
+
+// ErrConsumersWithoutWebSocketSink signals that consumers have been provided while the websocket sink is disabled,
+// so that no server accepts their connections
+var ErrConsumersWithoutWebSocketSink = errors.New("consumers can not be used if websocket sink is disabled")
+
+// ErrInvalidWriteTimeout signals that an invalid websocket write timeout has been provided
+var ErrInvalidWriteTimeout = errors.New("invalid write timeout")
+
+// ErrInvalidAckTimeout signals that an invalid acknowledge timeout has been provided
+var ErrInvalidAckTimeout = errors.New("invalid acknowledge timeout")
+
+// ErrNilWebSocket signals that a nil websocket has been provided
+var ErrNilWebSocket = errors.New("nil websocket")
+
+// ErrInvalidKeepaliveInterval signals that an invalid keepalive ping interval has been provided
+var ErrInvalidKeepaliveInterval = errors.New("invalid keepalive interval")
+
+// ErrInvalidPongTimeout signals that an invalid pong timeout has been provided
+var ErrInvalidPongTimeout = errors.New("invalid pong timeout")
+
+// ErrEmptyBlockLogDirectory signals that an empty block log directory has been provided
+var ErrEmptyBlockLogDirectory = errors.New("received empty block log directory")
+
+// ErrInvalidBlockLogSegmentSize signals that an invalid block log segment size has been provided
+var ErrInvalidBlockLogSegmentSize = errors.New("invalid block log segment size")
+
+// ErrInvalidBlockLogRetention signals that a negative block log retention has been provided
+var ErrInvalidBlockLogRetention = errors.New("invalid block log retention")
+
+// ErrCorruptedBlockLogEntry signals that a block log entry read from disk is corrupted
+var ErrCorruptedBlockLogEntry = errors.New("corrupted block log entry")
+
+// ErrNilResumePosition signals that a nil resume position has been provided
+var ErrNilResumePosition = errors.New("nil resume position")
+
+// ErrResumePositionNotFound signals that the block a consumer resumes from is unknown or no longer retained
+var ErrResumePositionNotFound = errors.New("resume position not found in block log")
+
+// ErrInvalidSendQueueSize signals that an invalid send queue size has been provided
+var ErrInvalidSendQueueSize = errors.New("invalid send queue size")
+
+// ErrUnsupportedQueueFullPolicy signals that an unknown queue full policy has been provided
+var ErrUnsupportedQueueFullPolicy = errors.New("unsupported queue full policy")
+
+// ErrSendQueueFull signals that a block was rejected because the send queue is full
+var ErrSendQueueFull = errors.New("send queue is full")
+
+// ErrSendQueueHalted signals that the send queue was stopped by the halt failure policy, since a queued block could
+// not be encoded
+var ErrSendQueueHalted = errors.New("send queue halted")
+
+// ErrNilHeaderHandler signals that a nil header handler has been provided
+var ErrNilHeaderHandler = errors.New("received nil input value: header handler")
+
+// ErrInvalidFinalityIndexSize signals that a negative finality index size has been provided
+var ErrInvalidFinalityIndexSize = errors.New("invalid finality index size")
+
+// ErrInvalidRatingIndexID signals that the index id of validators ratings is not formatted as <shardID>_<epoch>
+var ErrInvalidRatingIndexID = errors.New("invalid validators rating index id")
@@ -1,11 +1,27 @@
 package factory
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"io"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/blocklog"
+	"github.com/numbatx/gn-coval-index/certificates"
+	"github.com/numbatx/gn-coval-index/keepalive"
+	"github.com/numbatx/gn-coval-index/metrics"
+	"github.com/numbatx/gn-coval-index/ocf"
+	"github.com/numbatx/gn-coval-index/outbox"
 	"github.com/numbatx/gn-coval-index/process"
 	"github.com/numbatx/gn-coval-index/process/factory"
+	"github.com/numbatx/gn-coval-index/quarantine"
+	"github.com/numbatx/gn-coval-index/sink"
 	"github.com/numbatx/gn-core/core"
 	"github.com/numbatx/gn-core/core/check"
 	"github.com/numbatx/gn-core/hashing"
@@ -17,41 +33,152 @@ import (
 
 var log = logger.GetOrCreate("covalentIndexer")
 
+// covalentConsumer defines what a named consumer of the stream shall do
+type covalentConsumer interface {
+	covalent.Sink
+	Name() string
+	SetConnection(ws process.WSConn)
+	ConnectionLost(ws process.WSConn)
+	Resume(blockLog covalent.BlockLog, position *covalent.ResumePosition, connect func() (process.WSConn, bool)) error
+	Status() *sink.ConsumerStatus
+}
+
+const (
+	// RouteHealth is the route reporting the delivery status of the indexer, always answered with 200 OK
+	RouteHealth = "/health"
+	// RouteReady is the route reporting the delivery status of the indexer, answered with 503 if it is not ready
+	RouteReady = "/ready"
+	// RouteMetrics is the route serving the indexing pipeline metrics in Prometheus text format
+	RouteMetrics = "/metrics"
+	// RouteConsumer is the route on which a named consumer opens its websocket
+	RouteConsumer = "/consumers/{consumer}"
+	// RouteConsumerStatus is the route reporting the acknowledgement cursor of a named consumer
+	RouteConsumerStatus = "/consumers/{consumer}/status"
+)
+
+const (
+	// QueryLastNonce is the RouteConsumer query parameter holding the nonce of the last block processed by a
+	// resuming consumer
+	QueryLastNonce = "lastNonce"
+	// QueryLastHash is the RouteConsumer query parameter holding the hex encoded hash of the last block processed
+	// by a resuming consumer
+	QueryLastHash = "lastHash"
+)
+
+// ConsumerConfig holds the configuration of a named consumer, which follows the stream independently of the
+// others. Policy is either "drop"(default) or "block"
+type ConsumerConfig struct {
+	Name      string
+	Policy    string
+	QueueSize int
+}
+
 // ArgsCovalentIndexerFactory holds all input dependencies required by covalent data indexer factory
-// in order to create new instances
+// in order to create new instances. If BidirectionalConnection is set, a single websocket registered on
+// RouteSendData carries both data and acknowledge data, and RouteAcknowledgeData is not used.
+// If an Authenticator is provided, only authenticated requests are upgraded to websocket connections.
+// If TLSCertificateFile and TLSKeyFile are provided, the server only accepts TLS connections and reloads the
+// certificate when its files change. TLSClientCAFile is optional and used to verify client certificates, if given.
+// ChainID is written, together with the self shard id, in the envelope of each block result sent to covalent.
+// Besides the websocket sink, block results are also published to a file sink if FileSinkDirectory is provided,
+// to an http sink if HTTPSinkURL is provided, to avro object container files if ArchiveSinkDirectory is provided
+// and to stdout if StdoutSink is set. If DisableWebSocketSink is set,
+// no websocket route is registered and no server is started.
+// FailurePolicy is one of "halt"(default), "error", "skip" or "quarantine", the last one storing the raw input
+// of failed blocks in QuarantineDirectory. DrainTimeout is the maximum time Close waits for unsent blocks.
+// If RetryInitialDelay is provided, blocks not acknowledged by covalent are retried with an exponential backoff
+// (RetryMultiplier defaults to 1) and, once RetryMaxAttempts or RetryMaxElapsedTime are reached, handled according
+// to RetryExhaustedAction: "wait"(default), "error" or "spill", the last one writing them in RetrySpillDirectory.
+// The same backoff is used by the http sink, up to HTTPSinkMaxRetries retries.
+// The delivery status is served, without authentication, on RouteHealth and RouteReady, the indexer not being
+// ready if blocks were not acknowledged for longer than ReadinessStuckThreshold. Pipeline metrics are served on
+// RouteMetrics.
+// Each of the Consumers opens a single websocket, carrying both data and acknowledge data, on RouteConsumer,
+// besides the covalent websockets, and has its own acknowledgement cursor. If BlockLogDirectory is provided, the
+// published block results are retained, up to BlockLogMaxBlocks blocks or for BlockLogMaxAge, and a consumer
+// connecting with either QueryLastNonce or QueryLastHash is first sent all retained blocks following that one.
+// WriteTimeout and AckTimeout bound writing a block and waiting for its acknowledge, on all websockets.
+// If KeepaliveInterval is provided, all websockets are pinged periodically and closed if no pong is received within
+// KeepalivePongTimeout, so that a dead connection is detected, and reconnected, before the next block.
+// If SendQueueSize is provided, SaveBlock returns once the block is processed and queued, blocks being delivered
+// by a dedicated sender. When the queue is full, QueueFullPolicy is applied: "block"(default), "error" or "spill",
+// the last one writing blocks in RetrySpillDirectory.
+// FinalityIndexSize is the number of recently saved blocks which can be notified as finalized
 type ArgsCovalentIndexerFactory struct {
-	Enabled              bool
-	URL                  string
-	RouteSendData        string
-	RouteAcknowledgeData string
-	PubKeyConverter      core.PubkeyConverter
-	Accounts             covalent.AccountsAdapter
-	Hasher               hashing.Hasher
-	Marshaller           marshal.Marshalizer
-	ShardCoordinator     process.ShardCoordinator
-}
-
-// CreateCovalentIndexer creates a new Driver instance of type covalent data indexer
-func CreateCovalentIndexer(args *ArgsCovalentIndexerFactory) (covalent.Driver, error) {
+	Enabled                 bool
+	URL                     string
+	RouteSendData           string
+	RouteAcknowledgeData    string
+	PubKeyConverter         core.PubkeyConverter
+	Accounts                covalent.AccountsAdapter // Deprecated: Accounts is ignored, account state being built from the accounts passed to SaveAccounts
+	Hasher                  hashing.Hasher
+	Marshaller              marshal.Marshalizer
+	ShardCoordinator        process.ShardCoordinator
+	OutboxDirectory         string
+	OutboxMaxSegmentSize    int64
+	SendWindowSize          int
+	BidirectionalConnection bool
+	Authenticator           covalent.Authenticator
+	TLSCertificateFile      string
+	TLSKeyFile              string
+	TLSClientCAFile         string
+	ChainID                 string
+	DisableWebSocketSink    bool
+	FileSinkDirectory       string
+	FileSinkMaxFileSize     int64
+	HTTPSinkURL             string
+	HTTPSinkMaxRetries      int
+	ArchiveSinkDirectory    string
+	ArchiveSinkMaxFileSize  int64
+	ArchiveSinkCodec        string
+	StdoutSink              bool
+	FailurePolicy           string
+	QuarantineDirectory     string
+	DrainTimeout            time.Duration
+	RetryInitialDelay       time.Duration
+	RetryMultiplier         float64
+	RetryJitter             float64
+	RetryMaxDelay           time.Duration
+	RetryMaxAttempts        int
+	RetryMaxElapsedTime     time.Duration
+	RetryExhaustedAction    string
+	RetrySpillDirectory     string
+	ReadinessStuckThreshold time.Duration
+	Consumers               []ConsumerConfig
+	BlockLogDirectory       string
+	BlockLogMaxBlocks       int
+	BlockLogMaxAge          time.Duration
+	WriteTimeout            time.Duration
+	AckTimeout              time.Duration
+	KeepaliveInterval       time.Duration
+	KeepalivePongTimeout    time.Duration
+	SendQueueSize           int
+	QueueFullPolicy         string
+	FinalityIndexSize       int
+}
+
+// CreateCovalentIndexer creates a new Driver instance of type covalent data indexer. If creating it fails, all sinks,
+// consumers, block log and outbox created so far are closed
+func CreateCovalentIndexer(args *ArgsCovalentIndexerFactory) (_ covalent.Driver, err error) {
 	if check.IfNil(args.PubKeyConverter) {
 		return nil, covalent.ErrNilPubKeyConverter
 	}
-	if check.IfNil(args.Accounts) {
-		return nil, covalent.ErrNilAccountsAdapter
-	}
 	if check.IfNil(args.Hasher) {
 		return nil, covalent.ErrNilHasher
 	}
 	if check.IfNil(args.Marshaller) {
 		return nil, covalent.ErrNilMarshaller
 	}
+	if check.IfNil(args.ShardCoordinator) {
+		return nil, covalent.ErrNilShardCoordinator
+	}
 
+	pipelineMetrics := metrics.NewPipelineMetrics()
 	argsDataProcessor := &factory.ArgsDataProcessor{
-		PubKeyConvertor:  args.PubKeyConverter,
-		Accounts:         args.Accounts,
-		Hasher:           args.Hasher,
-		Marshaller:       args.Marshaller,
-		ShardCoordinator: args.ShardCoordinator,
+		PubKeyConvertor: args.PubKeyConverter,
+		Hasher:          args.Hasher,
+		Marshaller:      args.Marshaller,
+		Metrics:         pipelineMetrics,
 	}
 
 	dataProcessor, err := factory.CreateDataProcessor(argsDataProcessor)
@@ -59,62 +186,634 @@ func CreateCovalentIndexer(args *ArgsCovalentIndexerFactory) (covalent.Driver, e
 		return nil, err
 	}
 
+	if args.HTTPSinkMaxRetries < 0 {
+		return nil, covalent.ErrInvalidMaxRetries
+	}
+
+	wrapConnection, err := createConnectionWrapper(args)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make([]io.Closer, 0)
+	defer func() {
+		if err != nil {
+			closeAll(created)
+		}
+	}()
+
+	otherSinks, err := createSinks(args)
+	if err != nil {
+		return nil, err
+	}
+	for _, otherSink := range otherSinks {
+		created = append(created, otherSink)
+	}
+
+	// block log and consumers come first, so that they are not delayed by sinks which block while retrying
+	sinks := make([]covalent.Sink, 0)
+	blockLog, err := createBlockLog(args)
+	if err != nil {
+		return nil, err
+	}
+	if !check.IfNil(blockLog) {
+		sinks = append(sinks, blockLog)
+		created = append(created, blockLog)
+	}
+
+	consumers, err := createConsumers(args)
+	if err != nil {
+		return nil, err
+	}
+	for _, consumer := range consumers {
+		sinks = append(sinks, consumer)
+		created = append(created, consumer)
+	}
+	sinks = append(sinks, otherSinks...)
+
+	blocksQuarantine, err := createQuarantine(args)
+	if err != nil {
+		return nil, err
+	}
+
+	retryPolicy, spillSink, err := createRetryPolicy(args)
+	if err != nil {
+		return nil, err
+	}
+	if check.IfNil(spillSink) && covalent.QueueFullPolicy(args.QueueFullPolicy) == covalent.QueueFullSpill {
+		spillSink, err = createSpillSink(args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !check.IfNil(spillSink) {
+		created = append(created, spillSink)
+	}
+
+	argsCovalentIndexer := &covalent.ArgsCovalentDataIndexer{
+		Processor:            dataProcessor,
+		ChainID:              []byte(args.ChainID),
+		ShardID:              args.ShardCoordinator.SelfId(),
+		Sinks:                sinks,
+		DisableWebSocketSink: args.DisableWebSocketSink,
+		FailurePolicy:        covalent.FailurePolicy(args.FailurePolicy),
+		Quarantine:           blocksQuarantine,
+		DrainTimeout:         args.DrainTimeout,
+		RetryPolicy:          retryPolicy,
+		SpillSink:            spillSink,
+		SendQueueSize:        args.SendQueueSize,
+		QueueFullPolicy:      covalent.QueueFullPolicy(args.QueueFullPolicy),
+		FinalityIndexSize:    args.FinalityIndexSize,
+		StuckThreshold:       args.ReadinessStuckThreshold,
+		Metrics:              pipelineMetrics,
+		WriteTimeout:         args.WriteTimeout,
+		AckTimeout:           args.AckTimeout,
+	}
+	if args.DisableWebSocketSink {
+		return covalent.NewCovalentDataIndexer(argsCovalentIndexer)
+	}
+
+	tlsConfig, err := createTLSConfig(args)
+	if err != nil {
+		return nil, err
+	}
+
 	router := mux.NewRouter()
 	server := &http.Server{
-		Addr:    args.URL,
-		Handler: router,
+		Addr:      args.URL,
+		Handler:   router,
+		TLSConfig: tlsConfig,
 	}
 
-	ci, err := covalent.NewCovalentDataIndexer(dataProcessor, server)
+	blocksOutbox, err := createOutbox(args)
 	if err != nil {
 		return nil, err
 	}
+	if !check.IfNil(blocksOutbox) {
+		created = append(created, blocksOutbox)
+	}
+
+	argsCovalentIndexer.Server = server
+	argsCovalentIndexer.Outbox = blocksOutbox
+	argsCovalentIndexer.SendWindowSize = args.SendWindowSize
 
-	routeSendData := router.HandleFunc(args.RouteSendData, func(w http.ResponseWriter, r *http.Request) {
-		log.Debug("new connection", "route", args.RouteSendData)
-		var upgrader = websocket.Upgrader{
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
+	ci, err := covalent.NewCovalentDataIndexer(argsCovalentIndexer)
+	if err != nil {
+		return nil, err
+	}
+
+	registerStatusRoute(router, RouteHealth, ci.Status, false)
+	registerStatusRoute(router, RouteReady, ci.Status, true)
+	router.Handle(RouteMetrics, pipelineMetrics).Methods(http.MethodGet)
+	registerConsumerRoutes(router, args.Authenticator, wrapConnection, consumers, blockLog)
+
+	registerRoute := func(routeName string, setConnection func(conn process.WSConn)) {
+		registerWebSocketRoute(router, routeName, args.Authenticator, wrapConnection, setConnection, ci.ConnectionLost)
+	}
+	if args.BidirectionalConnection {
+		registerRoute(args.RouteSendData, ci.SetWSConnection)
+		return ci, nil
+	}
+
+	registerRoute(args.RouteSendData, ci.SetWSSender)
+	registerRoute(args.RouteAcknowledgeData, ci.SetWSReceiver)
+
+	return ci, nil
+}
+
+// createSinks creates all configured sinks, besides the websocket one which is created by the covalent indexer
+func createSinks(args *ArgsCovalentIndexerFactory) (_ []covalent.Sink, err error) {
+	sinks := make([]covalent.Sink, 0)
+	defer func() {
+		if err != nil {
+			closeSinks(sinks)
+		}
+	}()
+
+	if len(args.FileSinkDirectory) > 0 {
+		fileSink, err := sink.NewFileSink(&sink.ArgsFileSink{
+			Directory:   args.FileSinkDirectory,
+			MaxFileSize: args.FileSinkMaxFileSize,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, fileSink)
+	}
+
+	if len(args.HTTPSinkURL) > 0 {
+		httpRetryPolicy, err := covalent.NewRetryPolicy(createHTTPSinkRetryPolicyArgs(args))
+		if err != nil {
+			return nil, err
+		}
+
+		httpSink, err := sink.NewHTTPSink(&sink.ArgsHTTPSink{
+			URL:         args.HTTPSinkURL,
+			RetryPolicy: httpRetryPolicy,
+		})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, httpSink)
+	}
+
+	if len(args.ArchiveSinkDirectory) > 0 {
+		archiveSink, err := sink.NewArchiveSink(&sink.ArgsArchiveSink{
+			Directory:   args.ArchiveSinkDirectory,
+			MaxFileSize: args.ArchiveSinkMaxFileSize,
+			Codec:       ocf.Codec(args.ArchiveSinkCodec),
+		})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, archiveSink)
+	}
+
+	if args.StdoutSink {
+		stdoutSink, err := sink.NewStdoutSink(&sink.ArgsStdoutSink{})
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, stdoutSink)
+	}
+
+	return sinks, nil
+}
+
+// closeSinks closes the sinks created before a failed construction step
+func closeSinks(sinks []covalent.Sink) {
+	for _, createdSink := range sinks {
+		log.LogIfError(createdSink.Close())
+	}
+}
+
+// closeAll closes, in reverse order, the resources created before the covalent indexer failed to be created
+func closeAll(created []io.Closer) {
+	for idx := len(created) - 1; idx >= 0; idx-- {
+		log.LogIfError(created[idx].Close())
+	}
+}
+
+// createConsumers creates a consumer sink for each configured consumer
+func createConsumers(args *ArgsCovalentIndexerFactory) (_ map[string]covalentConsumer, err error) {
+	consumers := make(map[string]covalentConsumer)
+	defer func() {
+		if err != nil {
+			for _, consumer := range consumers {
+				log.LogIfError(consumer.Close())
+			}
 		}
-		upgrader.CheckOrigin = func(r *http.Request) bool { return true }
+	}()
+	if len(args.Consumers) == 0 {
+		return consumers, nil
+	}
+	if args.DisableWebSocketSink {
+		return nil, covalent.ErrConsumersWithoutWebSocketSink
+	}
+
+	for _, config := range args.Consumers {
+		if _, found := consumers[config.Name]; found {
+			return nil, covalent.ErrDuplicatedConsumerName
+		}
+
+		consumer, err := sink.NewConsumerSink(&sink.ArgsConsumerSink{
+			Name:         config.Name,
+			Policy:       sink.ConsumerPolicy(config.Policy),
+			QueueSize:    config.QueueSize,
+			WriteTimeout: args.WriteTimeout,
+			AckTimeout:   args.AckTimeout,
+		})
+		if err != nil {
+			return nil, err
+		}
+		consumers[config.Name] = consumer
+	}
+
+	return consumers, nil
+}
+
+// connectionWrapper wraps an upgraded websocket, onDead being called if the connection is found dead
+type connectionWrapper func(ws *websocket.Conn, onDead func(conn process.WSConn)) (process.WSConn, error)
+
+// createConnectionWrapper creates a wrapper which adds keepalive to websockets, if a keepalive interval is provided,
+// otherwise websockets are used as they are
+func createConnectionWrapper(args *ArgsCovalentIndexerFactory) (connectionWrapper, error) {
+	if args.KeepaliveInterval < 0 {
+		return nil, covalent.ErrInvalidKeepaliveInterval
+	}
+	if args.KeepalivePongTimeout < 0 {
+		return nil, covalent.ErrInvalidPongTimeout
+	}
+
+	if args.KeepaliveInterval == 0 {
+		return func(ws *websocket.Conn, _ func(conn process.WSConn)) (process.WSConn, error) {
+			return ws, nil
+		}, nil
+	}
+
+	return func(ws *websocket.Conn, onDead func(conn process.WSConn)) (process.WSConn, error) {
+		return keepalive.NewKeepaliveConnection(&keepalive.ArgsKeepaliveConnection{
+			Conn:         ws,
+			PingInterval: args.KeepaliveInterval,
+			PongTimeout:  args.KeepalivePongTimeout,
+			OnDead:       onDead,
+		})
+	}, nil
+}
+
+// createBlockLog creates a disk block log if a block log directory is provided, otherwise consumers can not resume
+func createBlockLog(args *ArgsCovalentIndexerFactory) (covalent.BlockLog, error) {
+	if len(args.BlockLogDirectory) == 0 {
+		return nil, nil
+	}
+
+	return blocklog.NewDiskBlockLog(&blocklog.ArgsDiskBlockLog{
+		Directory: args.BlockLogDirectory,
+		MaxBlocks: args.BlockLogMaxBlocks,
+		MaxAge:    args.BlockLogMaxAge,
+	})
+}
+
+// createRetryPolicy creates the retry policy used for blocks sent to covalent, if a retry delay is configured,
+// together with the spill sink required by the "spill" action
+func createRetryPolicy(args *ArgsCovalentIndexerFactory) (covalent.RetryPolicy, covalent.Sink, error) {
+	if args.RetryInitialDelay == 0 {
+		return nil, nil, nil
+	}
+
+	retryPolicy, err := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+		InitialDelay:   args.RetryInitialDelay,
+		Multiplier:     retryMultiplier(args),
+		Jitter:         args.RetryJitter,
+		MaxDelay:       args.RetryMaxDelay,
+		MaxAttempts:    args.RetryMaxAttempts,
+		MaxElapsedTime: args.RetryMaxElapsedTime,
+		OnExhausted:    covalent.RetryExhaustedAction(args.RetryExhaustedAction),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if retryPolicy.OnExhausted() != covalent.RetryExhaustedSpill {
+		return retryPolicy, nil, nil
+	}
+
+	spillSink, err := createSpillSink(args)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return retryPolicy, spillSink, nil
+}
+
+// createSpillSink creates the file sink receiving blocks which could not be delivered or queued
+func createSpillSink(args *ArgsCovalentIndexerFactory) (covalent.Sink, error) {
+	return sink.NewFileSink(&sink.ArgsFileSink{
+		Directory: args.RetrySpillDirectory,
+	})
+}
+
+// createHTTPSinkRetryPolicyArgs returns the configured backoff, limited to HTTPSinkMaxRetries retries
+func createHTTPSinkRetryPolicyArgs(args *ArgsCovalentIndexerFactory) *covalent.ArgsRetryPolicy {
+	initialDelay := args.RetryInitialDelay
+	if initialDelay == 0 {
+		initialDelay = sink.DefaultRetryInterval
+	}
+
+	return &covalent.ArgsRetryPolicy{
+		InitialDelay: initialDelay,
+		Multiplier:   retryMultiplier(args),
+		Jitter:       args.RetryJitter,
+		MaxDelay:     args.RetryMaxDelay,
+		MaxAttempts:  args.HTTPSinkMaxRetries + 1,
+		OnExhausted:  covalent.RetryExhaustedError,
+	}
+}
+
+func retryMultiplier(args *ArgsCovalentIndexerFactory) float64 {
+	if args.RetryMultiplier == 0 {
+		return 1
+	}
+
+	return args.RetryMultiplier
+}
 
-		ws, errUpgrade := upgrader.Upgrade(w, r, nil)
-		if errUpgrade != nil {
-			log.Warn("could not upgrade http connection to websocket", "error", errUpgrade)
+// createQuarantine creates a disk quarantine if the quarantine failure policy is used
+func createQuarantine(args *ArgsCovalentIndexerFactory) (covalent.Quarantine, error) {
+	if covalent.FailurePolicy(args.FailurePolicy) != covalent.FailurePolicyQuarantine {
+		return nil, nil
+	}
+
+	return quarantine.NewDiskQuarantine(&quarantine.ArgsDiskQuarantine{
+		Directory:  args.QuarantineDirectory,
+		Marshaller: args.Marshaller,
+	})
+}
+
+// createOutbox creates a disk outbox if an outbox directory is provided, otherwise block results are not persisted
+func createOutbox(args *ArgsCovalentIndexerFactory) (covalent.Outbox, error) {
+	if len(args.OutboxDirectory) == 0 {
+		return nil, nil
+	}
+
+	return outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{
+		Directory:      args.OutboxDirectory,
+		MaxSegmentSize: args.OutboxMaxSegmentSize,
+	})
+}
+
+// createTLSConfig creates a tls config if a certificate is provided, otherwise the server does not use TLS
+func createTLSConfig(args *ArgsCovalentIndexerFactory) (*tls.Config, error) {
+	if len(args.TLSCertificateFile) == 0 && len(args.TLSKeyFile) == 0 {
+		return nil, nil
+	}
+
+	reloader, err := certificates.NewCertificateReloader(&certificates.ArgsCertificateReloader{
+		CertificateFile: args.TLSCertificateFile,
+		KeyFile:         args.TLSKeyFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if len(args.TLSClientCAFile) == 0 {
+		return tlsConfig, nil
+	}
+
+	clientCAs, err := os.ReadFile(args.TLSClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.ClientCAs = x509.NewCertPool()
+	if !tlsConfig.ClientCAs.AppendCertsFromPEM(clientCAs) {
+		return nil, covalent.ErrInvalidClientCAFile
+	}
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+
+	return tlsConfig, nil
+}
+
+// registerWebSocketRoute upgrades every authenticated http connection on the given route to a websocket, which is
+// then handed to the covalent indexer using setConnection. Rejected requests never replace the current connection
+func registerWebSocketRoute(
+	router *mux.Router,
+	routeName string,
+	authenticator covalent.Authenticator,
+	wrapConnection connectionWrapper,
+	setConnection func(conn process.WSConn),
+	onDead func(conn process.WSConn),
+) {
+	route := router.HandleFunc(routeName, func(w http.ResponseWriter, r *http.Request) {
+		ws, ok := upgradeConnection(w, r, routeName, authenticator, wrapConnection, onDead)
+		if !ok {
 			return
 		}
 
-		ci.SetWSSender(ws)
+		setConnection(ws)
 	})
 
-	if routeSendData.GetError() != nil {
-		log.Error("websocket router failed to handle send data",
-			"route", routeSendData.GetName(),
-			"error", routeSendData.GetError())
+	if route.GetError() != nil {
+		log.Error("websocket router failed to handle route",
+			"route", routeName,
+			"error", route.GetError())
+	}
+}
+
+// registerStatusRoute serves the indexer status as json. If requireReady is set, a status which is not ready is
+// answered with 503 Service Unavailable
+func registerStatusRoute(
+	router *mux.Router,
+	routeName string,
+	getStatus func() *covalent.Status,
+	requireReady bool,
+) {
+	route := router.HandleFunc(routeName, func(w http.ResponseWriter, r *http.Request) {
+		status := getStatus()
+
+		statusCode := http.StatusOK
+		if requireReady && !status.Ready {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+		err := json.NewEncoder(w).Encode(status)
+		if err != nil {
+			log.Debug("could not write status", "route", routeName, "error", err)
+		}
+	}).Methods(http.MethodGet)
+
+	if route.GetError() != nil {
+		log.Error("router failed to handle route",
+			"route", routeName,
+			"error", route.GetError())
+	}
+}
+
+// upgradeConnection upgrades an authenticated http connection to a websocket. It returns false if the connection
+// was rejected or could not be upgraded, in which case the response was already written
+func upgradeConnection(
+	w http.ResponseWriter,
+	r *http.Request,
+	routeName string,
+	authenticator covalent.Authenticator,
+	wrapConnection connectionWrapper,
+	onDead func(conn process.WSConn),
+) (process.WSConn, bool) {
+	if !authenticate(w, r, routeName, authenticator) {
+		return nil, false
+	}
+
+	return upgrade(w, r, wrapConnection, onDead)
+}
+
+// authenticate checks the http connection against the authenticator, if one is provided. It returns false if the
+// connection was rejected, in which case the response was already written
+func authenticate(w http.ResponseWriter, r *http.Request, routeName string, authenticator covalent.Authenticator) bool {
+	log.Debug("new connection", "route", routeName)
+	if check.IfNil(authenticator) {
+		return true
+	}
+
+	errAuth := authenticator.Authenticate(r)
+	if errAuth != nil {
+		log.Warn("rejected unauthenticated connection",
+			"route", routeName, "remote address", r.RemoteAddr, "error", errAuth)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
+
+// upgrade upgrades the http connection to a websocket, writing the response if it fails, and wraps it
+func upgrade(
+	w http.ResponseWriter,
+	r *http.Request,
+	wrapConnection connectionWrapper,
+	onDead func(conn process.WSConn),
+) (process.WSConn, bool) {
+	var upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+	upgrader.CheckOrigin = func(r *http.Request) bool { return true }
+
+	ws, errUpgrade := upgrader.Upgrade(w, r, nil)
+	if errUpgrade != nil {
+		log.Warn("could not upgrade http connection to websocket", "error", errUpgrade)
+		return nil, false
+	}
+
+	conn, errWrap := wrapConnection(ws, onDead)
+	if errWrap != nil {
+		log.Error("could not wrap websocket connection", "error", errWrap)
+		log.LogIfError(ws.Close())
+		return nil, false
+	}
+
+	return conn, true
+}
+
+// registerConsumerRoutes registers the websocket and status routes of the named consumers. Unknown consumers are
+// answered with 404 Not Found. A resuming consumer is answered with 410 Gone if its last block is no longer retained
+func registerConsumerRoutes(
+	router *mux.Router,
+	authenticator covalent.Authenticator,
+	wrapConnection connectionWrapper,
+	consumers map[string]covalentConsumer,
+	blockLog covalent.BlockLog,
+) {
+	if len(consumers) == 0 {
+		return
 	}
 
-	routeAcknowledgeData := router.HandleFunc(args.RouteAcknowledgeData, func(w http.ResponseWriter, r *http.Request) {
-		log.Debug("new connection", "route", args.RouteAcknowledgeData)
-		var upgrader = websocket.Upgrader{
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
+	router.HandleFunc(RouteConsumerStatus, func(w http.ResponseWriter, r *http.Request) {
+		consumer, found := consumers[mux.Vars(r)["consumer"]]
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(consumer.Status())
+		if err != nil {
+			log.Debug("could not write consumer status", "consumer", consumer.Name(), "error", err)
+		}
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc(RouteConsumer, func(w http.ResponseWriter, r *http.Request) {
+		consumer, found := consumers[mux.Vars(r)["consumer"]]
+		if !found {
+			http.NotFound(w, r)
+			return
 		}
-		upgrader.CheckOrigin = func(r *http.Request) bool { return true }
 
-		ws, errUpgrade := upgrader.Upgrade(w, r, nil)
-		if errUpgrade != nil {
-			log.Warn("could not upgrade http connection to websocket", "error", errUpgrade)
+		if !authenticate(w, r, RouteConsumer, authenticator) {
 			return
 		}
 
-		ci.SetWSReceiver(ws)
+		position, err := parseResumePosition(r)
+		if err != nil {
+			log.Debug("rejected consumer connection, invalid resume position", "consumer", consumer.Name(), "error", err)
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		if position == nil {
+			ws, ok := upgrade(w, r, wrapConnection, consumer.ConnectionLost)
+			if ok {
+				consumer.SetConnection(ws)
+			}
+			return
+		}
+		if check.IfNil(blockLog) {
+			log.Debug("rejected consumer connection, block log is disabled", "consumer", consumer.Name())
+			http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+			return
+		}
+
+		err = consumer.Resume(blockLog, position, func() (process.WSConn, bool) {
+			return upgrade(w, r, wrapConnection, consumer.ConnectionLost)
+		})
+		if err == covalent.ErrResumePositionNotFound {
+			log.Warn("rejected consumer connection, resume position is not retained",
+				"consumer", consumer.Name(), "nonce", position.Nonce, "hash", hex.EncodeToString(position.Hash))
+			http.Error(w, http.StatusText(http.StatusGone), http.StatusGone)
+			return
+		}
+		if err != nil {
+			log.Error("could not read block log", "consumer", consumer.Name(), "error", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
 	})
+}
 
-	if routeAcknowledgeData.GetError() != nil {
-		log.Error("websocket router failed to acknowledge sent data",
-			"route", routeSendData.GetName(),
-			"error", routeSendData.GetError())
+// parseResumePosition returns the position a consumer resumes from, or nil if it does not resume
+func parseResumePosition(r *http.Request) (*covalent.ResumePosition, error) {
+	query := r.URL.Query()
+	lastHash := query.Get(QueryLastHash)
+	if len(lastHash) > 0 {
+		hash, err := hex.DecodeString(lastHash)
+		if err != nil {
+			return nil, err
+		}
+
+		return &covalent.ResumePosition{Hash: hash}, nil
 	}
 
-	return ci, nil
+	lastNonce := query.Get(QueryLastNonce)
+	if len(lastNonce) > 0 {
+		nonce, err := strconv.ParseUint(lastNonce, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		return &covalent.ResumePosition{Nonce: nonce}, nil
+	}
+
+	return nil, nil
 }
@@ -0,0 +1,118 @@
+package covalent
+
+import (
+	"encoding/hex"
+	"errors"
+
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-core/data/indexer"
+)
+
+// FailurePolicy defines how SaveBlock handles a block which can not be processed or encoded
+type FailurePolicy string
+
+const (
+	// FailurePolicyHalt panics, stopping the node. It is the default policy. A queued block failing on the sender
+	// of the send queue stops the queue instead, ErrSendQueueHalted being returned to the node afterwards
+	FailurePolicyHalt FailurePolicy = "halt"
+	// FailurePolicyError returns the error to the node
+	FailurePolicyError FailurePolicy = "error"
+	// FailurePolicySkip skips the block and publishes a schema.BlockProcessingFailed marker record instead
+	FailurePolicySkip FailurePolicy = "skip"
+	// FailurePolicyQuarantine skips the block after storing its raw input, so that it can be replayed later
+	FailurePolicyQuarantine FailurePolicy = "quarantine"
+)
+
+const (
+	processStage = "process"
+	encodeStage  = "encode"
+)
+
+// haltError is returned by the halt failure policy. SaveBlock panics with it, unless the block failed on the sender
+// of the send queue, which can not panic without crashing the node and stops instead
+type haltError struct {
+	stage string
+}
+
+// Error returns the message SaveBlock panics with
+func (he *haltError) Error() string {
+	return "could not " + he.stage + " block, check log"
+}
+
+func isHaltError(err error) bool {
+	var errHalt *haltError
+	return errors.As(err, &errHalt)
+}
+
+// IsFailurePolicySupported returns true if the failure policy is known. An empty policy means FailurePolicyHalt
+func IsFailurePolicySupported(policy FailurePolicy) bool {
+	switch policy {
+	case "", FailurePolicyHalt, FailurePolicyError, FailurePolicySkip, FailurePolicyQuarantine:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleFailure applies the failure policy for a block which could not be processed or encoded in the given stage.
+// With the skip policy, the failure marker is delivered the same way the block would have been, so that it keeps its
+// place in the stream
+func (ci *covalentIndexer) handleFailure(
+	args *indexer.ArgsSaveBlockData,
+	stage string,
+	cause error,
+	deliver func(item *outgoingRecord) error,
+) error {
+	marker := createFailureMarker(args, ci.shardID, stage, cause)
+	log.Error("SaveBlock failed",
+		"stage", stage,
+		"error", cause,
+		"headerHash", hex.EncodeToString(marker.Hash),
+		"nonce", marker.Nonce,
+		"policy", ci.failurePolicy)
+
+	switch ci.failurePolicy {
+	case FailurePolicyError:
+		return cause
+	case FailurePolicySkip:
+		return deliver(&outgoingRecord{
+			record: marker,
+			hash:   marker.Hash,
+			nonce:  uint64(marker.Nonce),
+			round:  uint64(marker.Round),
+			epoch:  uint32(marker.Epoch),
+		})
+	case FailurePolicyQuarantine:
+		err := ci.quarantine.Store(args, cause)
+		if err != nil {
+			log.Error("could not quarantine block", "error", err, "nonce", marker.Nonce)
+		}
+		return err
+	default:
+		return &haltError{stage: stage}
+	}
+}
+
+func createFailureMarker(args *indexer.ArgsSaveBlockData, shardID uint32, stage string, cause error) *schema.BlockProcessingFailed {
+	marker := &schema.BlockProcessingFailed{
+		Hash:    make([]byte, 0),
+		ShardID: int32(shardID),
+		Stage:   stage,
+		Error:   cause.Error(),
+	}
+	if args == nil {
+		return marker
+	}
+
+	if args.HeaderHash != nil {
+		marker.Hash = args.HeaderHash
+	}
+	if args.Header != nil {
+		marker.Nonce = int64(args.Header.GetNonce())
+		marker.Round = int64(args.Header.GetRound())
+		marker.Epoch = int32(args.Header.GetEpoch())
+		marker.ShardID = int32(args.Header.GetShardID())
+	}
+
+	return marker
+}
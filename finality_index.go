@@ -0,0 +1,74 @@
+package covalent
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/numbatx/gn-coval-index/schema"
+)
+
+// DefaultFinalityIndexSize is the number of recently saved blocks which can be finalized, if none is provided
+const DefaultFinalityIndexSize = 1000
+
+// finalityIndex keeps, in memory, the most recently saved blocks which were not yet finalized, so that finalized
+// header hashes can be resolved to the block they refer to. The oldest block is evicted when the index is full
+type finalityIndex struct {
+	mut      sync.Mutex
+	capacity int
+	blocks   map[string]*list.Element
+	order    *list.List
+}
+
+func newFinalityIndex(capacity int) *finalityIndex {
+	if capacity == 0 {
+		capacity = DefaultFinalityIndexSize
+	}
+
+	return &finalityIndex{
+		capacity: capacity,
+		blocks:   make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// add indexes the saved block, evicting the oldest one if the index is full. A block saved again is considered
+// the most recent one
+func (fi *finalityIndex) add(block *schema.Block) {
+	blockFinalized := &schema.BlockFinalized{
+		Hash:    block.Hash,
+		Nonce:   block.Nonce,
+		Round:   block.Round,
+		ShardID: block.ShardID,
+		Epoch:   block.Epoch,
+	}
+
+	fi.mut.Lock()
+	defer fi.mut.Unlock()
+
+	element, found := fi.blocks[string(block.Hash)]
+	if found {
+		element.Value = blockFinalized
+		fi.order.MoveToBack(element)
+		return
+	}
+
+	fi.blocks[string(block.Hash)] = fi.order.PushBack(blockFinalized)
+	if fi.order.Len() > fi.capacity {
+		oldest := fi.order.Remove(fi.order.Front()).(*schema.BlockFinalized)
+		delete(fi.blocks, string(oldest.Hash))
+	}
+}
+
+// take removes the block having the provided hash from the index and returns its finality record
+func (fi *finalityIndex) take(hash []byte) (*schema.BlockFinalized, bool) {
+	fi.mut.Lock()
+	defer fi.mut.Unlock()
+
+	element, found := fi.blocks[string(hash)]
+	if !found {
+		return nil, false
+	}
+
+	delete(fi.blocks, string(hash))
+	return fi.order.Remove(element).(*schema.BlockFinalized), true
+}
@@ -0,0 +1,223 @@
+package covalent_test
+
+import (
+	"encoding/binary"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/process/utility"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-coval-index/testscommon/mock"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/numbatx/gn-core/data"
+	"github.com/numbatx/gn-core/data/indexer"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCovalentDataIndexer_InvalidFinalityIndexSize(t *testing.T) {
+	t.Parallel()
+
+	args := createFinalityArgs(&mock.SinkStub{}, &mock.DataHandlerStub{})
+	args.FinalityIndexSize = -1
+
+	ci, err := covalent.NewCovalentDataIndexer(args)
+	require.Equal(t, covalent.ErrInvalidFinalityIndexSize, err)
+	require.True(t, check.IfNil(ci))
+}
+
+func TestCovalentIndexer_FinalizedBlock_ExpectFinalityPublishedAfterBlock(t *testing.T) {
+	t.Parallel()
+
+	blockRes := generateRandomValidBlockResult()
+	sink, publishedMessages := newRecordingSink()
+	ci, _ := covalent.NewCovalentDataIndexer(createFinalityArgs(sink, newBlockResultsProcessor(blockRes)))
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.FinalizedBlock(blockRes.Block.Hash))
+
+	require.Len(t, *publishedMessages, 2)
+	finalityMessage := (*publishedMessages)[1]
+	expectedBlockFinalized := &schema.BlockFinalized{
+		Hash:    blockRes.Block.Hash,
+		Nonce:   blockRes.Block.Nonce,
+		Round:   blockRes.Block.Round,
+		ShardID: blockRes.Block.ShardID,
+		Epoch:   blockRes.Block.Epoch,
+	}
+	require.Equal(t, uint64(1), finalityMessage.SequenceNumber)
+	require.Equal(t, expectedBlockFinalized, finalityMessage.Record)
+	require.Empty(t, finalityMessage.Hash)
+	require.Equal(t, uint64(blockRes.Block.Nonce), finalityMessage.Nonce)
+
+	record, _, err := utility.DecodeStreamMessageWithEnvelope(finalityMessage.Data)
+	require.Nil(t, err)
+	require.Equal(t, expectedBlockFinalized, record)
+}
+
+func TestCovalentIndexer_FinalizedBlock_ExpectAcknowledgedOnlyBySequenceNumber(t *testing.T) {
+	t.Parallel()
+
+	blockRes := generateRandomValidBlockResult()
+	retryPolicy, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+		InitialDelay: time.Millisecond,
+		Multiplier:   1,
+	})
+	ci, _ := covalent.NewCovalentDataIndexer(&covalent.ArgsCovalentDataIndexer{
+		Processor:   newBlockResultsProcessor(blockRes),
+		Server:      &http.Server{Addr: "localhost:21120"},
+		RetryPolicy: retryPolicy,
+	})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	finalityAckData := make([]byte, 8)
+	binary.BigEndian.PutUint64(finalityAckData, 1)
+	// the block hash is sent twice, the second time acknowledging nothing
+	acks := [][]byte{blockRes.Block.Hash, blockRes.Block.Hash, finalityAckData}
+	readCt := 0
+	ci.SetWSConnection(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			ackData := acks[readCt]
+			readCt++
+			return websocket.BinaryMessage, ackData, nil
+		},
+	})
+
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.FinalizedBlock(blockRes.Block.Hash))
+	require.Equal(t, 3, readCt)
+	require.Equal(t, uint64(1), retryPolicy.Retries())
+}
+
+func TestCovalentIndexer_FinalizedBlock_OlderBlock_ExpectPublishedWithLastSavedNonce(t *testing.T) {
+	t.Parallel()
+
+	blockRes1 := generateRandomValidBlockResult()
+	blockRes1.Block.Nonce = 10
+	blockRes2 := generateRandomValidBlockResult()
+	blockRes2.Block.Nonce = 11
+	sink, publishedMessages := newRecordingSink()
+	ci, _ := covalent.NewCovalentDataIndexer(createFinalityArgs(sink, newBlockResultsProcessor(blockRes1, blockRes2)))
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.FinalizedBlock(blockRes1.Block.Hash))
+
+	require.Len(t, *publishedMessages, 3)
+	finalityMessage := (*publishedMessages)[2]
+	require.Equal(t, uint64(11), finalityMessage.Nonce)
+	require.Equal(t, blockRes1.Block.Hash, finalityMessage.Record.(*schema.BlockFinalized).Hash)
+	require.Equal(t, int64(10), finalityMessage.Record.(*schema.BlockFinalized).Nonce)
+}
+
+func TestCovalentIndexer_FinalizedBlock_BlockNotInIndex_ExpectSkipped(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unknown block", func(t *testing.T) {
+		t.Parallel()
+
+		sink, publishedMessages := newRecordingSink()
+		ci, _ := covalent.NewCovalentDataIndexer(createFinalityArgs(sink, &mock.DataHandlerStub{}))
+
+		require.Nil(t, ci.FinalizedBlock([]byte("unknown hash")))
+		require.Empty(t, *publishedMessages)
+		require.Nil(t, ci.Close())
+	})
+
+	t.Run("already finalized block", func(t *testing.T) {
+		t.Parallel()
+
+		blockRes := generateRandomValidBlockResult()
+		sink, publishedMessages := newRecordingSink()
+		ci, _ := covalent.NewCovalentDataIndexer(createFinalityArgs(sink, newBlockResultsProcessor(blockRes)))
+
+		require.Nil(t, ci.SaveBlock(nil))
+		require.Nil(t, ci.FinalizedBlock(blockRes.Block.Hash))
+		require.Nil(t, ci.FinalizedBlock(blockRes.Block.Hash))
+		require.Len(t, *publishedMessages, 2)
+		require.Nil(t, ci.Close())
+	})
+
+	t.Run("evicted block", func(t *testing.T) {
+		t.Parallel()
+
+		blockRes1 := generateRandomValidBlockResult()
+		blockRes2 := generateRandomValidBlockResult()
+		sink, publishedMessages := newRecordingSink()
+		args := createFinalityArgs(sink, newBlockResultsProcessor(blockRes1, blockRes2))
+		args.FinalityIndexSize = 1
+		ci, _ := covalent.NewCovalentDataIndexer(args)
+
+		require.Nil(t, ci.SaveBlock(nil))
+		require.Nil(t, ci.SaveBlock(nil))
+		require.Nil(t, ci.FinalizedBlock(blockRes1.Block.Hash))
+		require.Len(t, *publishedMessages, 2)
+
+		require.Nil(t, ci.FinalizedBlock(blockRes2.Block.Hash))
+		require.Len(t, *publishedMessages, 3)
+		require.Equal(t, blockRes2.Block.Hash, (*publishedMessages)[2].Record.(*schema.BlockFinalized).Hash)
+		require.Nil(t, ci.Close())
+	})
+
+	t.Run("reverted block", func(t *testing.T) {
+		t.Parallel()
+
+		blockRes := generateRandomValidBlockResult()
+		sink, publishedMessages := newRecordingSink()
+		processor := newBlockResultsProcessor(blockRes)
+		processor.ProcessRevertCalled = func(_ data.HeaderHandler) (*schema.BlockRevert, error) {
+			return &schema.BlockRevert{Hash: blockRes.Block.Hash}, nil
+		}
+		ci, _ := covalent.NewCovalentDataIndexer(createFinalityArgs(sink, processor))
+
+		require.Nil(t, ci.SaveBlock(nil))
+		require.Nil(t, ci.RevertIndexedBlock(nil, nil))
+		require.Nil(t, ci.FinalizedBlock(blockRes.Block.Hash))
+		require.Len(t, *publishedMessages, 2)
+		require.Nil(t, ci.Close())
+	})
+}
+
+func createFinalityArgs(sink covalent.Sink, processor covalent.DataHandler) *covalent.ArgsCovalentDataIndexer {
+	return &covalent.ArgsCovalentDataIndexer{
+		Processor:            processor,
+		Sinks:                []covalent.Sink{sink},
+		DisableWebSocketSink: true,
+	}
+}
+
+// newBlockResultsProcessor creates a processor which returns the provided block results, in order
+func newBlockResultsProcessor(blockResults ...*schema.BlockResult) *mock.DataHandlerStub {
+	processed := 0
+	return &mock.DataHandlerStub{
+		ProcessDataCalled: func(_ *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+			blockRes := blockResults[processed]
+			processed++
+			return blockRes, nil
+		},
+	}
+}
+
+// newRecordingSink creates a sink which records all published messages, without synchronization, so it can only
+// be used by indexers without a send queue
+func newRecordingSink() (*mock.SinkStub, *[]*covalent.SinkMessage) {
+	publishedMessages := make([]*covalent.SinkMessage, 0)
+	sink := &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			publishedMessages = append(publishedMessages, message)
+			return nil
+		},
+	}
+
+	return sink, &publishedMessages
+}
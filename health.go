@@ -0,0 +1,145 @@
+package covalent
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/numbatx/gn-coval-index/process"
+)
+
+// DefaultStuckThreshold is the time blocks can wait to be acknowledged before the indexer is considered stuck,
+// if no threshold is provided
+const DefaultStuckThreshold = time.Minute
+
+// Status holds the delivery state of the covalent indexer, as reported by the health and readiness endpoints.
+// TimeSinceLastAckMs is -1 if no block was acknowledged since the indexer started. The indexer is stuck if blocks
+// are queued and none of them was acknowledged for longer than the stuck threshold. It is ready if both websockets
+// are connected and it is not stuck. QueuedBlocks includes the SendQueueDepth blocks waiting in the send queue,
+// SendQueueCapacity being zero if no send queue is used
+type Status struct {
+	SenderConnected    bool   `json:"senderConnected"`
+	ReceiverConnected  bool   `json:"receiverConnected"`
+	LastAckedHash      string `json:"lastAckedHash"`
+	LastAckedNonce     uint64 `json:"lastAckedNonce"`
+	TimeSinceLastAckMs int64  `json:"timeSinceLastAckMs"`
+	QueuedBlocks       int    `json:"queuedBlocks"`
+	SendQueueDepth     int    `json:"sendQueueDepth"`
+	SendQueueCapacity  int    `json:"sendQueueCapacity"`
+	Stuck              bool   `json:"stuck"`
+	Ready              bool   `json:"ready"`
+}
+
+// deliveryState keeps track of the blocks acknowledged by covalent
+type deliveryState struct {
+	mut            sync.RWMutex
+	lastAckedHash  []byte
+	lastAckedNonce uint64
+	lastAckTime    time.Time
+	waitingSince   time.Time
+}
+
+// blockQueued marks the time since which blocks are waiting, if no other block was waiting before
+func (ci *covalentIndexer) blockQueued(firstInQueue bool) {
+	if !firstInQueue {
+		return
+	}
+
+	ci.delivery.mut.Lock()
+	ci.delivery.waitingSince = time.Now()
+	ci.delivery.mut.Unlock()
+}
+
+func (ci *covalentIndexer) blockAcknowledged(hash []byte, nonce uint64) {
+	ci.delivery.mut.Lock()
+	ci.delivery.lastAckedHash = hash
+	ci.delivery.lastAckedNonce = nonce
+	ci.delivery.lastAckTime = time.Now()
+	ci.delivery.mut.Unlock()
+
+	ci.metrics.IncrementAcknowledged()
+	ci.metrics.SetAcknowledgedNonce(nonce)
+}
+
+// connectionFailed marks the websocket as disconnected, if it is still used as sender or receiver
+func (ci *covalentIndexer) connectionFailed(ws process.WSConn) {
+	ci.mutWSS.Lock()
+	if ci.wss == ws {
+		ci.wssFailed = true
+	}
+	ci.mutWSS.Unlock()
+
+	ci.mutWSR.Lock()
+	if ci.wsr == ws {
+		ci.wsrFailed = true
+	}
+	ci.mutWSR.Unlock()
+}
+
+// ConnectionLost closes the websocket, found dead while no block was being sent, and marks it as disconnected
+// until covalent reconnects
+func (ci *covalentIndexer) ConnectionLost(ws process.WSConn) {
+	log.Warn("covalent websocket connection lost")
+	ci.dropConnection(ws)
+}
+
+// dropConnection closes the websocket and marks it as disconnected, if it is still used as sender or receiver
+func (ci *covalentIndexer) dropConnection(ws process.WSConn) {
+	ci.mutWSS.Lock()
+	ci.mutWSR.Lock()
+	defer ci.mutWSR.Unlock()
+	defer ci.mutWSS.Unlock()
+
+	if ci.wss != ws && ci.wsr != ws {
+		return
+	}
+
+	closeConnection(ws)
+	if ci.wss == ws {
+		ci.wss = nil
+		ci.wssFailed = true
+	}
+	if ci.wsr == ws {
+		ci.wsr = nil
+		ci.wsrFailed = true
+	}
+}
+
+// Status returns the current delivery state of the indexer
+func (ci *covalentIndexer) Status() *Status {
+	ci.mutWSS.RLock()
+	senderConnected := ci.wss != nil && !ci.wssFailed
+	ci.mutWSS.RUnlock()
+
+	ci.mutWSR.RLock()
+	receiverConnected := ci.wsr != nil && !ci.wsrFailed
+	ci.mutWSR.RUnlock()
+
+	status := &Status{
+		SenderConnected:    senderConnected,
+		ReceiverConnected:  receiverConnected,
+		TimeSinceLastAckMs: -1,
+		QueuedBlocks:       ci.unsentBlocks(),
+		SendQueueDepth:     ci.sendQueueDepth(),
+		SendQueueCapacity:  cap(ci.sendQueue),
+	}
+
+	ci.delivery.mut.RLock()
+	defer ci.delivery.mut.RUnlock()
+
+	lastProgress := ci.delivery.waitingSince
+	if !ci.delivery.lastAckTime.IsZero() {
+		status.LastAckedHash = hex.EncodeToString(ci.delivery.lastAckedHash)
+		status.LastAckedNonce = ci.delivery.lastAckedNonce
+		status.TimeSinceLastAckMs = time.Since(ci.delivery.lastAckTime).Milliseconds()
+
+		if ci.delivery.lastAckTime.After(lastProgress) {
+			lastProgress = ci.delivery.lastAckTime
+		}
+	}
+
+	status.Stuck = status.QueuedBlocks > 0 && time.Since(lastProgress) > ci.stuckThreshold
+	status.Ready = senderConnected && receiverConnected && !status.Stuck
+
+	return status
+}
@@ -0,0 +1,196 @@
+package covalent_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/outbox"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-coval-index/testscommon/mock"
+	"github.com/numbatx/gn-core/data/indexer"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCovalentIndexer_Status_NoConnection_ExpectNotReady(t *testing.T) {
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	require.Equal(t, &covalent.Status{TimeSinceLastAckMs: -1}, ci.Status())
+}
+
+func TestCovalentIndexer_Status_BlockAcknowledged_ExpectReady(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	ci.SetWSConnection(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
+		},
+	})
+	require.Nil(t, ci.SaveBlock(nil))
+
+	status := ci.Status()
+	require.True(t, status.SenderConnected)
+	require.True(t, status.ReceiverConnected)
+	require.Equal(t, hex.EncodeToString(blockRes.Block.Hash), status.LastAckedHash)
+	require.Equal(t, uint64(blockRes.Block.Nonce), status.LastAckedNonce)
+	require.GreaterOrEqual(t, status.TimeSinceLastAckMs, int64(0))
+	require.Equal(t, 0, status.QueuedBlocks)
+	require.False(t, status.Stuck)
+	require.True(t, status.Ready)
+}
+
+func TestCovalentIndexer_Status_BlocksNotAcknowledged_ExpectStuck(t *testing.T) {
+	blocksOutbox, err := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+	require.Nil(t, err)
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return generateRandomValidBlockResult(), nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+			Outbox:         blocksOutbox,
+			StuckThreshold: time.Millisecond * 100,
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	ci.SetWSConnection(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, []byte{0x1}, nil
+		},
+	})
+	require.Nil(t, ci.SaveBlock(nil))
+	require.Nil(t, ci.SaveBlock(nil))
+
+	status := ci.Status()
+	require.Equal(t, 2, status.QueuedBlocks)
+	require.False(t, status.Stuck)
+	require.True(t, status.Ready)
+
+	time.Sleep(time.Millisecond * 200)
+	status = ci.Status()
+	require.Equal(t, 2, status.QueuedBlocks)
+	require.Equal(t, int64(-1), status.TimeSinceLastAckMs)
+	require.True(t, status.Stuck)
+	require.False(t, status.Ready)
+}
+
+func TestCovalentIndexer_Status_ConnectionFailed_ExpectDisconnectedUntilNewConnection(t *testing.T) {
+	blockRes := generateRandomValidBlockResult()
+
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{
+				ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+					return blockRes, nil
+				},
+			},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	ci.SetWSSender(&mock.WSConnStub{
+		WriteMessageCalled: func(messageType int, data []byte) error {
+			return errors.New("write message error")
+		},
+	})
+	ci.SetWSReceiver(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return websocket.BinaryMessage, blockRes.Block.Hash, nil
+		},
+	})
+
+	saveBlockErr := make(chan error, 1)
+	go func() {
+		saveBlockErr <- ci.SaveBlock(nil)
+	}()
+
+	time.Sleep(time.Millisecond * 100)
+	status := ci.Status()
+	require.False(t, status.SenderConnected)
+	require.True(t, status.ReceiverConnected)
+	require.Equal(t, 1, status.QueuedBlocks)
+	require.False(t, status.Ready)
+
+	ci.SetWSSender(&mock.WSConnStub{})
+	require.Nil(t, <-saveBlockErr)
+
+	status = ci.Status()
+	require.True(t, status.SenderConnected)
+	require.Equal(t, 0, status.QueuedBlocks)
+	require.True(t, status.Ready)
+}
+
+func TestCovalentIndexer_ConnectionLost_ExpectClosedAndDisconnected(t *testing.T) {
+	ci, _ := covalent.NewCovalentDataIndexer(
+		&covalent.ArgsCovalentDataIndexer{
+			Processor: &mock.DataHandlerStub{},
+			Server: &http.Server{
+				Addr: "localhost:21119",
+			},
+		})
+	defer func() {
+		_ = ci.Close()
+	}()
+
+	closeCt := 0
+	ws := &mock.WSConnStub{
+		CloseCalled: func() error {
+			closeCt++
+			return nil
+		},
+	}
+	ci.SetWSConnection(ws)
+
+	ci.ConnectionLost(&mock.WSConnStub{})
+	require.True(t, ci.Status().SenderConnected)
+	require.True(t, ci.Status().ReceiverConnected)
+
+	ci.ConnectionLost(ws)
+	require.Equal(t, 1, closeCt)
+	require.False(t, ci.Status().SenderConnected)
+	require.False(t, ci.Status().ReceiverConnected)
+
+	ci.SetWSConnection(&mock.WSConnStub{})
+	require.Equal(t, 1, closeCt)
+	require.True(t, ci.Status().SenderConnected)
+	require.True(t, ci.Status().ReceiverConnected)
+}
@@ -1,14 +1,23 @@
 package covalent
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/numbatx/gn-coval-index/schema"
 	"github.com/numbatx/gn-core/data"
 	"github.com/numbatx/gn-core/data/indexer"
 	vmcommon "github.com/numbatx/gn-vm-common"
+	"github.com/elodina/go-avro"
 )
 
 type DataHandler interface {
 	ProcessData(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error)
+	ProcessRevert(header data.HeaderHandler) (*schema.BlockRevert, error)
+	ProcessAccounts(blockTimestamp uint64, accounts []data.UserAccountHandler) *schema.AccountsSnapshot
+	ProcessRoundsInfo(roundsInfo []*indexer.RoundInfo) *schema.RoundsInfo
+	ProcessValidatorsPubKeys(validatorsPubKeys map[uint32][][]byte, epoch uint32) []*schema.EpochValidators
+	ProcessValidatorsRating(indexID string, ratingsInfo []*indexer.ValidatorRatingInfo) (*schema.ValidatorsRating, error)
 }
 
 type Driver interface {
@@ -19,11 +28,107 @@ type Driver interface {
 	SaveValidatorsRating(indexID string, infoRating []*indexer.ValidatorRatingInfo) error
 	SaveAccounts(blockTimestamp uint64, acc []data.UserAccountHandler) error
 	FinalizedBlock(headerHash []byte) error
+	Status() *Status
 	Close() error
 	IsInterfaceNil() bool
 }
 
+// AccountsAdapter defines the accounts which were loaded while processing blocks.
+//
+// Deprecated: account state is built from the accounts passed to SaveAccounts, nothing is loaded anymore
 type AccountsAdapter interface {
 	LoadAccount(address []byte) (vmcommon.AccountHandler, error)
 	IsInterfaceNil() bool
 }
+
+// OutboxEntry holds an encoded block result which waits to be acknowledged by covalent
+type OutboxEntry struct {
+	ID      uint64
+	Nonce   uint64
+	AckData []byte
+	Payload []byte
+}
+
+// Outbox defines what a durable, ordered storage of data waiting to be acknowledged shall do
+type Outbox interface {
+	Append(entry *OutboxEntry) error
+	Get(index int) (*OutboxEntry, error)
+	RemoveHead() error
+	Len() int
+	NextID() uint64
+	Close() error
+	IsInterfaceNil() bool
+}
+
+// SinkMessage holds an avro record(e.g. a block result) together with its encoded data, as sent to covalent, and
+// the block it refers to. Hash is empty for records which do not refer to a single block(e.g. rounds info)
+type SinkMessage struct {
+	SequenceNumber uint64
+	Nonce          uint64
+	Round          uint64
+	Epoch          uint32
+	Hash           []byte
+	Record         avro.AvroRecord
+	Data           []byte
+}
+
+// Sink defines what a destination of encoded stream messages shall do
+type Sink interface {
+	Publish(message *SinkMessage) error
+	Close() error
+	IsInterfaceNil() bool
+}
+
+// ResumePosition holds the last block processed by a consumer. Hash, if provided, takes precedence over Nonce
+type ResumePosition struct {
+	Nonce uint64
+	Hash  []byte
+}
+
+// BlockLog defines what a retained log of published block results shall do
+type BlockLog interface {
+	Sink
+	ReadAfter(position *ResumePosition) ([]*SinkMessage, error)
+}
+
+// Quarantine defines what a storage of blocks which could not be indexed shall do
+type Quarantine interface {
+	Store(args *indexer.ArgsSaveBlockData, cause error) error
+	IsInterfaceNil() bool
+}
+
+// Authenticator defines what a websocket connection request authenticator shall do
+type Authenticator interface {
+	Authenticate(request *http.Request) error
+	IsInterfaceNil() bool
+}
+
+// RetryPolicy defines what a policy deciding when an operation is retried and when it is given up shall do
+type RetryPolicy interface {
+	NewBackoff() Backoff
+	OnExhausted() RetryExhaustedAction
+	Retries() uint64
+	ExhaustedRetries() uint64
+	IsInterfaceNil() bool
+}
+
+// Backoff defines what the retry state of a single operation shall do
+type Backoff interface {
+	NextDelay() (time.Duration, bool)
+	Retries() int
+}
+
+// MetricsHandler defines what a collector of indexing pipeline metrics shall do
+type MetricsHandler interface {
+	ObserveStageDuration(stage string, duration time.Duration)
+	ObservePayloadSize(size int)
+	IncrementSent()
+	IncrementAcknowledged()
+	IncrementRetries()
+	IncrementReconnects(socket string)
+	IncrementTimeouts(operation string)
+	IncrementDeliveryErrors(operation string)
+	SetSavedNonce(nonce uint64)
+	SetAcknowledgedNonce(nonce uint64)
+	IsInterfaceNil() bool
+}
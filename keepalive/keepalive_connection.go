@@ -0,0 +1,255 @@
+package keepalive
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/process"
+	"github.com/numbatx/gn-core/core/check"
+	logger "github.com/numbatx/gn-logger"
+	"github.com/gorilla/websocket"
+)
+
+var log = logger.GetOrCreate("covalent/keepalive")
+
+const (
+	// DefaultPongTimeout is the time a pong is waited for, if none is provided
+	DefaultPongTimeout = time.Second * 10
+
+	// receivedMessagesBufferSize is the number of received messages kept until they are read. Once the buffer is
+	// full, the wrapped websocket is no longer read until a message is read, so that the peer is slowed down
+	receivedMessagesBufferSize = 16
+)
+
+// WebSocket defines what a websocket wrapped by a keepalive connection shall do
+type WebSocket interface {
+	process.WSConn
+	WriteControl(messageType int, data []byte, deadline time.Time) error
+	SetPongHandler(handler func(appData string) error)
+}
+
+// ArgsKeepaliveConnection holds all input dependencies required by keepalive connection in order to create a new
+// instance. PongTimeout defaults to DefaultPongTimeout. OnDead is optional and called, only once, if the
+// connection is found dead
+type ArgsKeepaliveConnection struct {
+	Conn         WebSocket
+	PingInterval time.Duration
+	PongTimeout  time.Duration
+	OnDead       func(conn process.WSConn)
+}
+
+type receivedMessage struct {
+	messageType int
+	data        []byte
+}
+
+type keepaliveConnection struct {
+	conn            WebSocket
+	pingInterval    time.Duration
+	pongTimeout     time.Duration
+	onDead          func(conn process.WSConn)
+	received        chan *receivedMessage
+	mutReadDeadline sync.Mutex
+	readDeadline    time.Time
+	closeOnce       sync.Once
+	closed          chan struct{}
+	closeErr        error
+}
+
+// NewKeepaliveConnection creates a new websocket connection which pings its peer every PingInterval and is
+// considered dead if neither a pong nor any other message is received within PongTimeout after a ping. A dead
+// connection is closed, so that the peer reconnects, without waiting for the next block to be sent. The wrapped
+// websocket is read continuously, in order to handle pongs, read deadlines only applying to ReadMessage calls.
+// Received messages are never dropped: while the buffer of unread messages is full, the wrapped websocket is not
+// read and the peer is considered alive, since it proved it by sending them
+func NewKeepaliveConnection(args *ArgsKeepaliveConnection) (*keepaliveConnection, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if check.IfNilReflect(args.Conn) {
+		return nil, covalent.ErrNilWebSocket
+	}
+	if args.PingInterval <= 0 {
+		return nil, covalent.ErrInvalidKeepaliveInterval
+	}
+	if args.PongTimeout < 0 {
+		return nil, covalent.ErrInvalidPongTimeout
+	}
+
+	pongTimeout := args.PongTimeout
+	if pongTimeout == 0 {
+		pongTimeout = DefaultPongTimeout
+	}
+
+	kc := &keepaliveConnection{
+		conn:         args.Conn,
+		pingInterval: args.PingInterval,
+		pongTimeout:  pongTimeout,
+		onDead:       args.OnDead,
+		received:     make(chan *receivedMessage, receivedMessagesBufferSize),
+		closed:       make(chan struct{}),
+	}
+
+	kc.conn.SetPongHandler(func(_ string) error {
+		return kc.extendLiveness()
+	})
+	err := kc.extendLiveness()
+	if err != nil {
+		return nil, err
+	}
+
+	go kc.readLoop()
+	go kc.pingLoop()
+
+	return kc, nil
+}
+
+// extendLiveness allows the peer one more ping interval, plus the pong timeout, to prove it is alive
+func (kc *keepaliveConnection) extendLiveness() error {
+	return kc.conn.SetReadDeadline(time.Now().Add(kc.pingInterval + kc.pongTimeout))
+}
+
+func (kc *keepaliveConnection) readLoop() {
+	for {
+		messageType, data, err := kc.conn.ReadMessage()
+		if err != nil {
+			kc.connectionDead(err)
+			return
+		}
+
+		isBuffered := kc.bufferMessage(&receivedMessage{
+			messageType: messageType,
+			data:        data,
+		})
+		if !isBuffered {
+			return
+		}
+
+		// liveness is extended only once the message is buffered, since pongs are not read while waiting for it
+		err = kc.extendLiveness()
+		if err != nil {
+			kc.connectionDead(err)
+			return
+		}
+	}
+}
+
+// bufferMessage waits until the received message can be buffered, applying backpressure to a peer which sends
+// messages faster than they are read instead of dropping any of them. It returns false if the connection was
+// closed meanwhile
+func (kc *keepaliveConnection) bufferMessage(message *receivedMessage) bool {
+	select {
+	case kc.received <- message:
+		return true
+	case <-kc.closed:
+		return false
+	}
+}
+
+func (kc *keepaliveConnection) pingLoop() {
+	ticker := time.NewTicker(kc.pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-kc.closed:
+			return
+		}
+
+		err := kc.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(kc.pongTimeout))
+		if err != nil {
+			kc.connectionDead(err)
+			return
+		}
+	}
+}
+
+// connectionDead closes the connection and notifies it, unless it was already closed
+func (kc *keepaliveConnection) connectionDead(err error) {
+	isDead := kc.close(err)
+	if !isDead {
+		return
+	}
+
+	log.Debug("websocket connection is dead", "error", err)
+	if kc.onDead != nil {
+		kc.onDead(kc)
+	}
+}
+
+// close closes the wrapped websocket, recording the error returned by subsequent reads. It returns false if the
+// connection was already closed
+func (kc *keepaliveConnection) close(err error) bool {
+	isClosedNow := false
+	kc.closeOnce.Do(func() {
+		kc.closeErr = err
+		close(kc.closed)
+		log.LogIfError(kc.conn.Close())
+		isClosedNow = true
+	})
+
+	return isClosedNow
+}
+
+// ReadMessage returns the oldest received message which was not yet read. It returns os.ErrDeadlineExceeded if no
+// message was received before the read deadline
+func (kc *keepaliveConnection) ReadMessage() (messageType int, p []byte, err error) {
+	var deadlineExceeded <-chan time.Time
+
+	deadline := kc.getReadDeadline()
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		deadlineExceeded = timer.C
+	}
+
+	select {
+	case message := <-kc.received:
+		return message.messageType, message.data, nil
+	case <-kc.closed:
+		return 0, nil, kc.closeErr
+	case <-deadlineExceeded:
+		return 0, nil, os.ErrDeadlineExceeded
+	}
+}
+
+// WriteMessage writes the message to the wrapped websocket
+func (kc *keepaliveConnection) WriteMessage(messageType int, data []byte) error {
+	return kc.conn.WriteMessage(messageType, data)
+}
+
+// SetReadDeadline sets the deadline of the following ReadMessage calls. A zero value means they do not time out
+func (kc *keepaliveConnection) SetReadDeadline(t time.Time) error {
+	kc.mutReadDeadline.Lock()
+	kc.readDeadline = t
+	kc.mutReadDeadline.Unlock()
+
+	return nil
+}
+
+func (kc *keepaliveConnection) getReadDeadline() time.Time {
+	kc.mutReadDeadline.Lock()
+	defer kc.mutReadDeadline.Unlock()
+
+	return kc.readDeadline
+}
+
+// SetWriteDeadline sets the write deadline of the wrapped websocket
+func (kc *keepaliveConnection) SetWriteDeadline(t time.Time) error {
+	return kc.conn.SetWriteDeadline(t)
+}
+
+// Close stops the keepalive and closes the wrapped websocket
+func (kc *keepaliveConnection) Close() error {
+	kc.close(net.ErrClosed)
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (kc *keepaliveConnection) IsInterfaceNil() bool {
+	return kc == nil
+}
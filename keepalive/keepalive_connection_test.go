@@ -0,0 +1,265 @@
+package keepalive_test
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/keepalive"
+	"github.com/numbatx/gn-coval-index/process"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKeepaliveConnection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *keepalive.ArgsKeepaliveConnection
+		expectedErr error
+	}{
+		{
+			args: func() *keepalive.ArgsKeepaliveConnection {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args: func() *keepalive.ArgsKeepaliveConnection {
+				return &keepalive.ArgsKeepaliveConnection{Conn: nil, PingInterval: time.Second}
+			},
+			expectedErr: covalent.ErrNilWebSocket,
+		},
+		{
+			args: func() *keepalive.ArgsKeepaliveConnection {
+				conn, _ := newConnectionPair(t)
+				return &keepalive.ArgsKeepaliveConnection{Conn: conn, PingInterval: 0}
+			},
+			expectedErr: covalent.ErrInvalidKeepaliveInterval,
+		},
+		{
+			args: func() *keepalive.ArgsKeepaliveConnection {
+				conn, _ := newConnectionPair(t)
+				return &keepalive.ArgsKeepaliveConnection{Conn: conn, PingInterval: time.Second, PongTimeout: -time.Second}
+			},
+			expectedErr: covalent.ErrInvalidPongTimeout,
+		},
+		{
+			args: func() *keepalive.ArgsKeepaliveConnection {
+				conn, _ := newConnectionPair(t)
+				return &keepalive.ArgsKeepaliveConnection{Conn: conn, PingInterval: time.Second}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := keepalive.NewKeepaliveConnection(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(instance))
+		if err == nil {
+			require.Nil(t, instance.Close())
+		}
+	}
+}
+
+func TestKeepaliveConnection_PeerAnswersPings_ExpectConnectionKeptAlive(t *testing.T) {
+	t.Parallel()
+
+	conn, peer := newConnectionPair(t)
+	peerReceived := make(chan []byte, 1)
+	go func() {
+		// reading makes the peer answer pings with pongs
+		for {
+			_, data, err := peer.ReadMessage()
+			if err != nil {
+				return
+			}
+			peerReceived <- data
+		}
+	}()
+
+	dead := make(chan struct{}, 1)
+	kc, _ := keepalive.NewKeepaliveConnection(&keepalive.ArgsKeepaliveConnection{
+		Conn:         conn,
+		PingInterval: time.Millisecond * 20,
+		PongTimeout:  time.Millisecond * 50,
+		OnDead: func(_ process.WSConn) {
+			dead <- struct{}{}
+		},
+	})
+	defer func() {
+		_ = kc.Close()
+	}()
+
+	select {
+	case <-dead:
+		require.Fail(t, "connection should be kept alive")
+	case <-time.After(time.Millisecond * 300):
+	}
+
+	require.Nil(t, kc.WriteMessage(websocket.BinaryMessage, []byte("block")))
+	require.Equal(t, []byte("block"), <-peerReceived)
+
+	require.Nil(t, peer.WriteMessage(websocket.BinaryMessage, []byte("ack")))
+	msgType, ackData, err := kc.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, websocket.BinaryMessage, msgType)
+	require.Equal(t, []byte("ack"), ackData)
+}
+
+func TestKeepaliveConnection_MessagesNotRead_ExpectNoneDroppedAndConnectionKeptAlive(t *testing.T) {
+	t.Parallel()
+
+	conn, peer := newConnectionPair(t)
+	go func() {
+		// reading makes the peer answer pings with pongs
+		for {
+			_, _, err := peer.ReadMessage()
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	dead := make(chan struct{}, 1)
+	kc, _ := keepalive.NewKeepaliveConnection(&keepalive.ArgsKeepaliveConnection{
+		Conn:         conn,
+		PingInterval: time.Millisecond * 20,
+		PongTimeout:  time.Millisecond * 50,
+		OnDead: func(_ process.WSConn) {
+			dead <- struct{}{}
+		},
+	})
+	defer func() {
+		_ = kc.Close()
+	}()
+
+	numMessages := 40
+	for idx := 0; idx < numMessages; idx++ {
+		require.Nil(t, peer.WriteMessage(websocket.BinaryMessage, []byte{byte(idx)}))
+	}
+
+	// messages are not read for longer than the liveness allowed by a ping
+	select {
+	case <-dead:
+		require.Fail(t, "connection should be kept alive while messages are not read")
+	case <-time.After(time.Millisecond * 300):
+	}
+
+	for idx := 0; idx < numMessages; idx++ {
+		_, data, err := kc.ReadMessage()
+		require.Nil(t, err)
+		require.Equal(t, []byte{byte(idx)}, data)
+	}
+
+	select {
+	case <-dead:
+		require.Fail(t, "connection should be kept alive once messages are read")
+	case <-time.After(time.Millisecond * 200):
+	}
+}
+
+func TestKeepaliveConnection_NoPong_ExpectConnectionDead(t *testing.T) {
+	t.Parallel()
+
+	// the peer never reads, so pings are not answered
+	conn, _ := newConnectionPair(t)
+
+	dead := make(chan process.WSConn, 1)
+	kc, _ := keepalive.NewKeepaliveConnection(&keepalive.ArgsKeepaliveConnection{
+		Conn:         conn,
+		PingInterval: time.Millisecond * 20,
+		PongTimeout:  time.Millisecond * 50,
+		OnDead: func(conn process.WSConn) {
+			dead <- conn
+		},
+	})
+
+	select {
+	case deadConn := <-dead:
+		require.True(t, deadConn == kc)
+	case <-time.After(time.Second):
+		require.Fail(t, "connection not found dead")
+	}
+
+	_, _, err := kc.ReadMessage()
+	require.NotNil(t, err)
+	require.Nil(t, kc.Close())
+}
+
+func TestKeepaliveConnection_ReadMessage_DeadlineExceeded_ExpectConnectionStillUsable(t *testing.T) {
+	t.Parallel()
+
+	conn, peer := newConnectionPair(t)
+	kc, _ := keepalive.NewKeepaliveConnection(&keepalive.ArgsKeepaliveConnection{
+		Conn:         conn,
+		PingInterval: time.Second,
+	})
+	defer func() {
+		_ = kc.Close()
+	}()
+
+	require.Nil(t, kc.SetReadDeadline(time.Now().Add(time.Millisecond*50)))
+	_, _, err := kc.ReadMessage()
+	require.Equal(t, os.ErrDeadlineExceeded, err)
+
+	require.Nil(t, kc.SetReadDeadline(time.Time{}))
+	require.Nil(t, peer.WriteMessage(websocket.BinaryMessage, []byte("ack")))
+	_, ackData, err := kc.ReadMessage()
+	require.Nil(t, err)
+	require.Equal(t, []byte("ack"), ackData)
+}
+
+func TestKeepaliveConnection_Close_ExpectNotReportedDead(t *testing.T) {
+	t.Parallel()
+
+	conn, _ := newConnectionPair(t)
+	dead := make(chan struct{}, 1)
+	kc, _ := keepalive.NewKeepaliveConnection(&keepalive.ArgsKeepaliveConnection{
+		Conn:         conn,
+		PingInterval: time.Millisecond * 20,
+		OnDead: func(_ process.WSConn) {
+			dead <- struct{}{}
+		},
+	})
+
+	require.Nil(t, kc.Close())
+	require.Nil(t, kc.Close())
+
+	_, _, err := kc.ReadMessage()
+	require.Equal(t, net.ErrClosed, err)
+	select {
+	case <-dead:
+		require.Fail(t, "closed connection should not be reported dead")
+	case <-time.After(time.Millisecond * 100):
+	}
+}
+
+// newConnectionPair returns the server side and the client side of a new websocket connection
+func newConnectionPair(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	serverConn := make(chan *websocket.Conn, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader := websocket.Upgrader{}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		serverConn <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	clientConn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.Nil(t, err)
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+	})
+
+	return <-serverConn, clientConn
+}
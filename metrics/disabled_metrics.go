@@ -0,0 +1,56 @@
+package metrics
+
+import "time"
+
+type disabledMetrics struct {
+}
+
+// NewDisabledMetrics creates a metrics collector which does not record anything
+func NewDisabledMetrics() *disabledMetrics {
+	return &disabledMetrics{}
+}
+
+// ObserveStageDuration does nothing
+func (dm *disabledMetrics) ObserveStageDuration(_ string, _ time.Duration) {
+}
+
+// ObservePayloadSize does nothing
+func (dm *disabledMetrics) ObservePayloadSize(_ int) {
+}
+
+// IncrementSent does nothing
+func (dm *disabledMetrics) IncrementSent() {
+}
+
+// IncrementAcknowledged does nothing
+func (dm *disabledMetrics) IncrementAcknowledged() {
+}
+
+// IncrementRetries does nothing
+func (dm *disabledMetrics) IncrementRetries() {
+}
+
+// IncrementReconnects does nothing
+func (dm *disabledMetrics) IncrementReconnects(_ string) {
+}
+
+// IncrementTimeouts does nothing
+func (dm *disabledMetrics) IncrementTimeouts(_ string) {
+}
+
+// IncrementDeliveryErrors does nothing
+func (dm *disabledMetrics) IncrementDeliveryErrors(_ string) {
+}
+
+// SetSavedNonce does nothing
+func (dm *disabledMetrics) SetSavedNonce(_ uint64) {
+}
+
+// SetAcknowledgedNonce does nothing
+func (dm *disabledMetrics) SetAcknowledgedNonce(_ uint64) {
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (dm *disabledMetrics) IsInterfaceNil() bool {
+	return dm == nil
+}
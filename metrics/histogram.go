@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// histogram counts observed values in cumulative buckets, as defined by the Prometheus text format.
+// It is not concurrent safe
+type histogram struct {
+	upperBounds []float64
+	counts      []uint64
+	sum         float64
+	count       uint64
+}
+
+func newHistogram(upperBounds []float64) *histogram {
+	return &histogram{
+		upperBounds: upperBounds,
+		counts:      make([]uint64, len(upperBounds)),
+	}
+}
+
+func (h *histogram) observe(value float64) {
+	for idx, upperBound := range h.upperBounds {
+		if value <= upperBound {
+			h.counts[idx]++
+		}
+	}
+
+	h.sum += value
+	h.count++
+}
+
+// write writes the histogram samples. Labels, if any, are written before the bucket label
+func (h *histogram) write(w io.Writer, name string, labels string) error {
+	separator := ""
+	if len(labels) > 0 {
+		separator = ","
+	}
+
+	for idx, upperBound := range h.upperBounds {
+		_, err := fmt.Fprintf(w, "%s_bucket{%s%sle=\"%s\"} %d\n", name, labels, separator, formatFloat(upperBound), h.counts[idx])
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, separator, h.count)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s_sum%s %s\n", name, wrapLabels(labels), formatFloat(h.sum))
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s_count%s %d\n", name, wrapLabels(labels), h.count)
+	return err
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func wrapLabels(labels string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	return "{" + labels + "}"
+}
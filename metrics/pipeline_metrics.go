@@ -0,0 +1,319 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	logger "github.com/numbatx/gn-logger"
+)
+
+var log = logger.GetOrCreate("covalent/metrics")
+
+// ContentType is the content type of the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	// StageBlock is the stage converting the block header and mini blocks
+	StageBlock = "block"
+	// StageTransactions is the stage converting transactions
+	StageTransactions = "transactions"
+	// StageSCRs is the stage converting smart contract results
+	StageSCRs = "scrs"
+	// StageReceipts is the stage converting receipts
+	StageReceipts = "receipts"
+	// StageLogs is the stage converting logs
+	StageLogs = "logs"
+	// StageAccounts is the stage converting saved accounts
+	StageAccounts = "accounts"
+)
+
+const (
+	// SocketSender labels the websocket used to send data to covalent
+	SocketSender = "wss"
+	// SocketReceiver labels the websocket used to receive acknowledge data from covalent
+	SocketReceiver = "wsr"
+)
+
+const (
+	// OperationWrite labels the writing of a payload to covalent
+	OperationWrite = "write"
+	// OperationAck labels the waiting for an acknowledge from covalent
+	OperationAck = "ack"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the stage latency histogram buckets
+var LatencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// SizeBuckets are the upper bounds, in bytes, of the encoded payload size histogram buckets
+var SizeBuckets = []float64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20, 64 << 20}
+
+type pipelineMetrics struct {
+	mut               sync.Mutex
+	stageDurations    map[string]*histogram
+	payloadSizes      *histogram
+	sent              uint64
+	acknowledged      uint64
+	retries           uint64
+	reconnects        map[string]uint64
+	timeouts          map[string]uint64
+	deliveryErrors    map[string]uint64
+	savedNonce        uint64
+	acknowledgedNonce uint64
+}
+
+// NewPipelineMetrics creates a new collector of indexing pipeline metrics, which serves them over http in
+// Prometheus text format
+func NewPipelineMetrics() *pipelineMetrics {
+	return &pipelineMetrics{
+		stageDurations: make(map[string]*histogram),
+		payloadSizes:   newHistogram(SizeBuckets),
+		reconnects: map[string]uint64{
+			SocketSender:   0,
+			SocketReceiver: 0,
+		},
+		timeouts: map[string]uint64{
+			OperationWrite: 0,
+			OperationAck:   0,
+		},
+		deliveryErrors: map[string]uint64{
+			OperationWrite: 0,
+			OperationAck:   0,
+		},
+	}
+}
+
+// ObserveStageDuration records how long a processing stage took
+func (pm *pipelineMetrics) ObserveStageDuration(stage string, duration time.Duration) {
+	pm.mut.Lock()
+	defer pm.mut.Unlock()
+
+	stageDuration, found := pm.stageDurations[stage]
+	if !found {
+		stageDuration = newHistogram(LatencyBuckets)
+		pm.stageDurations[stage] = stageDuration
+	}
+
+	stageDuration.observe(duration.Seconds())
+}
+
+// ObservePayloadSize records the size of an encoded payload
+func (pm *pipelineMetrics) ObservePayloadSize(size int) {
+	pm.mut.Lock()
+	pm.payloadSizes.observe(float64(size))
+	pm.mut.Unlock()
+}
+
+// IncrementSent counts a payload written to covalent
+func (pm *pipelineMetrics) IncrementSent() {
+	pm.mut.Lock()
+	pm.sent++
+	pm.mut.Unlock()
+}
+
+// IncrementAcknowledged counts a payload acknowledged by covalent
+func (pm *pipelineMetrics) IncrementAcknowledged() {
+	pm.mut.Lock()
+	pm.acknowledged++
+	pm.mut.Unlock()
+}
+
+// IncrementRetries counts a payload scheduled to be sent again
+func (pm *pipelineMetrics) IncrementRetries() {
+	pm.mut.Lock()
+	pm.retries++
+	pm.mut.Unlock()
+}
+
+// IncrementReconnects counts a websocket which replaced a previous one
+func (pm *pipelineMetrics) IncrementReconnects(socket string) {
+	pm.mut.Lock()
+	pm.reconnects[socket]++
+	pm.mut.Unlock()
+}
+
+// IncrementTimeouts counts a write or an acknowledge which did not complete before its deadline
+func (pm *pipelineMetrics) IncrementTimeouts(operation string) {
+	pm.mut.Lock()
+	pm.timeouts[operation]++
+	pm.mut.Unlock()
+}
+
+// IncrementDeliveryErrors counts a write or an acknowledge which failed for any reason other than a timeout
+func (pm *pipelineMetrics) IncrementDeliveryErrors(operation string) {
+	pm.mut.Lock()
+	pm.deliveryErrors[operation]++
+	pm.mut.Unlock()
+}
+
+// SetSavedNonce sets the nonce of the latest block received through SaveBlock
+func (pm *pipelineMetrics) SetSavedNonce(nonce uint64) {
+	pm.mut.Lock()
+	pm.savedNonce = nonce
+	pm.mut.Unlock()
+}
+
+// SetAcknowledgedNonce sets the nonce of the latest block acknowledged by covalent
+func (pm *pipelineMetrics) SetAcknowledgedNonce(nonce uint64) {
+	pm.mut.Lock()
+	pm.acknowledgedNonce = nonce
+	pm.mut.Unlock()
+}
+
+// ServeHTTP writes all metrics in Prometheus text format
+func (pm *pipelineMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	buff := &bytes.Buffer{}
+	err := pm.Write(buff)
+	if err != nil {
+		log.Error("could not write metrics", "error", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	_, err = w.Write(buff.Bytes())
+	if err != nil {
+		log.Debug("could not send metrics", "error", err)
+	}
+}
+
+// Write writes all metrics in Prometheus text format
+func (pm *pipelineMetrics) Write(w io.Writer) error {
+	pm.mut.Lock()
+	defer pm.mut.Unlock()
+
+	err := writeHeader(w, "covalent_stage_duration_seconds", "histogram", "Duration of each block processing stage.")
+	if err != nil {
+		return err
+	}
+	for _, stage := range pm.sortedStages() {
+		err = pm.stageDurations[stage].write(w, "covalent_stage_duration_seconds", fmt.Sprintf("stage=%q", stage))
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeHeader(w, "covalent_payload_size_bytes", "histogram", "Size of encoded payloads.")
+	if err != nil {
+		return err
+	}
+	err = pm.payloadSizes.write(w, "covalent_payload_size_bytes", "")
+	if err != nil {
+		return err
+	}
+
+	counters := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{name: "covalent_payloads_sent_total", help: "Payloads written to covalent, resends included.", value: pm.sent},
+		{name: "covalent_payloads_acknowledged_total", help: "Payloads acknowledged by covalent.", value: pm.acknowledged},
+		{name: "covalent_send_retries_total", help: "Payloads scheduled to be sent again.", value: pm.retries},
+	}
+	for _, counter := range counters {
+		err = writeSample(w, counter.name, "counter", counter.help, counter.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	labeledCounters := []struct {
+		name   string
+		help   string
+		label  string
+		values map[string]uint64
+		keys   []string
+	}{
+		{
+			name:   "covalent_websocket_reconnects_total",
+			help:   "Websockets which replaced a previous one.",
+			label:  "socket",
+			values: pm.reconnects,
+			keys:   []string{SocketSender, SocketReceiver},
+		},
+		{
+			name:   "covalent_delivery_timeouts_total",
+			help:   "Writes and acknowledges which did not complete before their deadline.",
+			label:  "operation",
+			values: pm.timeouts,
+			keys:   []string{OperationWrite, OperationAck},
+		},
+		{
+			name:   "covalent_delivery_errors_total",
+			help:   "Writes and acknowledges which failed for reasons other than timeouts.",
+			label:  "operation",
+			values: pm.deliveryErrors,
+			keys:   []string{OperationWrite, OperationAck},
+		},
+	}
+	for _, counter := range labeledCounters {
+		err = writeHeader(w, counter.name, "counter", counter.help)
+		if err != nil {
+			return err
+		}
+		for _, key := range counter.keys {
+			_, err = fmt.Fprintf(w, "%s{%s=%q} %d\n", counter.name, counter.label, key, counter.values[key])
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	lag := uint64(0)
+	if pm.savedNonce > pm.acknowledgedNonce {
+		lag = pm.savedNonce - pm.acknowledgedNonce
+	}
+
+	gauges := []struct {
+		name  string
+		help  string
+		value uint64
+	}{
+		{name: "covalent_saved_nonce", help: "Nonce of the latest saved block.", value: pm.savedNonce},
+		{name: "covalent_acknowledged_nonce", help: "Nonce of the latest block acknowledged by covalent.", value: pm.acknowledgedNonce},
+		{name: "covalent_nonce_lag", help: "Difference between the latest saved and acknowledged nonces.", value: lag},
+	}
+	for _, gauge := range gauges {
+		err = writeSample(w, gauge.name, "gauge", gauge.help, gauge.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (pm *pipelineMetrics) IsInterfaceNil() bool {
+	return pm == nil
+}
+
+func writeHeader(w io.Writer, name string, metricType string, help string) error {
+	_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+	return err
+}
+
+func writeSample(w io.Writer, name string, metricType string, help string, value uint64) error {
+	err := writeHeader(w, name, metricType, help)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "%s %d\n", name, value)
+	return err
+}
+
+func (pm *pipelineMetrics) sortedStages() []string {
+	stages := make([]string, 0, len(pm.stageDurations))
+	for stage := range pm.stageDurations {
+		stages = append(stages, stage)
+	}
+	sort.Strings(stages)
+
+	return stages
+}
@@ -0,0 +1,111 @@
+package metrics_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index/metrics"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPipelineMetrics(t *testing.T) {
+	t.Parallel()
+
+	require.False(t, check.IfNil(metrics.NewPipelineMetrics()))
+	require.False(t, check.IfNil(metrics.NewDisabledMetrics()))
+}
+
+func TestPipelineMetrics_Write_ExpectPrometheusTextFormat(t *testing.T) {
+	t.Parallel()
+
+	pm := metrics.NewPipelineMetrics()
+	pm.ObserveStageDuration(metrics.StageTransactions, time.Millisecond*3)
+	pm.ObserveStageDuration(metrics.StageBlock, time.Millisecond*20)
+	pm.ObserveStageDuration(metrics.StageBlock, time.Second*20)
+	pm.ObservePayloadSize(2000)
+	pm.IncrementSent()
+	pm.IncrementSent()
+	pm.IncrementAcknowledged()
+	pm.IncrementRetries()
+	pm.IncrementReconnects(metrics.SocketReceiver)
+	pm.IncrementTimeouts(metrics.OperationAck)
+	pm.IncrementDeliveryErrors(metrics.OperationWrite)
+	pm.SetSavedNonce(12)
+	pm.SetAcknowledgedNonce(9)
+
+	buff := &bytes.Buffer{}
+	require.Nil(t, pm.Write(buff))
+	lines := strings.Split(buff.String(), "\n")
+
+	expectedLines := []string{
+		"# HELP covalent_stage_duration_seconds Duration of each block processing stage.",
+		"# TYPE covalent_stage_duration_seconds histogram",
+		`covalent_stage_duration_seconds_bucket{stage="block",le="0.01"} 0`,
+		`covalent_stage_duration_seconds_bucket{stage="block",le="0.025"} 1`,
+		`covalent_stage_duration_seconds_bucket{stage="block",le="10"} 1`,
+		`covalent_stage_duration_seconds_bucket{stage="block",le="+Inf"} 2`,
+		`covalent_stage_duration_seconds_sum{stage="block"} 20.02`,
+		`covalent_stage_duration_seconds_count{stage="block"} 2`,
+		`covalent_stage_duration_seconds_bucket{stage="transactions",le="0.0025"} 0`,
+		`covalent_stage_duration_seconds_bucket{stage="transactions",le="0.005"} 1`,
+		`covalent_stage_duration_seconds_count{stage="transactions"} 1`,
+		"# TYPE covalent_payload_size_bytes histogram",
+		`covalent_payload_size_bytes_bucket{le="1024"} 0`,
+		`covalent_payload_size_bytes_bucket{le="4096"} 1`,
+		"covalent_payload_size_bytes_sum 2000",
+		"covalent_payload_size_bytes_count 1",
+		"# TYPE covalent_payloads_sent_total counter",
+		"covalent_payloads_sent_total 2",
+		"covalent_payloads_acknowledged_total 1",
+		"covalent_send_retries_total 1",
+		"# TYPE covalent_websocket_reconnects_total counter",
+		`covalent_websocket_reconnects_total{socket="wss"} 0`,
+		`covalent_websocket_reconnects_total{socket="wsr"} 1`,
+		"# TYPE covalent_delivery_timeouts_total counter",
+		`covalent_delivery_timeouts_total{operation="write"} 0`,
+		`covalent_delivery_timeouts_total{operation="ack"} 1`,
+		`covalent_delivery_errors_total{operation="write"} 1`,
+		`covalent_delivery_errors_total{operation="ack"} 0`,
+		"# TYPE covalent_saved_nonce gauge",
+		"covalent_saved_nonce 12",
+		"covalent_acknowledged_nonce 9",
+		"covalent_nonce_lag 3",
+	}
+	for _, expectedLine := range expectedLines {
+		require.Contains(t, lines, expectedLine)
+	}
+
+	// stages are written in alphabetical order
+	require.Less(t, strings.Index(buff.String(), `stage="block"`), strings.Index(buff.String(), `stage="transactions"`))
+}
+
+func TestPipelineMetrics_Write_AcknowledgedNonceAhead_ExpectNoLag(t *testing.T) {
+	t.Parallel()
+
+	pm := metrics.NewPipelineMetrics()
+	pm.SetSavedNonce(5)
+	pm.SetAcknowledgedNonce(6)
+
+	buff := &bytes.Buffer{}
+	require.Nil(t, pm.Write(buff))
+	require.Contains(t, strings.Split(buff.String(), "\n"), "covalent_nonce_lag 0")
+}
+
+func TestPipelineMetrics_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	pm := metrics.NewPipelineMetrics()
+	pm.IncrementSent()
+
+	recorder := httptest.NewRecorder()
+	pm.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Equal(t, metrics.ContentType, recorder.Header().Get("Content-Type"))
+	require.Contains(t, recorder.Body.String(), "covalent_payloads_sent_total 1\n")
+}
@@ -0,0 +1,160 @@
+package ocf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"io"
+
+	"github.com/numbatx/gn-coval-index"
+)
+
+// maxBlockSize limits the size of a header metadata value or of a data block, so that a corrupted length
+// does not result in a huge allocation
+const maxBlockSize = 1 << 30
+
+type reader struct {
+	input      *bufio.Reader
+	schema     string
+	codec      Codec
+	syncMarker []byte
+}
+
+// NewReader creates a new avro object container file reader, after reading and checking the file header
+func NewReader(input io.Reader) (*reader, error) {
+	if input == nil {
+		return nil, covalent.ErrNilInput
+	}
+
+	r := &reader{
+		input: bufio.NewReader(input),
+		codec: CodecNull,
+	}
+
+	return r, r.readHeader()
+}
+
+func (r *reader) readHeader() error {
+	fileMagic := make([]byte, len(magic))
+	_, err := io.ReadFull(r.input, fileMagic)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(fileMagic, magic) {
+		return covalent.ErrInvalidObjectContainerFile
+	}
+
+	for {
+		count, errRead := binary.ReadVarint(r.input)
+		if errRead != nil {
+			return errRead
+		}
+		if count == 0 {
+			break
+		}
+		if count < 0 {
+			// a negative count is followed by the size in bytes of the map block, which is not needed
+			count = -count
+			_, errRead = binary.ReadVarint(r.input)
+			if errRead != nil {
+				return errRead
+			}
+		}
+
+		for i := int64(0); i < count; i++ {
+			key, errKey := r.readBytes()
+			if errKey != nil {
+				return errKey
+			}
+			value, errValue := r.readBytes()
+			if errValue != nil {
+				return errValue
+			}
+
+			switch string(key) {
+			case schemaMetadataKey:
+				r.schema = string(value)
+			case codecMetadataKey:
+				r.codec = Codec(value)
+			}
+		}
+	}
+
+	if !IsCodecSupported(r.codec) {
+		return covalent.ErrUnsupportedCodec
+	}
+
+	r.syncMarker = make([]byte, syncMarkerSize)
+	_, err = io.ReadFull(r.input, r.syncMarker)
+	return err
+}
+
+// Schema returns the schema embedded in the file header
+func (r *reader) Schema() string {
+	return r.schema
+}
+
+// Codec returns the codec used for the data blocks
+func (r *reader) Codec() Codec {
+	return r.codec
+}
+
+// NextBlock returns the number of datums from the next data block, together with the uncompressed data block.
+// io.EOF is returned once all data blocks were read
+func (r *reader) NextBlock() (int64, []byte, error) {
+	count, err := binary.ReadVarint(r.input)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	data, err := r.readBytes()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	syncMarker := make([]byte, syncMarkerSize)
+	_, err = io.ReadFull(r.input, syncMarker)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !bytes.Equal(syncMarker, r.syncMarker) {
+		return 0, nil, covalent.ErrInvalidObjectContainerFile
+	}
+
+	data, err = r.decompress(data)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return count, data, nil
+}
+
+func (r *reader) decompress(data []byte) ([]byte, error) {
+	switch r.codec {
+	case CodecDeflate:
+		return io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	case CodecSnappy:
+		return decodeSnappyBlock(data)
+	default:
+		return data, nil
+	}
+}
+
+func (r *reader) readBytes() ([]byte, error) {
+	size, err := binary.ReadVarint(r.input)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 || size > maxBlockSize {
+		return nil, covalent.ErrInvalidObjectContainerFile
+	}
+
+	data := make([]byte, size)
+	_, err = io.ReadFull(r.input, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
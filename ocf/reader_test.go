@@ -0,0 +1,67 @@
+package ocf_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/ocf"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewReader_InvalidHeader_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	reader, err := ocf.NewReader(nil)
+	require.Equal(t, covalent.ErrNilInput, err)
+	require.Nil(t, reader)
+
+	_, err = ocf.NewReader(bytes.NewReader([]byte("Obj\x02")))
+	require.Equal(t, covalent.ErrInvalidObjectContainerFile, err)
+}
+
+func TestReader_NextBlock_CorruptedData_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		codec       ocf.Codec
+		corrupt     func(data []byte)
+		expectedErr error
+	}{
+		{
+			codec: ocf.CodecNull,
+			corrupt: func(data []byte) {
+				// last byte of the sync marker which follows the data block
+				data[len(data)-1]++
+			},
+			expectedErr: covalent.ErrInvalidObjectContainerFile,
+		},
+		{
+			codec: ocf.CodecSnappy,
+			corrupt: func(data []byte) {
+				// last byte of the checksum which follows the compressed data
+				data[len(data)-17]++
+			},
+			expectedErr: covalent.ErrInvalidSnappyData,
+		},
+	}
+
+	for _, currTest := range tests {
+		buff := &bytes.Buffer{}
+		writer, _ := ocf.NewWriter(buff, schema.NewAccountSnapshot().Schema(), currTest.codec)
+		for _, account := range generateAccounts(3) {
+			writer.Append(encode(t, account))
+		}
+		require.Nil(t, writer.Flush())
+
+		data := buff.Bytes()
+		currTest.corrupt(data)
+
+		reader, err := ocf.NewReader(bytes.NewReader(data))
+		require.Nil(t, err)
+
+		_, _, err = reader.NextBlock()
+		require.Equal(t, currTest.expectedErr, err)
+	}
+}
@@ -0,0 +1,178 @@
+package ocf
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/numbatx/gn-coval-index"
+)
+
+const (
+	snappyTagLiteral = 0x00
+	snappyTagCopy1   = 0x01
+	snappyTagCopy2   = 0x02
+	snappyTagCopy4   = 0x03
+
+	snappyMinMatch   = 4
+	snappyMaxOffset  = 1<<16 - 1
+	snappyMaxCopyLen = 64
+	snappyHashBits   = 14
+)
+
+// encodeSnappyBlock compresses the input data using the snappy block format, followed by the 4 bytes, big endian,
+// CRC-32 checksum of the uncompressed data, as required by avro snappy codec
+func encodeSnappyBlock(src []byte) []byte {
+	dst := binary.AppendUvarint(make([]byte, 0, len(src)+len(src)/6+16), uint64(len(src)))
+
+	// table holds the position+1 of the last 4 bytes sequence having the same hash, 0 meaning no position
+	table := make([]int32, 1<<snappyHashBits)
+	literalStart := 0
+	for i := 0; i+snappyMinMatch <= len(src); {
+		current := binary.LittleEndian.Uint32(src[i:])
+		hash := (current * 0x1e35a7bd) >> (32 - snappyHashBits)
+		candidate := int(table[hash]) - 1
+		table[hash] = int32(i + 1)
+
+		if candidate < 0 || i-candidate > snappyMaxOffset || binary.LittleEndian.Uint32(src[candidate:]) != current {
+			i++
+			continue
+		}
+
+		matchLen := snappyMinMatch
+		for i+matchLen < len(src) && src[candidate+matchLen] == src[i+matchLen] {
+			matchLen++
+		}
+
+		dst = appendSnappyLiteral(dst, src[literalStart:i])
+		dst = appendSnappyCopies(dst, i-candidate, matchLen)
+		i += matchLen
+		literalStart = i
+	}
+	dst = appendSnappyLiteral(dst, src[literalStart:])
+
+	return binary.BigEndian.AppendUint32(dst, crc32.ChecksumIEEE(src))
+}
+
+func appendSnappyLiteral(dst []byte, literal []byte) []byte {
+	if len(literal) == 0 {
+		return dst
+	}
+
+	n := uint32(len(literal) - 1)
+	switch {
+	case n < 60:
+		dst = append(dst, byte(n)<<2|snappyTagLiteral)
+	case n < 1<<8:
+		dst = append(dst, 60<<2|snappyTagLiteral, byte(n))
+	case n < 1<<16:
+		dst = append(dst, 61<<2|snappyTagLiteral, byte(n), byte(n>>8))
+	case n < 1<<24:
+		dst = append(dst, 62<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16))
+	default:
+		dst = append(dst, 63<<2|snappyTagLiteral, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+
+	return append(dst, literal...)
+}
+
+// appendSnappyCopies splits a match in copies of at most 64 bytes, each of them using 2 bytes offsets
+func appendSnappyCopies(dst []byte, offset int, length int) []byte {
+	for length > 0 {
+		copyLen := length
+		if copyLen > snappyMaxCopyLen {
+			copyLen = snappyMaxCopyLen
+		}
+
+		dst = append(dst, byte(copyLen-1)<<2|snappyTagCopy2, byte(offset), byte(offset>>8))
+		length -= copyLen
+	}
+
+	return dst
+}
+
+// decodeSnappyBlock decompresses data written with the avro snappy codec and checks its CRC-32 checksum
+func decodeSnappyBlock(src []byte) ([]byte, error) {
+	if len(src) < 4 {
+		return nil, covalent.ErrInvalidSnappyData
+	}
+
+	checksum := binary.BigEndian.Uint32(src[len(src)-4:])
+	src = src[:len(src)-4]
+
+	decodedLen, n := binary.Uvarint(src)
+	if n <= 0 || decodedLen > uint64(len(src))*255 {
+		return nil, covalent.ErrInvalidSnappyData
+	}
+
+	dst := make([]byte, 0, decodedLen)
+	for i := n; i < len(src); {
+		tag := src[i]
+		length, offset := 0, 0
+
+		switch tag & 0x03 {
+		case snappyTagLiteral:
+			length = int(tag>>2) + 1
+			i++
+			if length > 60 {
+				extraBytes := length - 60
+				if i+extraBytes > len(src) {
+					return nil, covalent.ErrInvalidSnappyData
+				}
+
+				length = 0
+				for j := extraBytes - 1; j >= 0; j-- {
+					length = length<<8 | int(src[i+j])
+				}
+				length++
+				i += extraBytes
+			}
+			if length <= 0 || i+length > len(src) {
+				return nil, covalent.ErrInvalidSnappyData
+			}
+
+			dst = append(dst, src[i:i+length]...)
+			i += length
+			continue
+		case snappyTagCopy1:
+			if i+2 > len(src) {
+				return nil, covalent.ErrInvalidSnappyData
+			}
+			length = int(tag>>2&0x07) + 4
+			offset = int(tag&0xe0)<<3 | int(src[i+1])
+			i += 2
+		case snappyTagCopy2:
+			if i+3 > len(src) {
+				return nil, covalent.ErrInvalidSnappyData
+			}
+			length = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint16(src[i+1:]))
+			i += 3
+		case snappyTagCopy4:
+			if i+5 > len(src) {
+				return nil, covalent.ErrInvalidSnappyData
+			}
+			length = int(tag>>2) + 1
+			offset = int(binary.LittleEndian.Uint32(src[i+1:]))
+			i += 5
+		}
+
+		if offset <= 0 || offset > len(dst) {
+			return nil, covalent.ErrInvalidSnappyData
+		}
+
+		// copies may overlap the bytes they produce, so they are appended one by one
+		start := len(dst) - offset
+		for j := 0; j < length; j++ {
+			dst = append(dst, dst[start+j])
+		}
+	}
+
+	if uint64(len(dst)) != decodedLen {
+		return nil, covalent.ErrInvalidSnappyData
+	}
+	if crc32.ChecksumIEEE(dst) != checksum {
+		return nil, covalent.ErrInvalidSnappyData
+	}
+
+	return dst, nil
+}
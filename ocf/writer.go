@@ -0,0 +1,170 @@
+package ocf
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/process/utility"
+	"github.com/elodina/go-avro"
+)
+
+// Codec is the compression codec used for the data blocks of an object container file
+type Codec string
+
+const (
+	// CodecNull writes data blocks uncompressed
+	CodecNull Codec = "null"
+	// CodecDeflate compresses data blocks using raw deflate(RFC 1951)
+	CodecDeflate Codec = "deflate"
+	// CodecSnappy compresses data blocks using snappy, followed by the CRC-32 checksum of the uncompressed data
+	CodecSnappy Codec = "snappy"
+)
+
+const (
+	schemaMetadataKey = "avro.schema"
+	codecMetadataKey  = "avro.codec"
+	syncMarkerSize    = 16
+)
+
+var magic = []byte{'O', 'b', 'j', 1}
+
+type writer struct {
+	output      io.Writer
+	codec       Codec
+	syncMarker  []byte
+	block       *bytes.Buffer
+	blockCount  int64
+	writtenSize int64
+}
+
+// NewWriter creates a new avro object container file writer and writes the file header to the output. The embedded
+// schema is the parsing canonical form of the provided schema. An empty codec means CodecNull
+func NewWriter(output io.Writer, schema avro.Schema, codec Codec) (*writer, error) {
+	if output == nil {
+		return nil, covalent.ErrNilOutput
+	}
+	if schema == nil {
+		return nil, covalent.ErrNilSchema
+	}
+	if len(codec) == 0 {
+		codec = CodecNull
+	}
+	if !IsCodecSupported(codec) {
+		return nil, covalent.ErrUnsupportedCodec
+	}
+
+	syncMarker := make([]byte, syncMarkerSize)
+	_, err := rand.Read(syncMarker)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &writer{
+		output:     output,
+		codec:      codec,
+		syncMarker: syncMarker,
+		block:      &bytes.Buffer{},
+	}
+
+	return w, w.writeHeader(utility.ParsingCanonicalForm(schema))
+}
+
+// IsCodecSupported returns true if data blocks can be written and read using the provided codec
+func IsCodecSupported(codec Codec) bool {
+	return codec == CodecNull || codec == CodecDeflate || codec == CodecSnappy
+}
+
+func (w *writer) writeHeader(schema string) error {
+	header := append([]byte{}, magic...)
+	header = binary.AppendVarint(header, 2)
+	header = appendBytes(header, []byte(schemaMetadataKey))
+	header = appendBytes(header, []byte(schema))
+	header = appendBytes(header, []byte(codecMetadataKey))
+	header = appendBytes(header, []byte(w.codec))
+	header = binary.AppendVarint(header, 0)
+	header = append(header, w.syncMarker...)
+
+	return w.write(header)
+}
+
+// Append adds an avro binary encoded datum to the current data block, which is written only when flushed
+func (w *writer) Append(datum []byte) {
+	w.block.Write(datum)
+	w.blockCount++
+}
+
+// Flush compresses and writes the current data block, followed by the sync marker. Nothing is written if the
+// current data block is empty
+func (w *writer) Flush() error {
+	if w.blockCount == 0 {
+		return nil
+	}
+
+	data, err := w.compress(w.block.Bytes())
+	if err != nil {
+		return err
+	}
+
+	block := binary.AppendVarint(make([]byte, 0, len(data)+syncMarkerSize+20), w.blockCount)
+	block = appendBytes(block, data)
+	block = append(block, w.syncMarker...)
+
+	err = w.write(block)
+	if err != nil {
+		return err
+	}
+
+	w.block.Reset()
+	w.blockCount = 0
+
+	return nil
+}
+
+func (w *writer) compress(data []byte) ([]byte, error) {
+	switch w.codec {
+	case CodecDeflate:
+		buff := &bytes.Buffer{}
+		deflateWriter, err := flate.NewWriter(buff, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = deflateWriter.Write(data)
+		if err != nil {
+			return nil, err
+		}
+
+		err = deflateWriter.Close()
+		return buff.Bytes(), err
+	case CodecSnappy:
+		return encodeSnappyBlock(data), nil
+	default:
+		return data, nil
+	}
+}
+
+func (w *writer) write(data []byte) error {
+	n, err := w.output.Write(data)
+	w.writtenSize += int64(n)
+
+	return err
+}
+
+// WrittenSize returns the number of bytes written to the output, including the header
+func (w *writer) WrittenSize() int64 {
+	return w.writtenSize
+}
+
+// PendingSize returns the uncompressed size of the current data block
+func (w *writer) PendingSize() int {
+	return w.block.Len()
+}
+
+func appendBytes(dst []byte, data []byte) []byte {
+	dst = binary.AppendVarint(dst, int64(len(data)))
+	return append(dst, data...)
+}
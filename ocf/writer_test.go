@@ -0,0 +1,183 @@
+package ocf_test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/ocf"
+	"github.com/numbatx/gn-coval-index/process/utility"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-coval-index/testscommon"
+	"github.com/elodina/go-avro"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		output      io.Writer
+		schema      avro.Schema
+		codec       ocf.Codec
+		expectedErr error
+	}{
+		{
+			output:      nil,
+			schema:      schema.NewBlockResult().Schema(),
+			expectedErr: covalent.ErrNilOutput,
+		},
+		{
+			output:      &bytes.Buffer{},
+			schema:      nil,
+			expectedErr: covalent.ErrNilSchema,
+		},
+		{
+			output:      &bytes.Buffer{},
+			schema:      schema.NewBlockResult().Schema(),
+			codec:       "bzip2",
+			expectedErr: covalent.ErrUnsupportedCodec,
+		},
+		{
+			output:      &bytes.Buffer{},
+			schema:      schema.NewBlockResult().Schema(),
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := ocf.NewWriter(currTest.output, currTest.schema, currTest.codec)
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, instance == nil)
+	}
+}
+
+func TestWriter_NullCodec_ExpectFileReadableByAvroDataFileReader(t *testing.T) {
+	t.Parallel()
+
+	fileName := filepath.Join(t.TempDir(), "accounts.avro")
+	file, err := os.Create(fileName)
+	require.Nil(t, err)
+
+	accounts := generateAccounts(5)
+	writer, err := ocf.NewWriter(file, schema.NewAccountSnapshot().Schema(), ocf.CodecNull)
+	require.Nil(t, err)
+	for idx, account := range accounts {
+		writer.Append(encode(t, account))
+		if idx%2 == 1 {
+			require.Nil(t, writer.Flush())
+		}
+	}
+	require.Nil(t, writer.Flush())
+	require.Nil(t, file.Close())
+
+	fileInfo, err := os.Stat(fileName)
+	require.Nil(t, err)
+	require.Equal(t, fileInfo.Size(), writer.WrittenSize())
+	require.Equal(t, 0, writer.PendingSize())
+
+	datumReader := avro.NewSpecificDatumReader()
+	datumReader.SetSchema(schema.NewAccountSnapshot().Schema())
+	dataFileReader, err := avro.NewDataFileReader(fileName, datumReader)
+	require.Nil(t, err)
+
+	readAccounts := make([]*schema.AccountSnapshot, 0)
+	for {
+		account := schema.NewAccountSnapshot()
+		ok, errNext := dataFileReader.Next(account)
+		if !ok {
+			require.Nil(t, errNext)
+			break
+		}
+		readAccounts = append(readAccounts, account)
+	}
+	require.Equal(t, accounts, readAccounts)
+}
+
+func TestWriter_Codecs_ExpectSameRecordsRead(t *testing.T) {
+	t.Parallel()
+
+	writtenSizes := make(map[ocf.Codec]int64)
+	for _, codec := range []ocf.Codec{ocf.CodecNull, ocf.CodecDeflate, ocf.CodecSnappy} {
+		accounts := generateAccounts(10)
+		// repeated balances make data blocks compressible
+		for _, account := range accounts {
+			account.Balance = bytes.Repeat([]byte{0xab, 0xcd}, 100)
+		}
+
+		buff := &bytes.Buffer{}
+		writer, err := ocf.NewWriter(buff, schema.NewAccountSnapshot().Schema(), codec)
+		require.Nil(t, err)
+		for _, account := range accounts[:4] {
+			writer.Append(encode(t, account))
+		}
+		require.Nil(t, writer.Flush())
+		for _, account := range accounts[4:] {
+			writer.Append(encode(t, account))
+		}
+		require.Nil(t, writer.Flush())
+		writtenSizes[codec] = writer.WrittenSize()
+
+		reader, err := ocf.NewReader(buff)
+		require.Nil(t, err)
+		require.Equal(t, codec, reader.Codec())
+		require.Equal(t, utility.ParsingCanonicalForm(schema.NewAccountSnapshot().Schema()), reader.Schema())
+
+		readAccounts := readAllAccounts(t, reader)
+		require.Equal(t, accounts, readAccounts)
+	}
+
+	require.Less(t, writtenSizes[ocf.CodecDeflate], writtenSizes[ocf.CodecNull])
+	require.Less(t, writtenSizes[ocf.CodecSnappy], writtenSizes[ocf.CodecNull])
+}
+
+func generateAccounts(n int) []*schema.AccountSnapshot {
+	accounts := make([]*schema.AccountSnapshot, n)
+	for i := 0; i < n; i++ {
+		accounts[i] = &schema.AccountSnapshot{
+			Address:         testscommon.GenerateRandomFixedBytes(62),
+			Balance:         testscommon.GenerateRandomBytes(),
+			Nonce:           int64(i),
+			UserName:        []byte("user"),
+			CodeHash:        testscommon.GenerateRandomFixedBytes(32),
+			RootHash:        testscommon.GenerateRandomFixedBytes(32),
+			Owner:           testscommon.GenerateRandomFixedBytes(62),
+			DeveloperReward: testscommon.GenerateRandomBytes(),
+		}
+	}
+
+	return accounts
+}
+
+func encode(t *testing.T, record avro.AvroRecord) []byte {
+	datum, err := utility.Encode(record)
+	require.Nil(t, err)
+
+	return datum
+}
+
+func readAllAccounts(t *testing.T, reader interface {
+	NextBlock() (int64, []byte, error)
+}) []*schema.AccountSnapshot {
+	datumReader := avro.NewSpecificDatumReader()
+	datumReader.SetSchema(schema.NewAccountSnapshot().Schema())
+
+	accounts := make([]*schema.AccountSnapshot, 0)
+	for {
+		count, data, err := reader.NextBlock()
+		if err == io.EOF {
+			return accounts
+		}
+		require.Nil(t, err)
+
+		decoder := avro.NewBinaryDecoder(data)
+		for i := int64(0); i < count; i++ {
+			account := schema.NewAccountSnapshot()
+			require.Nil(t, datumReader.Read(account, decoder))
+			accounts = append(accounts, account)
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package outbox
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/segment"
+	logger "github.com/numbatx/gn-logger"
+)
+
+var log = logger.GetOrCreate("covalent/outbox")
+
+const (
+	cursorFileName = "cursor"
+
+	// DefaultMaxSegmentSize is the segment size used if none is provided
+	DefaultMaxSegmentSize = 64 * 1024 * 1024
+)
+
+// ArgsDiskOutbox holds all input dependencies required by disk outbox in order to create a new instance
+type ArgsDiskOutbox struct {
+	Directory      string
+	MaxSegmentSize int64
+}
+
+type entryPosition struct {
+	id      uint64
+	segment *segment.Segment
+	offset  int64
+}
+
+type diskOutbox struct {
+	mut            sync.RWMutex
+	directory      string
+	maxSegmentSize int64
+	segments       []*segment.Segment
+	positions      []*entryPosition
+	nextID         uint64
+	closed         bool
+}
+
+// NewDiskOutbox creates a new instance of an append only, segment based outbox stored in the provided directory.
+// Entries which were not removed before a restart are loaded back, in the same order
+func NewDiskOutbox(args *ArgsDiskOutbox) (*diskOutbox, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if len(args.Directory) == 0 {
+		return nil, covalent.ErrEmptyOutboxDirectory
+	}
+	if args.MaxSegmentSize < 0 {
+		return nil, covalent.ErrInvalidOutboxSegmentSize
+	}
+
+	maxSegmentSize := args.MaxSegmentSize
+	if maxSegmentSize == 0 {
+		maxSegmentSize = DefaultMaxSegmentSize
+	}
+
+	err := os.MkdirAll(args.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	do := &diskOutbox{
+		directory:      args.Directory,
+		maxSegmentSize: maxSegmentSize,
+	}
+
+	err = do.load()
+	if err != nil {
+		do.closeSegments()
+		return nil, err
+	}
+
+	log.Debug("outbox loaded", "directory", do.directory, "pending entries", len(do.positions), "next id", do.nextID)
+	return do, nil
+}
+
+func (do *diskOutbox) load() error {
+	firstIDs, err := segment.List(do.directory, segmentFormat)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := do.readCursor()
+	if err != nil {
+		return err
+	}
+	do.nextID = cursor
+
+	for _, firstID := range firstIDs {
+		seg, errOpen := segment.Open(do.directory, segmentFormat, firstID)
+		if errOpen != nil {
+			return errOpen
+		}
+
+		do.segments = append(do.segments, seg)
+		if firstID > do.nextID {
+			do.nextID = firstID
+		}
+
+		errScan := do.scanSegment(seg, cursor)
+		if errScan != nil {
+			return errScan
+		}
+	}
+
+	if len(do.segments) == 0 {
+		seg, errCreate := segment.Create(do.directory, segmentFormat, do.nextID)
+		if errCreate != nil {
+			return errCreate
+		}
+		do.segments = append(do.segments, seg)
+	}
+
+	return do.removeAckedSegments(cursor)
+}
+
+// scanSegment indexes all entries from the segment which are not yet acknowledged. A partially written
+// or corrupted record (e.g. the node stopped while appending) ends the segment and is discarded
+func (do *diskOutbox) scanSegment(seg *segment.Segment, cursor uint64) error {
+	offset := int64(0)
+	for offset < seg.Size() {
+		entry, recordSize, err := readEntry(seg, offset)
+		if err != nil {
+			log.Warn("discarding corrupted outbox segment tail",
+				"segment", seg.Name(), "offset", offset, "error", err)
+			return seg.Truncate(offset)
+		}
+
+		if entry.ID >= cursor {
+			do.positions = append(do.positions, &entryPosition{
+				id:      entry.ID,
+				segment: seg,
+				offset:  offset,
+			})
+		}
+		if entry.ID >= do.nextID {
+			do.nextID = entry.ID + 1
+		}
+
+		offset += recordSize
+	}
+
+	return nil
+}
+
+// Append stores the entry on disk, after assigning it the next available id
+func (do *diskOutbox) Append(entry *covalent.OutboxEntry) error {
+	do.mut.Lock()
+	defer do.mut.Unlock()
+
+	if do.closed {
+		return covalent.ErrOutboxClosed
+	}
+
+	entry.ID = do.nextID
+	body := encodeEntry(entry)
+
+	active := do.segments[len(do.segments)-1]
+	if active.Size() > 0 && active.Size()+segment.RecordHeaderSize+int64(len(body)) > do.maxSegmentSize {
+		seg, err := segment.Create(do.directory, segmentFormat, entry.ID)
+		if err != nil {
+			return err
+		}
+
+		do.segments = append(do.segments, seg)
+		active = seg
+	}
+
+	offset, err := active.Append(body)
+	if err != nil {
+		return err
+	}
+
+	do.positions = append(do.positions, &entryPosition{
+		id:      entry.ID,
+		segment: active,
+		offset:  offset,
+	})
+	do.nextID++
+
+	return nil
+}
+
+// Get returns the pending entry found at the given index, the oldest one having index 0
+func (do *diskOutbox) Get(index int) (*covalent.OutboxEntry, error) {
+	do.mut.RLock()
+	defer do.mut.RUnlock()
+
+	if do.closed {
+		return nil, covalent.ErrOutboxClosed
+	}
+	if index < 0 || index >= len(do.positions) {
+		return nil, covalent.ErrOutboxIndexOutOfRange
+	}
+
+	position := do.positions[index]
+	entry, _, err := readEntry(position.segment, position.offset)
+	return entry, err
+}
+
+// RemoveHead removes the oldest pending entry. Segments are deleted from disk once all their entries are removed
+func (do *diskOutbox) RemoveHead() error {
+	do.mut.Lock()
+	defer do.mut.Unlock()
+
+	if do.closed {
+		return covalent.ErrOutboxClosed
+	}
+	if len(do.positions) == 0 {
+		return covalent.ErrOutboxIndexOutOfRange
+	}
+
+	cursor := do.positions[0].id + 1
+	err := do.writeCursor(cursor)
+	if err != nil {
+		return err
+	}
+
+	do.positions[0] = nil
+	do.positions = do.positions[1:]
+
+	return do.removeAckedSegments(cursor)
+}
+
+// removeAckedSegments deletes all segments, except the active one, whose entries are all before cursor
+func (do *diskOutbox) removeAckedSegments(cursor uint64) error {
+	for len(do.segments) > 1 && do.segments[1].First() <= cursor {
+		err := do.segments[0].Remove()
+		if err != nil {
+			return err
+		}
+
+		do.segments[0] = nil
+		do.segments = do.segments[1:]
+	}
+
+	return nil
+}
+
+func (do *diskOutbox) readCursor() (uint64, error) {
+	buff, err := os.ReadFile(filepath.Join(do.directory, cursorFileName))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(buff) != 8 {
+		return 0, covalent.ErrCorruptedOutboxEntry
+	}
+
+	return binary.BigEndian.Uint64(buff), nil
+}
+
+// writeCursor atomically and durably persists the id of the oldest entry not yet acknowledged: the cursor is
+// written and synced to a temporary file, which is then renamed over the cursor file and the directory is synced,
+// so that a crash leaves either the previous or the new cursor
+func (do *diskOutbox) writeCursor(cursor uint64) error {
+	buff := make([]byte, 8)
+	binary.BigEndian.PutUint64(buff, cursor)
+
+	tmpFileName := filepath.Join(do.directory, cursorFileName+".tmp")
+	err := writeFileSync(tmpFileName, buff)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpFileName, filepath.Join(do.directory, cursorFileName))
+	if err != nil {
+		return err
+	}
+
+	return syncDirectory(do.directory)
+}
+
+func writeFileSync(fileName string, buff []byte) error {
+	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(buff)
+	if err == nil {
+		err = file.Sync()
+	}
+	errClose := file.Close()
+	if err != nil {
+		return err
+	}
+
+	return errClose
+}
+
+func syncDirectory(directory string) error {
+	dir, err := os.Open(directory)
+	if err != nil {
+		return err
+	}
+
+	err = dir.Sync()
+	errClose := dir.Close()
+	if err != nil {
+		return err
+	}
+
+	return errClose
+}
+
+// Len returns the number of pending entries
+func (do *diskOutbox) Len() int {
+	do.mut.RLock()
+	defer do.mut.RUnlock()
+
+	return len(do.positions)
+}
+
+// NextID returns the id which will be assigned to the next appended entry
+func (do *diskOutbox) NextID() uint64 {
+	do.mut.RLock()
+	defer do.mut.RUnlock()
+
+	return do.nextID
+}
+
+// Close closes all opened segment files. Pending entries are kept on disk
+func (do *diskOutbox) Close() error {
+	do.mut.Lock()
+	defer do.mut.Unlock()
+
+	if do.closed {
+		return nil
+	}
+	do.closed = true
+
+	return do.closeSegments()
+}
+
+func (do *diskOutbox) closeSegments() error {
+	var lastErr error
+	for _, seg := range do.segments {
+		err := seg.Close()
+		if err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (do *diskOutbox) IsInterfaceNil() bool {
+	return do == nil
+}
@@ -0,0 +1,247 @@
+package outbox_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/outbox"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiskOutbox(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *outbox.ArgsDiskOutbox
+		expectedErr error
+	}{
+		{
+			args: func() *outbox.ArgsDiskOutbox {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args: func() *outbox.ArgsDiskOutbox {
+				return &outbox.ArgsDiskOutbox{Directory: ""}
+			},
+			expectedErr: covalent.ErrEmptyOutboxDirectory,
+		},
+		{
+			args: func() *outbox.ArgsDiskOutbox {
+				return &outbox.ArgsDiskOutbox{Directory: t.TempDir(), MaxSegmentSize: -1}
+			},
+			expectedErr: covalent.ErrInvalidOutboxSegmentSize,
+		},
+		{
+			args: func() *outbox.ArgsDiskOutbox {
+				return &outbox.ArgsDiskOutbox{Directory: t.TempDir()}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := outbox.NewDiskOutbox(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(instance))
+		if err == nil {
+			require.Nil(t, instance.Close())
+		}
+	}
+}
+
+func TestDiskOutbox_AppendGetRemoveHead(t *testing.T) {
+	t.Parallel()
+
+	do, _ := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+	defer func() {
+		_ = do.Close()
+	}()
+
+	entries := generateEntries(3)
+	for _, entry := range entries {
+		require.Nil(t, do.Append(entry))
+	}
+	require.Equal(t, 3, do.Len())
+
+	for idx, entry := range entries {
+		require.Equal(t, uint64(idx), entry.ID)
+
+		storedEntry, err := do.Get(idx)
+		require.Nil(t, err)
+		require.Equal(t, entry, storedEntry)
+	}
+
+	_, err := do.Get(3)
+	require.Equal(t, covalent.ErrOutboxIndexOutOfRange, err)
+
+	require.Nil(t, do.RemoveHead())
+	require.Equal(t, 2, do.Len())
+
+	storedEntry, err := do.Get(0)
+	require.Nil(t, err)
+	require.Equal(t, entries[1], storedEntry)
+
+	require.Nil(t, do.RemoveHead())
+	require.Nil(t, do.RemoveHead())
+	require.Equal(t, 0, do.Len())
+	require.Equal(t, covalent.ErrOutboxIndexOutOfRange, do.RemoveHead())
+}
+
+func TestDiskOutbox_Restart_ExpectPendingEntriesResumed(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	do, _ := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: dir})
+
+	entries := generateEntries(4)
+	for _, entry := range entries {
+		require.Nil(t, do.Append(entry))
+	}
+	require.Nil(t, do.RemoveHead())
+	require.Nil(t, do.Close())
+
+	do, _ = outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: dir})
+	defer func() {
+		_ = do.Close()
+	}()
+
+	require.Equal(t, 3, do.Len())
+	for idx := 0; idx < 3; idx++ {
+		storedEntry, err := do.Get(idx)
+		require.Nil(t, err)
+		require.Equal(t, entries[idx+1], storedEntry)
+	}
+
+	require.Equal(t, uint64(4), do.NextID())
+	newEntry := generateEntries(1)[0]
+	require.Nil(t, do.Append(newEntry))
+	require.Equal(t, uint64(4), newEntry.ID)
+	require.Equal(t, uint64(5), do.NextID())
+}
+
+func TestDiskOutbox_TornCursorUpdate_ExpectPreviousCursorKept(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	do, _ := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: dir})
+
+	entries := generateEntries(3)
+	for _, entry := range entries {
+		require.Nil(t, do.Append(entry))
+	}
+	require.Nil(t, do.RemoveHead())
+	require.Nil(t, do.Close())
+
+	_, err := os.Stat(filepath.Join(dir, "cursor.tmp"))
+	require.True(t, os.IsNotExist(err))
+
+	// a crash while updating the cursor leaves a partially written temporary file, never a torn cursor
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "cursor.tmp"), []byte{0, 0, 0}, 0644))
+
+	do, err = outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: dir})
+	require.Nil(t, err)
+	defer func() {
+		_ = do.Close()
+	}()
+
+	require.Equal(t, 2, do.Len())
+	storedEntry, err := do.Get(0)
+	require.Nil(t, err)
+	require.Equal(t, entries[1], storedEntry)
+}
+
+func TestDiskOutbox_SegmentRotation_ExpectAcknowledgedSegmentsRemoved(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	do, _ := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: dir, MaxSegmentSize: 100})
+	defer func() {
+		_ = do.Close()
+	}()
+
+	// Each entry has more than 50 bytes, so every segment holds only one entry
+	entries := generateEntries(3)
+	for _, entry := range entries {
+		entry.Payload = make([]byte, 60)
+		require.Nil(t, do.Append(entry))
+	}
+	require.Equal(t, 3, countSegments(t, dir))
+
+	require.Nil(t, do.RemoveHead())
+	require.Equal(t, 2, countSegments(t, dir))
+
+	require.Nil(t, do.RemoveHead())
+	require.Nil(t, do.RemoveHead())
+	require.Equal(t, 1, countSegments(t, dir))
+}
+
+func TestDiskOutbox_PartiallyWrittenEntry_ExpectDiscardedAtRestart(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	do, _ := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: dir})
+
+	entries := generateEntries(2)
+	for _, entry := range entries {
+		require.Nil(t, do.Append(entry))
+	}
+	require.Nil(t, do.Close())
+
+	segmentPath := filepath.Join(dir, "segment-00000000000000000000.log")
+	info, err := os.Stat(segmentPath)
+	require.Nil(t, err)
+	require.Nil(t, os.Truncate(segmentPath, info.Size()-3))
+
+	do, _ = outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: dir})
+	defer func() {
+		_ = do.Close()
+	}()
+
+	require.Equal(t, 1, do.Len())
+	storedEntry, err := do.Get(0)
+	require.Nil(t, err)
+	require.Equal(t, entries[0], storedEntry)
+
+	newEntry := generateEntries(1)[0]
+	require.Nil(t, do.Append(newEntry))
+	require.Equal(t, uint64(1), newEntry.ID)
+}
+
+func TestDiskOutbox_Closed_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	do, _ := outbox.NewDiskOutbox(&outbox.ArgsDiskOutbox{Directory: t.TempDir()})
+	require.Nil(t, do.Close())
+
+	require.Equal(t, covalent.ErrOutboxClosed, do.Append(generateEntries(1)[0]))
+	_, err := do.Get(0)
+	require.Equal(t, covalent.ErrOutboxClosed, err)
+	require.Equal(t, covalent.ErrOutboxClosed, do.RemoveHead())
+}
+
+func generateEntries(n int) []*covalent.OutboxEntry {
+	entries := make([]*covalent.OutboxEntry, n)
+
+	for i := 0; i < n; i++ {
+		entries[i] = &covalent.OutboxEntry{
+			Nonce:   uint64(i + 100),
+			AckData: []byte("hash" + strconv.Itoa(i)),
+			Payload: []byte("payload" + strconv.Itoa(i)),
+		}
+	}
+
+	return entries
+}
+
+func countSegments(t *testing.T, dir string) int {
+	matches, err := filepath.Glob(filepath.Join(dir, "segment-*.log"))
+	require.Nil(t, err)
+
+	return len(matches)
+}
@@ -0,0 +1,61 @@
+package outbox
+
+import (
+	"encoding/binary"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/segment"
+)
+
+// entryHeaderSize = 8 bytes id + 8 bytes nonce + 4 bytes acknowledge data length
+const entryHeaderSize = 20
+
+// segmentFormat defines the segments holding consecutive outbox entries, named after the id of their first entry
+var segmentFormat = segment.Format{
+	FilePrefix:   "segment-",
+	ErrCorrupted: covalent.ErrCorruptedOutboxEntry,
+}
+
+// readEntry returns the entry found at the given offset, together with the size of the whole record
+func readEntry(seg *segment.Segment, offset int64) (*covalent.OutboxEntry, int64, error) {
+	body, recordSize, err := seg.Read(offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entry, err := decodeEntry(body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entry, recordSize, nil
+}
+
+func encodeEntry(entry *covalent.OutboxEntry) []byte {
+	body := make([]byte, entryHeaderSize+len(entry.AckData)+len(entry.Payload))
+	binary.BigEndian.PutUint64(body[0:8], entry.ID)
+	binary.BigEndian.PutUint64(body[8:16], entry.Nonce)
+	binary.BigEndian.PutUint32(body[16:20], uint32(len(entry.AckData)))
+	copy(body[entryHeaderSize:], entry.AckData)
+	copy(body[entryHeaderSize+len(entry.AckData):], entry.Payload)
+
+	return body
+}
+
+func decodeEntry(body []byte) (*covalent.OutboxEntry, error) {
+	if len(body) < entryHeaderSize {
+		return nil, covalent.ErrCorruptedOutboxEntry
+	}
+
+	ackLen := int(binary.BigEndian.Uint32(body[16:20]))
+	if len(body) < entryHeaderSize+ackLen {
+		return nil, covalent.ErrCorruptedOutboxEntry
+	}
+
+	return &covalent.OutboxEntry{
+		ID:      binary.BigEndian.Uint64(body[0:8]),
+		Nonce:   binary.BigEndian.Uint64(body[8:16]),
+		AckData: body[entryHeaderSize : entryHeaderSize+ackLen],
+		Payload: body[entryHeaderSize+ackLen:],
+	}, nil
+}
@@ -1,125 +1,91 @@
 package accounts
 
 import (
-	"bytes"
+	"math/big"
 
 	"github.com/numbatx/gn-coval-index"
-	"github.com/numbatx/gn-coval-index/process"
 	"github.com/numbatx/gn-coval-index/process/utility"
 	"github.com/numbatx/gn-coval-index/schema"
 	"github.com/numbatx/gn-core/core"
 	"github.com/numbatx/gn-core/core/check"
 	"github.com/numbatx/gn-core/data"
-	logger "github.com/numbatx/gn-logger"
 )
 
-var log = logger.GetOrCreate("covalent/process/accounts")
+// userAccountStateHandler defines the account state, besides balance and nonce, exposed by the user accounts which
+// the node passes when saving accounts
+type userAccountStateHandler interface {
+	GetUserName() []byte
+	GetCodeHash() []byte
+	GetRootHash() []byte
+	GetOwnerAddress() []byte
+	GetDeveloperReward() *big.Int
+}
 
 type accountsProcessor struct {
-	shardCoordinator process.ShardCoordinator
-	pubKeyConverter  core.PubkeyConverter
-	accounts         covalent.AccountsAdapter
+	pubKeyConverter core.PubkeyConverter
 }
 
 // NewAccountsProcessor creates a new instance of accounts processor
-func NewAccountsProcessor(
-	shardCoordinator process.ShardCoordinator,
-	accounts covalent.AccountsAdapter,
-	pubKeyConverter core.PubkeyConverter,
-) (*accountsProcessor, error) {
-
-	if check.IfNil(shardCoordinator) {
-		return nil, covalent.ErrNilShardCoordinator
-	}
-	if check.IfNil(accounts) {
-		return nil, covalent.ErrNilAccountsAdapter
-	}
+func NewAccountsProcessor(pubKeyConverter core.PubkeyConverter) (*accountsProcessor, error) {
 	if check.IfNil(pubKeyConverter) {
 		return nil, covalent.ErrNilPubKeyConverter
 	}
 
 	return &accountsProcessor{
-		accounts:         accounts,
-		pubKeyConverter:  pubKeyConverter,
-		shardCoordinator: shardCoordinator,
+		pubKeyConverter: pubKeyConverter,
 	}, nil
 }
 
-// ProcessAccounts converts accounts data to a specific structure defined by avro schema
-func (ap *accountsProcessor) ProcessAccounts(
-	processedTxs []*schema.Transaction,
-	processedSCRs []*schema.SCResult,
-	processedReceipts []*schema.Receipt,
-) []*schema.AccountBalanceUpdate {
-	addresses := ap.getAllAddresses(processedTxs, processedSCRs, processedReceipts)
-	accounts := make([]*schema.AccountBalanceUpdate, 0, len(addresses))
-
-	for address := range addresses {
-		account, err := ap.processAccount(address)
-		if err != nil || account == nil {
-			log.Warn("cannot get account address", "address", address, "error", err)
+// ProcessAccounts converts the accounts saved at the given block timestamp to a specific structure defined by avro
+// schema. Account state which is not exposed by a user account is left empty
+func (ap *accountsProcessor) ProcessAccounts(blockTimestamp uint64, accounts []data.UserAccountHandler) *schema.AccountsSnapshot {
+	snapshots := make([]*schema.AccountSnapshot, 0, len(accounts))
+	for _, account := range accounts {
+		if check.IfNil(account) {
 			continue
 		}
 
-		accounts = append(accounts, account)
+		snapshots = append(snapshots, ap.processAccount(account))
 	}
 
-	return accounts
+	return &schema.AccountsSnapshot{
+		Timestamp: int64(blockTimestamp),
+		Accounts:  snapshots,
+	}
 }
 
-func (ap *accountsProcessor) getAllAddresses(
-	processedTxs []*schema.Transaction,
-	processedSCRs []*schema.SCResult,
-	processedReceipts []*schema.Receipt,
-) map[string]struct{} {
-	addresses := make(map[string]struct{})
-
-	for _, tx := range processedTxs {
-		ap.addAddressIfInSelfShard(addresses, tx.Sender)
-		ap.addAddressIfInSelfShard(addresses, tx.Receiver)
+func (ap *accountsProcessor) processAccount(account data.UserAccountHandler) *schema.AccountSnapshot {
+	snapshot := &schema.AccountSnapshot{
+		Address:         utility.EncodePubKey(ap.pubKeyConverter, account.AddressBytes()),
+		Balance:         utility.GetBytes(account.GetBalance()),
+		Nonce:           int64(account.GetNonce()),
+		UserName:        make([]byte, 0),
+		CodeHash:        make([]byte, 0),
+		RootHash:        make([]byte, 0),
+		Owner:           make([]byte, 0),
+		DeveloperReward: utility.GetBytes(nil),
 	}
 
-	for _, scr := range processedSCRs {
-		ap.addAddressIfInSelfShard(addresses, scr.Sender)
-		ap.addAddressIfInSelfShard(addresses, scr.Receiver)
+	accountState, ok := account.(userAccountStateHandler)
+	if !ok {
+		return snapshot
 	}
 
-	for _, receipt := range processedReceipts {
-		ap.addAddressIfInSelfShard(addresses, receipt.Sender)
+	snapshot.UserName = getBytesOrEmpty(accountState.GetUserName())
+	snapshot.CodeHash = getBytesOrEmpty(accountState.GetCodeHash())
+	snapshot.RootHash = getBytesOrEmpty(accountState.GetRootHash())
+	if owner := accountState.GetOwnerAddress(); len(owner) != 0 {
+		snapshot.Owner = utility.EncodePubKey(ap.pubKeyConverter, owner)
 	}
+	snapshot.DeveloperReward = utility.GetBytes(accountState.GetDeveloperReward())
 
-	return addresses
+	return snapshot
 }
 
-func (ap *accountsProcessor) addAddressIfInSelfShard(addresses map[string]struct{}, address []byte) {
-	if bytes.Equal(address, utility.MetaChainShardAddress()) {
-		return
-	}
-	if ap.shardCoordinator.SelfId() == ap.shardCoordinator.ComputeId(address) {
-		addresses[string(address)] = struct{}{}
-	}
-}
-
-func (ap *accountsProcessor) processAccount(address string) (*schema.AccountBalanceUpdate, error) {
-	//TODO: This only works as long as covalent indexer is part of numbat node binary.
-	// This needs to be changed, so that account content is given as an input parameter, not loaded.
-	pubKey, err := ap.pubKeyConverter.Decode(address)
-	if err != nil {
-		return nil, err
+func getBytesOrEmpty(buff []byte) []byte {
+	if buff == nil {
+		return make([]byte, 0)
 	}
 
-	acc, err := ap.accounts.LoadAccount(pubKey)
-	if err != nil {
-		return nil, err
-	}
-
-	account, castOk := acc.(data.UserAccountHandler)
-	if !castOk {
-		return nil, covalent.ErrCannotCastAccountHandlerToUserAccount
-	}
-	return &schema.AccountBalanceUpdate{
-		Address: []byte(address),
-		Balance: utility.GetBytes(account.GetBalance()),
-		Nonce:   int64(account.GetNonce()),
-	}, nil
+	return buff
 }
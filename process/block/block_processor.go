@@ -16,22 +16,32 @@ import (
 const ProposerIndex = int64(0)
 
 type blockProcessor struct {
-	marshaller        marshal.Marshalizer
-	miniBlocksHandler process.MiniBlockHandler
+	marshaller               marshal.Marshalizer
+	miniBlocksHandler        process.MiniBlockHandler
+	validatorsPubKeysHandler process.ValidatorsPubKeysHandler
 }
 
-// NewBlockProcessor creates a new instance of block processor
-func NewBlockProcessor(marshaller marshal.Marshalizer, mbHandler process.MiniBlockHandler) (*blockProcessor, error) {
+// NewBlockProcessor creates a new instance of block processor, which resolves the public keys of the block
+// validators from the latest reported ones
+func NewBlockProcessor(
+	marshaller marshal.Marshalizer,
+	mbHandler process.MiniBlockHandler,
+	validatorsPubKeysHandler process.ValidatorsPubKeysHandler,
+) (*blockProcessor, error) {
 	if check.IfNil(marshaller) {
 		return nil, covalent.ErrNilMarshaller
 	}
 	if mbHandler == nil {
 		return nil, covalent.ErrNilMiniBlockHandler
 	}
+	if validatorsPubKeysHandler == nil {
+		return nil, covalent.ErrNilValidatorsPubKeysHandler
+	}
 
 	return &blockProcessor{
-		marshaller:        marshaller,
-		miniBlocksHandler: mbHandler,
+		marshaller:               marshaller,
+		miniBlocksHandler:        mbHandler,
+		validatorsPubKeysHandler: validatorsPubKeysHandler,
 	}, nil
 }
 
@@ -73,6 +83,7 @@ func (bp *blockProcessor) ProcessBlock(args *indexer.ArgsSaveBlockData) (*schema
 		DeveloperFees:         utility.GetBytes(header.GetDeveloperFees()),
 		EpochStartBlock:       header.IsStartOfEpochBlock(),
 		EpochStartInfo:        getEpochStartInfo(header),
+		ValidatorsPubKeys:     bp.getValidatorsPubKeys(header, args.SignersIndexes),
 	}, nil
 }
 
@@ -99,6 +110,25 @@ func getProposerIndex(signersIndexes []uint64) int64 {
 	return ProposerIndex
 }
 
+// getValidatorsPubKeys returns the public keys of the signers, in the same order as their indexes. It returns no
+// public key if the validators of the block shard were not reported for its epoch or any index can not be resolved
+func (bp *blockProcessor) getValidatorsPubKeys(header data.HeaderHandler, signersIndexes []uint64) [][]byte {
+	validatorsPubKeys := make([][]byte, 0, len(signersIndexes))
+	shardPubKeys, found := bp.validatorsPubKeysHandler.GetValidatorsPubKeys(header.GetShardID(), header.GetEpoch())
+	if !found {
+		return validatorsPubKeys
+	}
+
+	for _, signerIndex := range signersIndexes {
+		if signerIndex >= uint64(len(shardPubKeys)) {
+			return make([][]byte, 0)
+		}
+		validatorsPubKeys = append(validatorsPubKeys, shardPubKeys[signerIndex])
+	}
+
+	return validatorsPubKeys
+}
+
 func getEpochStartInfo(header data.HeaderHandler) *schema.EpochStartInfo {
 	if header.GetShardID() != core.MetachainShardId {
 		return nil
@@ -24,24 +24,30 @@ func TestBlockProcessor_NewBlockProcessor(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		args        func() (marshal.Marshalizer, process.MiniBlockHandler)
+		args        func() (marshal.Marshalizer, process.MiniBlockHandler, process.ValidatorsPubKeysHandler)
 		expectedErr error
 	}{
 		{
-			args: func() (marshal.Marshalizer, process.MiniBlockHandler) {
-				return nil, &mock.MiniBlockHandlerStub{}
+			args: func() (marshal.Marshalizer, process.MiniBlockHandler, process.ValidatorsPubKeysHandler) {
+				return nil, &mock.MiniBlockHandlerStub{}, &mock.ValidatorsPubKeysHandlerStub{}
 			},
 			expectedErr: covalent.ErrNilMarshaller,
 		},
 		{
-			args: func() (marshal.Marshalizer, process.MiniBlockHandler) {
-				return &mock.MarshallerStub{}, nil
+			args: func() (marshal.Marshalizer, process.MiniBlockHandler, process.ValidatorsPubKeysHandler) {
+				return &mock.MarshallerStub{}, nil, &mock.ValidatorsPubKeysHandlerStub{}
 			},
 			expectedErr: covalent.ErrNilMiniBlockHandler,
 		},
 		{
-			args: func() (marshal.Marshalizer, process.MiniBlockHandler) {
-				return &mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{}
+			args: func() (marshal.Marshalizer, process.MiniBlockHandler, process.ValidatorsPubKeysHandler) {
+				return &mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{}, nil
+			},
+			expectedErr: covalent.ErrNilValidatorsPubKeysHandler,
+		},
+		{
+			args: func() (marshal.Marshalizer, process.MiniBlockHandler, process.ValidatorsPubKeysHandler) {
+				return &mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{}, &mock.ValidatorsPubKeysHandlerStub{}
 			},
 			expectedErr: nil,
 		},
@@ -86,7 +92,8 @@ func TestBlockProcessor_ProcessBlock_InvalidBodyAndHeaderMarshaller_ExpectProces
 	for _, currTest := range tests {
 		bp, _ := block.NewBlockProcessor(
 			&mock.MarshallerStub{MarshalCalled: currTest.Marshaller},
-			&mock.MiniBlockHandlerStub{})
+			&mock.MiniBlockHandlerStub{},
+			&mock.ValidatorsPubKeysHandlerStub{})
 
 		args := getInitializedArgs(false)
 		_, err := bp.ProcessBlock(args)
@@ -97,7 +104,7 @@ func TestBlockProcessor_ProcessBlock_InvalidBodyAndHeaderMarshaller_ExpectProces
 
 func TestBlockProcessor_ProcessBlock_InvalidBody_ExpectErrBlockBodyAssertion(t *testing.T) {
 	mbp, _ := miniblocks.NewMiniBlocksProcessor(&mock.HasherMock{}, &mock.MarshallerStub{})
-	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, mbp)
+	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, mbp, &mock.ValidatorsPubKeysHandlerStub{})
 
 	args := getInitializedArgs(false)
 	args.Body = nil
@@ -114,7 +121,8 @@ func TestNewBlockProcessor_ProcessBlock_InvalidMBHandler_ExpectErr(t *testing.T)
 		&mock.MiniBlockHandlerStub{
 			ProcessMiniBlockCalled: func(header data.HeaderHandler, body data.BodyHandler) ([]*schema.MiniBlock, error) {
 				return nil, errMBHandler
-			}})
+			}},
+		&mock.ValidatorsPubKeysHandlerStub{})
 
 	args := getInitializedArgs(false)
 	_, err := bp.ProcessBlock(args)
@@ -123,7 +131,7 @@ func TestNewBlockProcessor_ProcessBlock_InvalidMBHandler_ExpectErr(t *testing.T)
 }
 
 func TestNewBlockProcessor_ProcessBlock_NoSigners_ExpectDefaultProposerIndex(t *testing.T) {
-	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{})
+	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{}, &mock.ValidatorsPubKeysHandlerStub{})
 
 	args := getInitializedArgs(false)
 	args.SignersIndexes = nil
@@ -135,7 +143,7 @@ func TestNewBlockProcessor_ProcessBlock_NoSigners_ExpectDefaultProposerIndex(t *
 func TestBlockProcessor_ProcessBlock(t *testing.T) {
 	t.Parallel()
 
-	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{})
+	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{}, &mock.ValidatorsPubKeysHandlerStub{})
 	args := getInitializedArgs(false)
 	ret, _ := bp.ProcessBlock(args)
 	expectedNotarizedHeaderHashes, _ := utility.HexSliceToByteSlice(args.NotarizedHeadersHashes)
@@ -158,12 +166,66 @@ func TestBlockProcessor_ProcessBlock(t *testing.T) {
 	require.Equal(t, args.Header.GetDeveloperFees().Bytes(), ret.DeveloperFees)
 
 	require.Equal(t, ret.EpochStartInfo, (*schema.EpochStartInfo)(nil))
+	require.Empty(t, ret.ValidatorsPubKeys)
+}
+
+func TestBlockProcessor_ProcessBlock_ValidatorsPubKeys(t *testing.T) {
+	t.Parallel()
+
+	shardPubKeys := [][]byte{[]byte("key0"), []byte("key1"), []byte("key2"), []byte("key3")}
+	tests := []struct {
+		name           string
+		signersIndexes []uint64
+		found          bool
+		expected       [][]byte
+	}{
+		{
+			name:           "validators reported, expect public keys in signers order",
+			signersIndexes: []uint64{3, 1, 2},
+			found:          true,
+			expected:       [][]byte{[]byte("key3"), []byte("key1"), []byte("key2")},
+		},
+		{
+			name:           "validators not reported, expect no public key",
+			signersIndexes: []uint64{3, 1, 2},
+			found:          false,
+			expected:       [][]byte{},
+		},
+		{
+			name:           "signer index out of range, expect no public key",
+			signersIndexes: []uint64{1, 4},
+			found:          true,
+			expected:       [][]byte{},
+		},
+	}
+
+	for _, currTest := range tests {
+		args := getInitializedArgs(false)
+		args.SignersIndexes = currTest.signersIndexes
+		bp, _ := block.NewBlockProcessor(
+			&mock.MarshallerStub{},
+			&mock.MiniBlockHandlerStub{},
+			&mock.ValidatorsPubKeysHandlerStub{
+				GetValidatorsPubKeysCalled: func(shardID uint32, epoch uint32) ([][]byte, bool) {
+					require.Equal(t, args.Header.GetShardID(), shardID)
+					require.Equal(t, args.Header.GetEpoch(), epoch)
+					if !currTest.found {
+						return nil, false
+					}
+					return shardPubKeys, true
+				},
+			})
+
+		ret, err := bp.ProcessBlock(args)
+		require.Nil(t, err, currTest.name)
+		require.Equal(t, currTest.expected, ret.ValidatorsPubKeys, currTest.name)
+	}
 }
 
 func TestBlockProcessor_ProcessMetaBlock(t *testing.T) {
 	t.Parallel()
 
-	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{})
+	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{}, &mock.ValidatorsPubKeysHandlerStub{})
 	args := getInitializedArgs(true)
 	ret, _ := bp.ProcessBlock(args)
 	expectedNotarizedHeaderHashes, _ := utility.HexSliceToByteSlice(args.NotarizedHeadersHashes)
@@ -197,7 +259,7 @@ func TestBlockProcessor_ProcessMetaBlock(t *testing.T) {
 }
 
 func TestBlockProcessor_ProcessMetaBlock_NotStartOfEpochBlock_ExpectNilEpochStartInfo(t *testing.T) {
-	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{})
+	bp, _ := block.NewBlockProcessor(&mock.MarshallerStub{}, &mock.MiniBlockHandlerStub{}, &mock.ValidatorsPubKeysHandlerStub{})
 
 	metaBlockHeader := getInitializedMetaBlockHeader()
 	metaBlockHeader.EpochStart.LastFinalizedHeaders = nil
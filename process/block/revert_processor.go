@@ -0,0 +1,52 @@
+package block
+
+import (
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-core/core"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/numbatx/gn-core/data"
+	"github.com/numbatx/gn-core/hashing"
+	"github.com/numbatx/gn-core/marshal"
+)
+
+type revertProcessor struct {
+	hasher     hashing.Hasher
+	marshaller marshal.Marshalizer
+}
+
+// NewRevertProcessor creates a new instance of revert processor
+func NewRevertProcessor(hasher hashing.Hasher, marshaller marshal.Marshalizer) (*revertProcessor, error) {
+	if check.IfNil(hasher) {
+		return nil, covalent.ErrNilHasher
+	}
+	if check.IfNil(marshaller) {
+		return nil, covalent.ErrNilMarshaller
+	}
+
+	return &revertProcessor{
+		hasher:     hasher,
+		marshaller: marshaller,
+	}, nil
+}
+
+// ProcessRevert converts the header of a reverted block to a specific structure defined by avro schema. The header
+// hash is computed the same way the node does, so that it matches the hash of the previously indexed block
+func (rp *revertProcessor) ProcessRevert(header data.HeaderHandler) (*schema.BlockRevert, error) {
+	if check.IfNil(header) {
+		return nil, covalent.ErrNilHeaderHandler
+	}
+
+	headerHash, err := core.CalculateHash(rp.marshaller, rp.hasher, header)
+	if err != nil {
+		return nil, err
+	}
+
+	return &schema.BlockRevert{
+		Hash:    headerHash,
+		Nonce:   int64(header.GetNonce()),
+		Round:   int64(header.GetRound()),
+		ShardID: int32(header.GetShardID()),
+		Epoch:   int32(header.GetEpoch()),
+	}, nil
+}
@@ -0,0 +1,97 @@
+package block_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/process/block"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-coval-index/testscommon/mock"
+	moaBlock "github.com/numbatx/gn-core/data/block"
+	"github.com/numbatx/gn-core/hashing"
+	"github.com/numbatx/gn-core/marshal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRevertProcessor(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() (hashing.Hasher, marshal.Marshalizer)
+		expectedErr error
+	}{
+		{
+			args: func() (hashing.Hasher, marshal.Marshalizer) {
+				return nil, &mock.MarshallerStub{}
+			},
+			expectedErr: covalent.ErrNilHasher,
+		},
+		{
+			args: func() (hashing.Hasher, marshal.Marshalizer) {
+				return &mock.HasherMock{}, nil
+			},
+			expectedErr: covalent.ErrNilMarshaller,
+		},
+		{
+			args: func() (hashing.Hasher, marshal.Marshalizer) {
+				return &mock.HasherMock{}, &mock.MarshallerStub{}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		_, err := block.NewRevertProcessor(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+	}
+}
+
+func TestRevertProcessor_ProcessRevert_NilHeader_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	rp, _ := block.NewRevertProcessor(&mock.HasherMock{}, &mock.MarshallerStub{})
+
+	blockRevert, err := rp.ProcessRevert(nil)
+	require.Nil(t, blockRevert)
+	require.Equal(t, covalent.ErrNilHeaderHandler, err)
+}
+
+func TestRevertProcessor_ProcessRevert_InvalidMarshaller_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	errMarshall := errors.New("err header marshall")
+	rp, _ := block.NewRevertProcessor(
+		&mock.HasherMock{},
+		&mock.MarshallerStub{
+			MarshalCalled: func(obj interface{}) ([]byte, error) {
+				return nil, errMarshall
+			},
+		})
+
+	blockRevert, err := rp.ProcessRevert(&moaBlock.Header{})
+	require.Nil(t, blockRevert)
+	require.Equal(t, errMarshall, err)
+}
+
+func TestRevertProcessor_ProcessRevert(t *testing.T) {
+	t.Parallel()
+
+	rp, _ := block.NewRevertProcessor(&mock.HasherMock{}, &mock.MarshallerStub{})
+	header := &moaBlock.Header{
+		Nonce:   100,
+		Round:   102,
+		ShardID: 2,
+		Epoch:   4,
+	}
+
+	blockRevert, err := rp.ProcessRevert(header)
+	require.Nil(t, err)
+	require.Equal(t, &schema.BlockRevert{
+		Hash:    []byte("ok"),
+		Nonce:   100,
+		Round:   102,
+		ShardID: 2,
+		Epoch:   4,
+	}, blockRevert)
+}
@@ -1,7 +1,11 @@
 package process
 
 import (
+	"time"
+
+	"github.com/numbatx/gn-coval-index/metrics"
 	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-core/core/check"
 	"github.com/numbatx/gn-core/data"
 	"github.com/numbatx/gn-core/data/indexer"
 )
@@ -13,9 +17,15 @@ type dataProcessor struct {
 	scHandler          SCResultsHandler
 	logHandler         LogHandler
 	accountsHandler    AccountsHandler
+	revertHandler      RevertHandler
+	roundsHandler      RoundsHandler
+	validatorsHandler  ValidatorsHandler
+	ratingsHandler     RatingsHandler
+	metrics            MetricsHandler
 }
 
-// NewDataProcessor creates a new instance of data processor, which handles all sub-processes
+// NewDataProcessor creates a new instance of data processor, which handles all sub-processes and records how long
+// each of them took. Metrics handler is optional
 func NewDataProcessor(
 	blockHandler BlockHandler,
 	transactionHandler TransactionHandler,
@@ -23,7 +33,15 @@ func NewDataProcessor(
 	receiptHandler ReceiptHandler,
 	logHandler LogHandler,
 	accountsHandler AccountsHandler,
+	revertHandler RevertHandler,
+	roundsHandler RoundsHandler,
+	validatorsHandler ValidatorsHandler,
+	ratingsHandler RatingsHandler,
+	metricsHandler MetricsHandler,
 ) (*dataProcessor, error) {
+	if check.IfNil(metricsHandler) {
+		metricsHandler = metrics.NewDisabledMetrics()
+	}
 
 	return &dataProcessor{
 		blockHandler:       blockHandler,
@@ -32,6 +50,11 @@ func NewDataProcessor(
 		receiptHandler:     receiptHandler,
 		logHandler:         logHandler,
 		accountsHandler:    accountsHandler,
+		revertHandler:      revertHandler,
+		roundsHandler:      roundsHandler,
+		validatorsHandler:  validatorsHandler,
+		ratingsHandler:     ratingsHandler,
+		metrics:            metricsHandler,
 	}, nil
 }
 
@@ -39,20 +62,25 @@ func NewDataProcessor(
 func (dp *dataProcessor) ProcessData(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
 	pool := getPool(args)
 
+	start := time.Now()
 	block, err := dp.blockHandler.ProcessBlock(args)
 	if err != nil {
 		return nil, err
 	}
+	start = dp.observeStage(metrics.StageBlock, start)
 
 	transactions, err := dp.transactionHandler.ProcessTransactions(args.Header, args.HeaderHash, args.Body, pool)
 	if err != nil {
 		return nil, err
 	}
+	start = dp.observeStage(metrics.StageTransactions, start)
 
 	smartContractResults := dp.scHandler.ProcessSCRs(pool.Scrs, args.Header.GetTimeStamp())
+	start = dp.observeStage(metrics.StageSCRs, start)
 	receipts := dp.receiptHandler.ProcessReceipts(pool.Receipts, args.Header.GetTimeStamp())
+	start = dp.observeStage(metrics.StageReceipts, start)
 	logs := dp.logHandler.ProcessLogs(pool.Logs)
-	accountUpdates := dp.accountsHandler.ProcessAccounts(transactions, smartContractResults, receipts)
+	dp.observeStage(metrics.StageLogs, start)
 
 	return &schema.BlockResult{
 		Block:        block,
@@ -60,10 +88,50 @@ func (dp *dataProcessor) ProcessData(args *indexer.ArgsSaveBlockData) (*schema.B
 		Receipts:     receipts,
 		SCResults:    smartContractResults,
 		Logs:         logs,
-		StateChanges: accountUpdates,
+		// account state is sent in AccountsSnapshot records, the field is kept so that the block schema is unchanged
+		StateChanges: make([]*schema.AccountBalanceUpdate, 0),
 	}, nil
 }
 
+// ProcessAccounts converts the accounts saved at the given block timestamp to a specific structure defined by avro
+// schema
+func (dp *dataProcessor) ProcessAccounts(blockTimestamp uint64, accounts []data.UserAccountHandler) *schema.AccountsSnapshot {
+	start := time.Now()
+	accountsSnapshot := dp.accountsHandler.ProcessAccounts(blockTimestamp, accounts)
+	dp.observeStage(metrics.StageAccounts, start)
+
+	return accountsSnapshot
+}
+
+// ProcessRevert converts the header of a reverted block to a specific structure defined by avro schema
+func (dp *dataProcessor) ProcessRevert(header data.HeaderHandler) (*schema.BlockRevert, error) {
+	return dp.revertHandler.ProcessRevert(header)
+}
+
+// ProcessRoundsInfo converts rounds info data to a specific structure defined by avro schema
+func (dp *dataProcessor) ProcessRoundsInfo(roundsInfo []*indexer.RoundInfo) *schema.RoundsInfo {
+	return dp.roundsHandler.ProcessRoundsInfo(roundsInfo)
+}
+
+// ProcessValidatorsPubKeys converts the validators public keys of the epoch to specific structures defined by avro
+// schema, one for each shard
+func (dp *dataProcessor) ProcessValidatorsPubKeys(validatorsPubKeys map[uint32][][]byte, epoch uint32) []*schema.EpochValidators {
+	return dp.validatorsHandler.ProcessValidatorsPubKeys(validatorsPubKeys, epoch)
+}
+
+// ProcessValidatorsRating converts validators ratings data to a specific structure defined by avro schema
+func (dp *dataProcessor) ProcessValidatorsRating(indexID string, ratingsInfo []*indexer.ValidatorRatingInfo) (*schema.ValidatorsRating, error) {
+	return dp.ratingsHandler.ProcessValidatorsRating(indexID, ratingsInfo)
+}
+
+// observeStage records the duration of a stage which started at the given time and returns the time it ended
+func (dp *dataProcessor) observeStage(stage string, start time.Time) time.Time {
+	end := time.Now()
+	dp.metrics.ObserveStageDuration(stage, end.Sub(start))
+
+	return end
+}
+
 func getPool(args *indexer.ArgsSaveBlockData) *indexer.Pool {
 	pool := &indexer.Pool{
 		Txs:      make(map[string]data.TransactionHandler),
@@ -8,20 +8,23 @@ import (
 	"github.com/numbatx/gn-coval-index/process/block/miniblocks"
 	"github.com/numbatx/gn-coval-index/process/logs"
 	"github.com/numbatx/gn-coval-index/process/receipts"
+	"github.com/numbatx/gn-coval-index/process/rounds"
 	"github.com/numbatx/gn-coval-index/process/transactions"
+	"github.com/numbatx/gn-coval-index/process/validators"
 	"github.com/numbatx/gn-core/core"
 	"github.com/numbatx/gn-core/hashing"
 	"github.com/numbatx/gn-core/marshal"
 )
 
 // ArgsDataProcessor holds all input dependencies required by data processor factory
-// in order to create a new data handler instance of type data processor
+// in order to create a new data handler instance of type data processor. Metrics is optional
 type ArgsDataProcessor struct {
 	PubKeyConvertor  core.PubkeyConverter
-	Accounts         covalent.AccountsAdapter
+	Accounts         covalent.AccountsAdapter // Deprecated: Accounts is ignored, account state being built from the accounts passed to SaveAccounts
 	Hasher           hashing.Hasher
 	Marshaller       marshal.Marshalizer
-	ShardCoordinator process.ShardCoordinator
+	ShardCoordinator process.ShardCoordinator // Deprecated: ShardCoordinator is ignored, it was only used to load the accounts of the own shard
+	Metrics          process.MetricsHandler
 }
 
 // CreateDataProcessor creates a new data handler instance of type data processor
@@ -31,7 +34,8 @@ func CreateDataProcessor(args *ArgsDataProcessor) (covalent.DataHandler, error)
 		return nil, err
 	}
 
-	blockHandler, err := blockCovalent.NewBlockProcessor(args.Marshaller, miniBlocksHandler)
+	validatorsHandler := validators.NewValidatorsProcessor()
+	blockHandler, err := blockCovalent.NewBlockProcessor(args.Marshaller, miniBlocksHandler, validatorsHandler)
 	if err != nil {
 		return nil, err
 	}
@@ -56,7 +60,12 @@ func CreateDataProcessor(args *ArgsDataProcessor) (covalent.DataHandler, error)
 		return nil, err
 	}
 
-	accountsHandler, err := accounts.NewAccountsProcessor(args.ShardCoordinator, args.Accounts, args.PubKeyConvertor)
+	accountsHandler, err := accounts.NewAccountsProcessor(args.PubKeyConvertor)
+	if err != nil {
+		return nil, err
+	}
+
+	revertHandler, err := blockCovalent.NewRevertProcessor(args.Hasher, args.Marshaller)
 	if err != nil {
 		return nil, err
 	}
@@ -67,5 +76,10 @@ func CreateDataProcessor(args *ArgsDataProcessor) (covalent.DataHandler, error)
 		scResultsHandler,
 		receiptsHandler,
 		logHandler,
-		accountsHandler)
+		accountsHandler,
+		revertHandler,
+		rounds.NewRoundsProcessor(),
+		validatorsHandler,
+		validators.NewRatingsProcessor(),
+		args.Metrics)
 }
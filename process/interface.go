@@ -2,6 +2,7 @@ package process
 
 import (
 	"io"
+	"time"
 
 	"github.com/numbatx/gn-coval-index/schema"
 	"github.com/numbatx/gn-core/data"
@@ -13,6 +14,33 @@ type BlockHandler interface {
 	ProcessBlock(args *indexer.ArgsSaveBlockData) (*schema.Block, error)
 }
 
+// RevertHandler defines what a reverted block processor shall do
+type RevertHandler interface {
+	ProcessRevert(header data.HeaderHandler) (*schema.BlockRevert, error)
+}
+
+// RoundsHandler defines what a rounds info processor shall do
+type RoundsHandler interface {
+	ProcessRoundsInfo(roundsInfo []*indexer.RoundInfo) *schema.RoundsInfo
+}
+
+// ValidatorsPubKeysHandler defines how the public keys of the latest reported validators are looked up
+type ValidatorsPubKeysHandler interface {
+	GetValidatorsPubKeys(shardID uint32, epoch uint32) ([][]byte, bool)
+}
+
+// ValidatorsHandler defines what a validators processor shall do. The public keys of the latest reported epoch are
+// kept, so that validators indexes can be resolved
+type ValidatorsHandler interface {
+	ProcessValidatorsPubKeys(validatorsPubKeys map[uint32][][]byte, epoch uint32) []*schema.EpochValidators
+	GetValidatorsPubKeys(shardID uint32, epoch uint32) ([][]byte, bool)
+}
+
+// RatingsHandler defines what a validators ratings processor shall do
+type RatingsHandler interface {
+	ProcessValidatorsRating(indexID string, ratingsInfo []*indexer.ValidatorRatingInfo) (*schema.ValidatorsRating, error)
+}
+
 // MiniBlockHandler defines what a mini blocks processor shall do
 type MiniBlockHandler interface {
 	ProcessMiniBlocks(header data.HeaderHandler, body data.BodyHandler) ([]*schema.MiniBlock, error)
@@ -44,10 +72,7 @@ type LogHandler interface {
 
 // AccountsHandler defines what an account processor shall do
 type AccountsHandler interface {
-	ProcessAccounts(
-		processedTxs []*schema.Transaction,
-		processedSCRs []*schema.SCResult,
-		processedReceipts []*schema.Receipt) []*schema.AccountBalanceUpdate
+	ProcessAccounts(blockTimestamp uint64, accounts []data.UserAccountHandler) *schema.AccountsSnapshot
 }
 
 // ShardCoordinator defines what a shard coordinator shall do
@@ -62,4 +87,12 @@ type WSConn interface {
 	io.Closer
 	ReadMessage() (messageType int, p []byte, err error)
 	WriteMessage(messageType int, data []byte) error
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+// MetricsHandler defines what a collector of processing stage latencies shall do
+type MetricsHandler interface {
+	ObserveStageDuration(stage string, duration time.Duration)
+	IsInterfaceNil() bool
 }
@@ -0,0 +1,47 @@
+package rounds
+
+import (
+	"github.com/numbatx/gn-coval-index/process/utility"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-core/data/indexer"
+)
+
+type roundsProcessor struct {
+}
+
+// NewRoundsProcessor creates a new instance of rounds processor
+func NewRoundsProcessor() *roundsProcessor {
+	return &roundsProcessor{}
+}
+
+// ProcessRoundsInfo converts rounds info data to a specific structure defined by avro schema
+func (rp *roundsProcessor) ProcessRoundsInfo(roundsInfo []*indexer.RoundInfo) *schema.RoundsInfo {
+	rounds := make([]*schema.RoundInfo, 0, len(roundsInfo))
+
+	for _, roundInfo := range roundsInfo {
+		if roundInfo == nil {
+			continue
+		}
+
+		rounds = append(rounds, &schema.RoundInfo{
+			Round:            int64(roundInfo.Index),
+			SignersIndexes:   getSignersIndexes(roundInfo.SignersIndexes),
+			BlockWasProposed: roundInfo.BlockWasProposed,
+			ShardID:          int32(roundInfo.ShardId),
+			Epoch:            int32(roundInfo.Epoch),
+			Timestamp:        int64(roundInfo.Timestamp),
+		})
+	}
+
+	return &schema.RoundsInfo{
+		Rounds: rounds,
+	}
+}
+
+func getSignersIndexes(signersIndexes []uint64) []int64 {
+	if signersIndexes == nil {
+		return make([]int64, 0)
+	}
+
+	return utility.UIntSliceToIntSlice(signersIndexes)
+}
@@ -0,0 +1,68 @@
+package rounds_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index/process/rounds"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-core/data/indexer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundsProcessor_ProcessRoundsInfo(t *testing.T) {
+	t.Parallel()
+
+	rp := rounds.NewRoundsProcessor()
+	roundsInfo := []*indexer.RoundInfo{
+		{
+			Index:            100,
+			SignersIndexes:   []uint64{0, 3, 5},
+			BlockWasProposed: true,
+			ShardId:          1,
+			Epoch:            4,
+			Timestamp:        time.Duration(1650000000),
+		},
+		nil,
+		{
+			Index:            101,
+			SignersIndexes:   nil,
+			BlockWasProposed: false,
+			ShardId:          1,
+			Epoch:            4,
+			Timestamp:        time.Duration(1650000006),
+		},
+	}
+
+	ret := rp.ProcessRoundsInfo(roundsInfo)
+
+	require.Equal(t, &schema.RoundsInfo{
+		Rounds: []*schema.RoundInfo{
+			{
+				Round:            100,
+				SignersIndexes:   []int64{0, 3, 5},
+				BlockWasProposed: true,
+				ShardID:          1,
+				Epoch:            4,
+				Timestamp:        1650000000,
+			},
+			{
+				Round:            101,
+				SignersIndexes:   []int64{},
+				BlockWasProposed: false,
+				ShardID:          1,
+				Epoch:            4,
+				Timestamp:        1650000006,
+			},
+		},
+	}, ret)
+}
+
+func TestRoundsProcessor_ProcessRoundsInfo_NoRounds_ExpectEmptyRecord(t *testing.T) {
+	t.Parallel()
+
+	rp := rounds.NewRoundsProcessor()
+
+	ret := rp.ProcessRoundsInfo(nil)
+	require.Equal(t, &schema.RoundsInfo{Rounds: []*schema.RoundInfo{}}, ret)
+}
@@ -0,0 +1,110 @@
+package utility
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/elodina/go-avro"
+)
+
+// EnvelopeVersion is the protocol version of the envelopes created by EncodeWithEnvelope
+const EnvelopeVersion = 1
+
+// EncodeWithEnvelope returns the binary encoding of an envelope which wraps the binary encoding of the input
+// avro record, together with its sequence number, schema fingerprint, chain id, shard id and payload checksum
+func EncodeWithEnvelope(record avro.AvroRecord, sequenceNumber uint64, chainID []byte, shardID uint32) ([]byte, error) {
+	payload, err := Encode(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeEnvelope(payload, record.Schema(), sequenceNumber, chainID, shardID)
+}
+
+// EncodeStreamMessageWithEnvelope returns the binary encoding of an envelope which wraps the binary encoding of a
+// schema.StreamMessage having the input record as payload
+func EncodeStreamMessageWithEnvelope(record avro.AvroRecord, sequenceNumber uint64, chainID []byte, shardID uint32) ([]byte, error) {
+	payload, err := EncodeStreamMessage(record)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeEnvelope(payload, schema.NewStreamMessage().Schema(), sequenceNumber, chainID, shardID)
+}
+
+func encodeEnvelope(payload []byte, payloadSchema avro.Schema, sequenceNumber uint64, chainID []byte, shardID uint32) ([]byte, error) {
+	envelope := &schema.Envelope{
+		Version:           EnvelopeVersion,
+		SequenceNumber:    int64(sequenceNumber),
+		SchemaFingerprint: Fingerprint(payloadSchema),
+		ChainID:           chainID,
+		ShardID:           int32(shardID),
+		Checksum:          Checksum(payload),
+		Payload:           payload,
+	}
+
+	return Encode(envelope)
+}
+
+// DecodeWithEnvelope decodes an envelope from the data buffer and stores its payload on the input record, after
+// checking the envelope version, the schema fingerprint of the record and the payload checksum.
+// The envelope is returned, so that sequence number, chain id and shard id can be checked by the caller
+func DecodeWithEnvelope(record avro.AvroRecord, buffer []byte) (*schema.Envelope, error) {
+	envelope, err := decodeEnvelope(buffer, record.Schema())
+	if err != nil {
+		return nil, err
+	}
+
+	err = Decode(record, envelope.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+// DecodeStreamMessageWithEnvelope decodes an envelope wrapping a schema.StreamMessage from the data buffer and
+// returns the stream message payload together with the envelope, after the same checks as DecodeWithEnvelope
+func DecodeStreamMessageWithEnvelope(buffer []byte) (avro.AvroRecord, *schema.Envelope, error) {
+	envelope, err := decodeEnvelope(buffer, schema.NewStreamMessage().Schema())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	record, err := DecodeStreamMessage(envelope.Payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return record, envelope, nil
+}
+
+func decodeEnvelope(buffer []byte, payloadSchema avro.Schema) (*schema.Envelope, error) {
+	envelope := schema.NewEnvelope()
+	err := Decode(envelope, buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	if envelope.Version != EnvelopeVersion {
+		return nil, ErrUnsupportedEnvelopeVersion
+	}
+	if !bytes.Equal(envelope.SchemaFingerprint, Fingerprint(payloadSchema)) {
+		return nil, ErrSchemaFingerprintMismatch
+	}
+	if !bytes.Equal(envelope.Checksum, Checksum(envelope.Payload)) {
+		return nil, ErrChecksumMismatch
+	}
+
+	return envelope, nil
+}
+
+// Checksum returns the 4 bytes, big endian, CRC-32 (IEEE) checksum of the input data
+func Checksum(data []byte) []byte {
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, crc32.ChecksumIEEE(data))
+
+	return checksum
+}
@@ -0,0 +1,131 @@
+package utility_test
+
+import (
+	"testing"
+
+	"github.com/numbatx/gn-coval-index/process/utility"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-coval-index/testscommon"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeWithEnvelope(t *testing.T) {
+	t.Parallel()
+
+	account := &schema.AccountSnapshot{
+		Address:         testscommon.GenerateRandomFixedBytes(62),
+		Balance:         testscommon.GenerateRandomBytes(),
+		Nonce:           4,
+		UserName:        []byte("user"),
+		CodeHash:        testscommon.GenerateRandomFixedBytes(32),
+		RootHash:        testscommon.GenerateRandomFixedBytes(32),
+		Owner:           testscommon.GenerateRandomFixedBytes(62),
+		DeveloperReward: testscommon.GenerateRandomBytes(),
+	}
+
+	buff, err := utility.EncodeWithEnvelope(account, 7, []byte("chain"), 2)
+	require.Nil(t, err)
+
+	decodedAccount := &schema.AccountSnapshot{}
+	envelope, err := utility.DecodeWithEnvelope(decodedAccount, buff)
+	require.Nil(t, err)
+	require.Equal(t, account, decodedAccount)
+	require.Equal(t, int32(utility.EnvelopeVersion), envelope.Version)
+	require.Equal(t, int64(7), envelope.SequenceNumber)
+	require.Equal(t, []byte("chain"), envelope.ChainID)
+	require.Equal(t, int32(2), envelope.ShardID)
+	require.Equal(t, utility.Fingerprint(account.Schema()), envelope.SchemaFingerprint)
+}
+
+func TestDecodeWithEnvelope_InvalidEnvelope_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	account := &schema.AccountSnapshot{
+		Address: testscommon.GenerateRandomFixedBytes(62),
+		Balance: testscommon.GenerateRandomBytes(),
+	}
+	payload, err := utility.Encode(account)
+	require.Nil(t, err)
+
+	tests := []struct {
+		envelope    func() *schema.Envelope
+		expectedErr error
+	}{
+		{
+			envelope: func() *schema.Envelope {
+				envelope := createEnvelope(account, payload)
+				envelope.Version = utility.EnvelopeVersion + 1
+				return envelope
+			},
+			expectedErr: utility.ErrUnsupportedEnvelopeVersion,
+		},
+		{
+			envelope: func() *schema.Envelope {
+				envelope := createEnvelope(account, payload)
+				envelope.SchemaFingerprint = utility.Fingerprint(schema.NewBlockResult().Schema())
+				return envelope
+			},
+			expectedErr: utility.ErrSchemaFingerprintMismatch,
+		},
+		{
+			envelope: func() *schema.Envelope {
+				envelope := createEnvelope(account, payload)
+				envelope.Payload = append([]byte{}, payload...)
+				envelope.Payload[0]++
+				return envelope
+			},
+			expectedErr: utility.ErrChecksumMismatch,
+		},
+	}
+
+	for _, currTest := range tests {
+		buff, errEncode := utility.Encode(currTest.envelope())
+		require.Nil(t, errEncode)
+
+		envelope, errDecode := utility.DecodeWithEnvelope(&schema.AccountSnapshot{}, buff)
+		require.Equal(t, currTest.expectedErr, errDecode)
+		require.Nil(t, envelope)
+	}
+}
+
+func createEnvelope(account *schema.AccountSnapshot, payload []byte) *schema.Envelope {
+	return &schema.Envelope{
+		Version:           utility.EnvelopeVersion,
+		SchemaFingerprint: utility.Fingerprint(account.Schema()),
+		Checksum:          utility.Checksum(payload),
+		Payload:           payload,
+	}
+}
+
+func TestEncodeDecodeStreamMessageWithEnvelope(t *testing.T) {
+	t.Parallel()
+
+	blockRevert := &schema.BlockRevert{
+		Hash:    testscommon.GenerateRandomBytes(),
+		Nonce:   4,
+		Round:   5,
+		ShardID: 1,
+		Epoch:   2,
+	}
+
+	buff, err := utility.EncodeStreamMessageWithEnvelope(blockRevert, 7, []byte("chain"), 2)
+	require.Nil(t, err)
+
+	record, envelope, err := utility.DecodeStreamMessageWithEnvelope(buff)
+	require.Nil(t, err)
+	require.Equal(t, blockRevert, record)
+	require.Equal(t, int64(7), envelope.SequenceNumber)
+	require.Equal(t, []byte("chain"), envelope.ChainID)
+	require.Equal(t, int32(2), envelope.ShardID)
+	require.Equal(t, utility.Fingerprint(schema.NewStreamMessage().Schema()), envelope.SchemaFingerprint)
+
+	_, err = utility.DecodeWithEnvelope(&schema.BlockRevert{}, buff)
+	require.Equal(t, utility.ErrSchemaFingerprintMismatch, err)
+
+	buff, err = utility.EncodeWithEnvelope(blockRevert, 7, []byte("chain"), 2)
+	require.Nil(t, err)
+	record, envelope, err = utility.DecodeStreamMessageWithEnvelope(buff)
+	require.Equal(t, utility.ErrSchemaFingerprintMismatch, err)
+	require.Nil(t, record)
+	require.Nil(t, envelope)
+}
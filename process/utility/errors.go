@@ -0,0 +1,15 @@
+package utility
+
+import "errors"
+
+// ErrUnsupportedEnvelopeVersion signals that a received envelope has a protocol version which can not be decoded
+var ErrUnsupportedEnvelopeVersion = errors.New("unsupported envelope version")
+
+// ErrSchemaFingerprintMismatch signals that an envelope payload was encoded with a different schema than the expected one
+var ErrSchemaFingerprintMismatch = errors.New("envelope schema fingerprint does not match the expected schema")
+
+// ErrChecksumMismatch signals that an envelope payload does not match its checksum
+var ErrChecksumMismatch = errors.New("envelope payload checksum mismatch")
+
+// ErrUnknownStreamPayload signals that a record is not one of the payload types of a stream message
+var ErrUnknownStreamPayload = errors.New("unknown stream message payload type")
@@ -0,0 +1,154 @@
+package utility
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/elodina/go-avro"
+)
+
+// rabinEmpty is the initial value of the CRC-64-AVRO fingerprint, as defined by avro specification
+const rabinEmpty = uint64(0xc15d213aa4d7a795)
+
+var rabinTable = computeRabinTable()
+
+var fingerprints sync.Map
+
+func computeRabinTable() [256]uint64 {
+	table := [256]uint64{}
+	for i := 0; i < 256; i++ {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (rabinEmpty & -(fp & 1))
+		}
+		table[i] = fp
+	}
+
+	return table
+}
+
+// Fingerprint returns the 8 bytes, little endian, CRC-64-AVRO fingerprint of the parsing canonical form of the
+// input schema. This is the same fingerprint used by avro single object encoding, so consumers can compute it
+// from the avsc schema files with any avro library
+func Fingerprint(schema avro.Schema) []byte {
+	canonicalForm := ParsingCanonicalForm(schema)
+	cached, found := fingerprints.Load(canonicalForm)
+	if found {
+		return cached.([]byte)
+	}
+
+	fp := rabinEmpty
+	for i := 0; i < len(canonicalForm); i++ {
+		fp = (fp >> 8) ^ rabinTable[byte(fp)^canonicalForm[i]]
+	}
+
+	fingerprint := make([]byte, 8)
+	binary.LittleEndian.PutUint64(fingerprint, fp)
+	fingerprints.Store(canonicalForm, fingerprint)
+
+	return fingerprint
+}
+
+// ParsingCanonicalForm returns the parsing canonical form of the input schema, as defined by avro specification:
+// full names, no whitespaces and only the attributes relevant for parsing, in a fixed order
+func ParsingCanonicalForm(schema avro.Schema) string {
+	builder := &strings.Builder{}
+	writeCanonicalForm(builder, schema, "", make(map[string]struct{}))
+
+	return builder.String()
+}
+
+func writeCanonicalForm(builder *strings.Builder, schema avro.Schema, namespace string, defined map[string]struct{}) {
+	switch s := schema.(type) {
+	case *avro.RecordSchema:
+		if len(s.Namespace) > 0 {
+			namespace = s.Namespace
+		}
+		if writeNameIfDefined(builder, fullName(s.Name, namespace), defined) {
+			return
+		}
+
+		builder.WriteString(`{"name":` + strconv.Quote(fullName(s.Name, namespace)) + `,"type":"record","fields":[`)
+		for idx, field := range s.Fields {
+			if idx > 0 {
+				builder.WriteString(",")
+			}
+			builder.WriteString(`{"name":` + strconv.Quote(field.Name) + `,"type":`)
+			writeCanonicalForm(builder, field.Type, namespace, defined)
+			builder.WriteString("}")
+		}
+		builder.WriteString("]}")
+	case *avro.RecursiveSchema:
+		recordNamespace := namespace
+		if len(s.Actual.Namespace) > 0 {
+			recordNamespace = s.Actual.Namespace
+		}
+		builder.WriteString(strconv.Quote(fullName(s.Actual.Name, recordNamespace)))
+	case *avro.EnumSchema:
+		if len(s.Namespace) > 0 {
+			namespace = s.Namespace
+		}
+		if writeNameIfDefined(builder, fullName(s.Name, namespace), defined) {
+			return
+		}
+
+		builder.WriteString(`{"name":` + strconv.Quote(fullName(s.Name, namespace)) + `,"type":"enum","symbols":[`)
+		for idx, symbol := range s.Symbols {
+			if idx > 0 {
+				builder.WriteString(",")
+			}
+			builder.WriteString(strconv.Quote(symbol))
+		}
+		builder.WriteString("]}")
+	case *avro.FixedSchema:
+		if len(s.Namespace) > 0 {
+			namespace = s.Namespace
+		}
+		if writeNameIfDefined(builder, fullName(s.Name, namespace), defined) {
+			return
+		}
+
+		builder.WriteString(`{"name":` + strconv.Quote(fullName(s.Name, namespace)) + `,"type":"fixed","size":` + strconv.Itoa(s.Size) + "}")
+	case *avro.ArraySchema:
+		builder.WriteString(`{"type":"array","items":`)
+		writeCanonicalForm(builder, s.Items, namespace, defined)
+		builder.WriteString("}")
+	case *avro.MapSchema:
+		builder.WriteString(`{"type":"map","values":`)
+		writeCanonicalForm(builder, s.Values, namespace, defined)
+		builder.WriteString("}")
+	case *avro.UnionSchema:
+		builder.WriteString("[")
+		for idx, unionType := range s.Types {
+			if idx > 0 {
+				builder.WriteString(",")
+			}
+			writeCanonicalForm(builder, unionType, namespace, defined)
+		}
+		builder.WriteString("]")
+	default:
+		builder.WriteString(strconv.Quote(schema.GetName()))
+	}
+}
+
+// writeNameIfDefined writes only the full name of an already defined named schema, otherwise marks it as defined
+func writeNameIfDefined(builder *strings.Builder, name string, defined map[string]struct{}) bool {
+	_, found := defined[name]
+	if found {
+		builder.WriteString(strconv.Quote(name))
+		return true
+	}
+
+	defined[name] = struct{}{}
+	return false
+}
+
+func fullName(name string, namespace string) string {
+	if len(namespace) == 0 || strings.ContainsRune(name, '.') {
+		return name
+	}
+
+	return namespace + "." + name
+}
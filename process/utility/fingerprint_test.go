@@ -0,0 +1,57 @@
+package utility_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index/process/utility"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/elodina/go-avro"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint_PrimitiveSchemas(t *testing.T) {
+	t.Parallel()
+
+	// Expected values are taken from avro specification test vectors, as signed 64 bit integers
+	tests := []struct {
+		schema      string
+		fingerprint int64
+	}{
+		{schema: `"null"`, fingerprint: 7195948357588979594},
+		{schema: `"boolean"`, fingerprint: -6970731678124411036},
+		{schema: `"int"`, fingerprint: 8247732601305521295},
+		{schema: `"long"`, fingerprint: -3434872931120570953},
+		{schema: `"string"`, fingerprint: -8142146995180207161},
+	}
+
+	for _, currTest := range tests {
+		fingerprint := utility.Fingerprint(avro.MustParseSchema(currTest.schema))
+		require.Len(t, fingerprint, 8)
+		require.Equal(t, currTest.fingerprint, int64(binary.LittleEndian.Uint64(fingerprint)))
+	}
+}
+
+func TestParsingCanonicalForm_NamedTypes_ExpectFullNamesDefinedOnce(t *testing.T) {
+	t.Parallel()
+
+	canonicalForm := utility.ParsingCanonicalForm(schema.NewEnvelope().Schema())
+
+	expected := `{"name":"com.covalenthq.block.schema.Envelope","type":"record","fields":[` +
+		`{"name":"Version","type":"int"},` +
+		`{"name":"SequenceNumber","type":"long"},` +
+		`{"name":"SchemaFingerprint","type":{"name":"com.covalenthq.block.schema.fingerprint","type":"fixed","size":8}},` +
+		`{"name":"ChainID","type":"bytes"},` +
+		`{"name":"ShardID","type":"int"},` +
+		`{"name":"Checksum","type":{"name":"com.covalenthq.block.schema.checksum","type":"fixed","size":4}},` +
+		`{"name":"Payload","type":"bytes"}]}`
+	require.Equal(t, expected, canonicalForm)
+}
+
+func TestFingerprint_DifferentSchemas_ExpectDifferentFingerprints(t *testing.T) {
+	t.Parallel()
+
+	blockResultFingerprint := utility.Fingerprint(schema.NewBlockResult().Schema())
+	require.Equal(t, blockResultFingerprint, utility.Fingerprint(schema.NewBlockResult().Schema()))
+	require.NotEqual(t, blockResultFingerprint, utility.Fingerprint(schema.NewEnvelope().Schema()))
+}
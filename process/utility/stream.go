@@ -0,0 +1,84 @@
+package utility
+
+import (
+	"bytes"
+
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/elodina/go-avro"
+)
+
+// streamPayloads holds, by schema name, a constructor for each payload type of schema.StreamMessage
+var streamPayloads = map[string]func() avro.AvroRecord{
+	schema.NewBlockResult().Schema().GetName():           func() avro.AvroRecord { return schema.NewBlockResult() },
+	schema.NewBlockRevert().Schema().GetName():           func() avro.AvroRecord { return schema.NewBlockRevert() },
+	schema.NewBlockFinalized().Schema().GetName():        func() avro.AvroRecord { return schema.NewBlockFinalized() },
+	schema.NewBlockProcessingFailed().Schema().GetName(): func() avro.AvroRecord { return schema.NewBlockProcessingFailed() },
+	schema.NewRoundsInfo().Schema().GetName():            func() avro.AvroRecord { return schema.NewRoundsInfo() },
+	schema.NewEpochValidators().Schema().GetName():       func() avro.AvroRecord { return schema.NewEpochValidators() },
+	schema.NewValidatorsRating().Schema().GetName():      func() avro.AvroRecord { return schema.NewValidatorsRating() },
+	schema.NewAccountsSnapshot().Schema().GetName():      func() avro.AvroRecord { return schema.NewAccountsSnapshot() },
+}
+
+// StreamPayloadTypes returns the schemas of the payload union of schema.StreamMessage, in union order
+func StreamPayloadTypes() []avro.Schema {
+	streamMessageSchema := schema.NewStreamMessage().Schema().(*avro.RecordSchema)
+	return streamMessageSchema.Fields[0].Type.(*avro.UnionSchema).Types
+}
+
+// EncodeStreamMessage returns the binary encoding of a schema.StreamMessage having the input record as payload.
+// The union branch is chosen by the name of the record schema, since avro would choose the first record branch
+// for any record. Names are unique, all payload types sharing the namespace of the stream message
+func EncodeStreamMessage(record avro.AvroRecord) ([]byte, error) {
+	payloadTypes := StreamPayloadTypes()
+	name := record.Schema().GetName()
+
+	for index, payloadType := range payloadTypes {
+		if payloadType.GetName() != name {
+			continue
+		}
+
+		buffer := new(bytes.Buffer)
+		encoder := avro.NewBinaryEncoder(buffer)
+		encoder.WriteLong(int64(index))
+
+		writer := avro.NewSpecificDatumWriter()
+		writer.SetSchema(payloadType)
+		err := writer.Write(record, encoder)
+		if err != nil {
+			return nil, err
+		}
+
+		return buffer.Bytes(), nil
+	}
+
+	return nil, ErrUnknownStreamPayload
+}
+
+// DecodeStreamMessage decodes a schema.StreamMessage from the data buffer and returns its payload, as a record of
+// the specific type of its union branch(e.g. *schema.BlockResult), so that consumers can dispatch on its type
+func DecodeStreamMessage(buffer []byte) (avro.AvroRecord, error) {
+	decoder := avro.NewBinaryDecoder(buffer)
+	index, err := decoder.ReadLong()
+	if err != nil {
+		return nil, err
+	}
+
+	payloadTypes := StreamPayloadTypes()
+	if index < 0 || index >= int64(len(payloadTypes)) {
+		return nil, ErrUnknownStreamPayload
+	}
+	newPayload, found := streamPayloads[payloadTypes[index].GetName()]
+	if !found {
+		return nil, ErrUnknownStreamPayload
+	}
+
+	record := newPayload()
+	reader := avro.NewSpecificDatumReader()
+	reader.SetSchema(payloadTypes[index])
+	err = reader.Read(record, decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
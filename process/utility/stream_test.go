@@ -0,0 +1,102 @@
+package utility_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index/process/utility"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-coval-index/testscommon"
+	"github.com/elodina/go-avro"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamPayloadTypes_ExpectSameSchemasAsRecords(t *testing.T) {
+	t.Parallel()
+
+	records := []avro.AvroRecord{
+		schema.NewBlockResult(),
+		schema.NewBlockRevert(),
+		schema.NewBlockFinalized(),
+		schema.NewBlockProcessingFailed(),
+		schema.NewRoundsInfo(),
+		schema.NewEpochValidators(),
+		schema.NewValidatorsRating(),
+		schema.NewAccountsSnapshot(),
+	}
+
+	// payload types are nested in the stream message, hence they have no namespace of their own
+	namespace := schema.NewStreamMessage().Schema().(*avro.RecordSchema).Namespace + "."
+	payloadTypes := utility.StreamPayloadTypes()
+	require.Len(t, payloadTypes, len(records))
+	for idx, record := range records {
+		expectedForm := strings.ReplaceAll(utility.ParsingCanonicalForm(record.Schema()), namespace, "")
+		require.Equal(t, expectedForm, utility.ParsingCanonicalForm(payloadTypes[idx]), record.Schema().GetName())
+	}
+}
+
+func TestEncodeDecodeStreamMessage(t *testing.T) {
+	t.Parallel()
+
+	records := []avro.AvroRecord{
+		&schema.BlockFinalized{
+			Hash:    testscommon.GenerateRandomBytes(),
+			Nonce:   4,
+			Round:   5,
+			ShardID: 1,
+			Epoch:   2,
+		},
+		&schema.BlockProcessingFailed{
+			Hash:  testscommon.GenerateRandomBytes(),
+			Nonce: 4,
+			Stage: "process",
+			Error: "error",
+		},
+		&schema.RoundsInfo{
+			Rounds: []*schema.RoundInfo{
+				{Round: 5, SignersIndexes: []int64{1, 2}, BlockWasProposed: true, ShardID: 1, Epoch: 2, Timestamp: 3},
+			},
+		},
+		&schema.EpochValidators{
+			Epoch:      2,
+			ShardID:    1,
+			PublicKeys: [][]byte{testscommon.GenerateRandomBytes()},
+		},
+		&schema.ValidatorsRating{
+			Ratings: []*schema.ValidatorRating{
+				{PublicKey: "key", Rating: 50.5, ShardID: 1, Epoch: 2},
+			},
+		},
+	}
+
+	for _, record := range records {
+		buff, err := utility.EncodeStreamMessage(record)
+		require.Nil(t, err)
+
+		decodedRecord, err := utility.DecodeStreamMessage(buff)
+		require.Nil(t, err)
+		require.Equal(t, record, decodedRecord)
+	}
+}
+
+func TestEncodeStreamMessage_UnknownPayload_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	buff, err := utility.EncodeStreamMessage(&schema.Envelope{})
+	require.Equal(t, utility.ErrUnknownStreamPayload, err)
+	require.Nil(t, buff)
+}
+
+func TestDecodeStreamMessage_InvalidPayloadIndex_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	encoded, err := utility.Encode(&schema.EpochValidators{Epoch: 1, PublicKeys: [][]byte{}})
+	require.Nil(t, err)
+
+	// the first byte of an encoded stream message is the zigzag encoded union index
+	for _, index := range []byte{0x01, 0x7e} {
+		record, errDecode := utility.DecodeStreamMessage(append([]byte{index}, encoded...))
+		require.Equal(t, utility.ErrUnknownStreamPayload, errDecode)
+		require.Nil(t, record)
+	}
+}
@@ -60,16 +60,21 @@ func TestGetBytes(t *testing.T) {
 }
 
 func TestEncodeDecode(t *testing.T) {
-	account := &schema.AccountBalanceUpdate{
-		Address: testscommon.GenerateRandomFixedBytes(62),
-		Balance: big.NewInt(1000).Bytes(),
-		Nonce:   444,
+	account := &schema.AccountSnapshot{
+		Address:         testscommon.GenerateRandomFixedBytes(62),
+		Balance:         big.NewInt(1000).Bytes(),
+		Nonce:           444,
+		UserName:        []byte("user"),
+		CodeHash:        testscommon.GenerateRandomFixedBytes(32),
+		RootHash:        testscommon.GenerateRandomFixedBytes(32),
+		Owner:           testscommon.GenerateRandomFixedBytes(62),
+		DeveloperReward: big.NewInt(10).Bytes(),
 	}
 
 	buffer, err := utility.Encode(account)
 	require.Nil(t, err)
 
-	decodedAccount := &schema.AccountBalanceUpdate{}
+	decodedAccount := &schema.AccountSnapshot{}
 	err = utility.Decode(decodedAccount, buffer)
 	require.Nil(t, err)
 
@@ -224,19 +229,6 @@ func TestEncode_LogAndEvent(t *testing.T) {
 	require.Nil(t, err)
 }
 
-func TestEncode_AccountBalanceUpdate(t *testing.T) {
-	acc := schema.AccountBalanceUpdate{
-		Address: testscommon.GenerateRandomFixedBytes(62),
-	}
-	_, err := utility.Encode(&acc)
-	require.Nil(t, err)
-
-	accNilAddress := acc
-	accNilAddress.Address = nil
-	_, err = utility.Encode(&accNilAddress)
-	require.NotNil(t, err)
-}
-
 func TestEncode_BlockResult(t *testing.T) {
 	block := schema.Block{
 		Hash:          testscommon.GenerateRandomFixedBytes(32),
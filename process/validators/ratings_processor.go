@@ -0,0 +1,66 @@
+package validators
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-core/data/indexer"
+)
+
+// indexIDSeparator separates the shard id from the epoch in the index id of validators ratings, e.g. "1_20"
+const indexIDSeparator = "_"
+
+type ratingsProcessor struct {
+}
+
+// NewRatingsProcessor creates a new instance of validators ratings processor
+func NewRatingsProcessor() *ratingsProcessor {
+	return &ratingsProcessor{}
+}
+
+// ProcessValidatorsRating converts validators ratings data to a specific structure defined by avro schema. The index
+// id is parsed into the shard id and epoch of the ratings
+func (rp *ratingsProcessor) ProcessValidatorsRating(indexID string, ratingsInfo []*indexer.ValidatorRatingInfo) (*schema.ValidatorsRating, error) {
+	shardID, epoch, err := parseIndexID(indexID)
+	if err != nil {
+		return nil, err
+	}
+
+	ratings := make([]*schema.ValidatorRating, 0, len(ratingsInfo))
+	for _, ratingInfo := range ratingsInfo {
+		if ratingInfo == nil {
+			continue
+		}
+
+		ratings = append(ratings, &schema.ValidatorRating{
+			PublicKey: ratingInfo.PublicKey,
+			Rating:    ratingInfo.Rating,
+			ShardID:   int32(shardID),
+			Epoch:     int32(epoch),
+		})
+	}
+
+	return &schema.ValidatorsRating{
+		Ratings: ratings,
+	}, nil
+}
+
+func parseIndexID(indexID string) (uint32, uint32, error) {
+	parts := strings.Split(indexID, indexIDSeparator)
+	if len(parts) != 2 {
+		return 0, 0, covalent.ErrInvalidRatingIndexID
+	}
+
+	shardID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, 0, covalent.ErrInvalidRatingIndexID
+	}
+	epoch, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return 0, 0, covalent.ErrInvalidRatingIndexID
+	}
+
+	return uint32(shardID), uint32(epoch), nil
+}
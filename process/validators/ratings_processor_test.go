@@ -0,0 +1,44 @@
+package validators_test
+
+import (
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/process/validators"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-core/data/indexer"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatingsProcessor_ProcessValidatorsRating(t *testing.T) {
+	t.Parallel()
+
+	rp := validators.NewRatingsProcessor()
+	ratingsInfo := []*indexer.ValidatorRatingInfo{
+		{PublicKey: "key1", Rating: 50.5},
+		nil,
+		{PublicKey: "key2", Rating: 100},
+	}
+
+	ret, err := rp.ProcessValidatorsRating("4294967295_20", ratingsInfo)
+	require.Nil(t, err)
+	require.Equal(t, &schema.ValidatorsRating{
+		Ratings: []*schema.ValidatorRating{
+			{PublicKey: "key1", Rating: 50.5, ShardID: -1, Epoch: 20},
+			{PublicKey: "key2", Rating: 100, ShardID: -1, Epoch: 20},
+		},
+	}, ret)
+}
+
+func TestRatingsProcessor_ProcessValidatorsRating_InvalidIndexID_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	rp := validators.NewRatingsProcessor()
+	invalidIndexIDs := []string{"", "1", "1_", "_20", "1_20_3", "a_20", "1_b", "-1_20", "4294967296_20"}
+
+	for _, indexID := range invalidIndexIDs {
+		ret, err := rp.ProcessValidatorsRating(indexID, []*indexer.ValidatorRatingInfo{{PublicKey: "key1"}})
+		require.Nil(t, ret)
+		require.Equal(t, covalent.ErrInvalidRatingIndexID, err, indexID)
+	}
+}
@@ -0,0 +1,75 @@
+package validators
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/numbatx/gn-coval-index/schema"
+)
+
+type validatorsProcessor struct {
+	mut     sync.RWMutex
+	epoch   uint32
+	pubKeys map[uint32][][]byte
+}
+
+// NewValidatorsProcessor creates a new instance of validators processor, which also keeps the latest reported
+// validators public keys, so that they can be resolved from their indexes
+func NewValidatorsProcessor() *validatorsProcessor {
+	return &validatorsProcessor{
+		pubKeys: make(map[uint32][][]byte),
+	}
+}
+
+// ProcessValidatorsPubKeys caches the validators public keys of the epoch and converts them to specific structures
+// defined by avro schema, one for each shard, ordered by shard id. The public keys are copied, since the records may
+// be encoded asynchronously, after the node reused its slices
+func (vp *validatorsProcessor) ProcessValidatorsPubKeys(validatorsPubKeys map[uint32][][]byte, epoch uint32) []*schema.EpochValidators {
+	shardIDs := make([]uint32, 0, len(validatorsPubKeys))
+	pubKeys := make(map[uint32][][]byte, len(validatorsPubKeys))
+	for shardID, shardPubKeys := range validatorsPubKeys {
+		shardIDs = append(shardIDs, shardID)
+		pubKeys[shardID] = copyPubKeys(shardPubKeys)
+	}
+	sort.Slice(shardIDs, func(i, j int) bool {
+		return shardIDs[i] < shardIDs[j]
+	})
+
+	vp.mut.Lock()
+	vp.epoch = epoch
+	vp.pubKeys = pubKeys
+	vp.mut.Unlock()
+
+	epochValidators := make([]*schema.EpochValidators, 0, len(shardIDs))
+	for _, shardID := range shardIDs {
+		epochValidators = append(epochValidators, &schema.EpochValidators{
+			Epoch:      int32(epoch),
+			ShardID:    int32(shardID),
+			PublicKeys: pubKeys[shardID],
+		})
+	}
+
+	return epochValidators
+}
+
+// GetValidatorsPubKeys returns the ordered validators public keys of the shard, if they were reported for the epoch
+func (vp *validatorsProcessor) GetValidatorsPubKeys(shardID uint32, epoch uint32) ([][]byte, bool) {
+	vp.mut.RLock()
+	defer vp.mut.RUnlock()
+
+	if vp.epoch != epoch {
+		return nil, false
+	}
+
+	pubKeys, found := vp.pubKeys[shardID]
+	return pubKeys, found
+}
+
+func copyPubKeys(pubKeys [][]byte) [][]byte {
+	copied := make([][]byte, 0, len(pubKeys))
+	for _, pubKey := range pubKeys {
+		copied = append(copied, append(make([]byte, 0, len(pubKey)), pubKey...))
+	}
+
+	return copied
+}
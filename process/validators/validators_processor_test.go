@@ -0,0 +1,63 @@
+package validators_test
+
+import (
+	"testing"
+
+	"github.com/numbatx/gn-coval-index/process/validators"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-core/core"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatorsProcessor_ProcessValidatorsPubKeys_ExpectOneRecordPerShardOrderedByShard(t *testing.T) {
+	t.Parallel()
+
+	vp := validators.NewValidatorsProcessor()
+	validatorsPubKeys := map[uint32][][]byte{
+		core.MetachainShardId: {[]byte("meta1")},
+		1:                     {[]byte("key3"), []byte("key4")},
+		0:                     {[]byte("key1"), []byte("key2")},
+	}
+
+	ret := vp.ProcessValidatorsPubKeys(validatorsPubKeys, 4)
+
+	require.Equal(t, []*schema.EpochValidators{
+		{Epoch: 4, ShardID: 0, PublicKeys: [][]byte{[]byte("key1"), []byte("key2")}},
+		{Epoch: 4, ShardID: 1, PublicKeys: [][]byte{[]byte("key3"), []byte("key4")}},
+		// metachain shard id is written as an int, same as in blocks
+		{Epoch: 4, ShardID: -1, PublicKeys: [][]byte{[]byte("meta1")}},
+	}, ret)
+}
+
+func TestValidatorsProcessor_GetValidatorsPubKeys(t *testing.T) {
+	t.Parallel()
+
+	vp := validators.NewValidatorsProcessor()
+
+	_, found := vp.GetValidatorsPubKeys(0, 0)
+	require.False(t, found)
+
+	shard0PubKeys := [][]byte{[]byte("key1"), []byte("key2")}
+	validatorsPubKeys := map[uint32][][]byte{0: shard0PubKeys}
+	ret := vp.ProcessValidatorsPubKeys(validatorsPubKeys, 4)
+	// the node may reuse its slices while the records wait to be encoded
+	shard0PubKeys[0] = []byte("changed by node")
+	shard0PubKeys[1][0] = 'K'
+
+	pubKeys, found := vp.GetValidatorsPubKeys(0, 4)
+	require.True(t, found)
+	require.Equal(t, [][]byte{[]byte("key1"), []byte("key2")}, pubKeys)
+	require.Equal(t, [][]byte{[]byte("key1"), []byte("key2")}, ret[0].PublicKeys)
+
+	_, found = vp.GetValidatorsPubKeys(1, 4)
+	require.False(t, found)
+	_, found = vp.GetValidatorsPubKeys(0, 3)
+	require.False(t, found)
+
+	vp.ProcessValidatorsPubKeys(map[uint32][][]byte{0: {[]byte("key5")}}, 5)
+	_, found = vp.GetValidatorsPubKeys(0, 4)
+	require.False(t, found)
+	pubKeys, found = vp.GetValidatorsPubKeys(0, 5)
+	require.True(t, found)
+	require.Equal(t, [][]byte{[]byte("key5")}, pubKeys)
+}
@@ -0,0 +1,226 @@
+package quarantine
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/numbatx/gn-core/data"
+	"github.com/numbatx/gn-core/data/indexer"
+	"github.com/numbatx/gn-core/marshal"
+	logger "github.com/numbatx/gn-logger"
+)
+
+var log = logger.GetOrCreate("covalent/quarantine")
+
+// ArgsDiskQuarantine holds all input dependencies required by disk quarantine in order to create a new instance
+type ArgsDiskQuarantine struct {
+	Directory  string
+	Marshaller marshal.Marshalizer
+}
+
+// MarshalledObject holds an object marshalled with the node's marshaller, together with its go type,
+// so that it can be unmarshalled into the same type when replayed
+type MarshalledObject struct {
+	Type string `json:"type"`
+	Data []byte `json:"data"`
+}
+
+// QuarantinedBlock is the content of a quarantine file. Transactions are grouped by pool category
+// (txs, scrs, rewards, invalid, receipts) and indexed by their hex encoded hash, as are logs
+type QuarantinedBlock struct {
+	HeaderHash             []byte                                  `json:"headerHash"`
+	Nonce                  uint64                                  `json:"nonce"`
+	Round                  uint64                                  `json:"round"`
+	Epoch                  uint32                                  `json:"epoch"`
+	Error                  string                                  `json:"error"`
+	Timestamp              int64                                   `json:"timestamp"`
+	Header                 *MarshalledObject                       `json:"header,omitempty"`
+	Body                   *MarshalledObject                       `json:"body,omitempty"`
+	SignersIndexes         []uint64                                `json:"signersIndexes,omitempty"`
+	NotarizedHeadersHashes []string                                `json:"notarizedHeadersHashes,omitempty"`
+	HeaderGasConsumption   indexer.HeaderGasConsumption            `json:"headerGasConsumption"`
+	Transactions           map[string]map[string]*MarshalledObject `json:"transactions,omitempty"`
+	Logs                   map[string]*MarshalledObject            `json:"logs,omitempty"`
+}
+
+type diskQuarantine struct {
+	directory  string
+	marshaller marshal.Marshalizer
+}
+
+// NewDiskQuarantine creates a new quarantine which stores the raw input of each block which could not be indexed
+// in its own json file from the provided directory. Files are named after the nonce and hash of the block
+func NewDiskQuarantine(args *ArgsDiskQuarantine) (*diskQuarantine, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if len(args.Directory) == 0 {
+		return nil, covalent.ErrEmptyQuarantineDirectory
+	}
+	if check.IfNil(args.Marshaller) {
+		return nil, covalent.ErrNilMarshaller
+	}
+
+	err := os.MkdirAll(args.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &diskQuarantine{
+		directory:  args.Directory,
+		marshaller: args.Marshaller,
+	}, nil
+}
+
+// Store writes the block input, together with the cause of its failure, to a new quarantine file
+func (dq *diskQuarantine) Store(args *indexer.ArgsSaveBlockData, cause error) error {
+	if args == nil {
+		return covalent.ErrNilArguments
+	}
+
+	quarantinedBlock, err := dq.createQuarantinedBlock(args, cause)
+	if err != nil {
+		return err
+	}
+
+	buff, err := json.MarshalIndent(quarantinedBlock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fileName := filepath.Join(dq.directory,
+		fmt.Sprintf("block-%020d-%s.json", quarantinedBlock.Nonce, hex.EncodeToString(args.HeaderHash)))
+	tmpFileName := fileName + ".tmp"
+	err = os.WriteFile(tmpFileName, buff, 0644)
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(tmpFileName, fileName)
+	if err != nil {
+		return err
+	}
+
+	log.Info("block quarantined", "file", fileName, "nonce", quarantinedBlock.Nonce)
+	return nil
+}
+
+func (dq *diskQuarantine) createQuarantinedBlock(args *indexer.ArgsSaveBlockData, cause error) (*QuarantinedBlock, error) {
+	quarantinedBlock := &QuarantinedBlock{
+		HeaderHash:             args.HeaderHash,
+		Timestamp:              time.Now().Unix(),
+		SignersIndexes:         args.SignersIndexes,
+		NotarizedHeadersHashes: args.NotarizedHeadersHashes,
+		HeaderGasConsumption:   args.HeaderGasConsumption,
+	}
+	if cause != nil {
+		quarantinedBlock.Error = cause.Error()
+	}
+
+	var err error
+	if !check.IfNil(args.Header) {
+		quarantinedBlock.Nonce = args.Header.GetNonce()
+		quarantinedBlock.Round = args.Header.GetRound()
+		quarantinedBlock.Epoch = args.Header.GetEpoch()
+
+		quarantinedBlock.Header, err = dq.marshal(args.Header)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !check.IfNil(args.Body) {
+		quarantinedBlock.Body, err = dq.marshal(args.Body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if args.TransactionsPool == nil {
+		return quarantinedBlock, nil
+	}
+
+	quarantinedBlock.Transactions, err = dq.marshalTransactions(args.TransactionsPool)
+	if err != nil {
+		return nil, err
+	}
+
+	quarantinedBlock.Logs, err = dq.marshalLogs(args.TransactionsPool.Logs)
+	if err != nil {
+		return nil, err
+	}
+
+	return quarantinedBlock, nil
+}
+
+func (dq *diskQuarantine) marshalTransactions(pool *indexer.Pool) (map[string]map[string]*MarshalledObject, error) {
+	categories := map[string]map[string]data.TransactionHandler{
+		"txs":      pool.Txs,
+		"scrs":     pool.Scrs,
+		"rewards":  pool.Rewards,
+		"invalid":  pool.Invalid,
+		"receipts": pool.Receipts,
+	}
+
+	transactions := make(map[string]map[string]*MarshalledObject)
+	for category, txs := range categories {
+		if len(txs) == 0 {
+			continue
+		}
+
+		transactions[category] = make(map[string]*MarshalledObject, len(txs))
+		for hash, tx := range txs {
+			marshalledTx, err := dq.marshal(tx)
+			if err != nil {
+				return nil, err
+			}
+
+			transactions[category][hex.EncodeToString([]byte(hash))] = marshalledTx
+		}
+	}
+
+	return transactions, nil
+}
+
+func (dq *diskQuarantine) marshalLogs(logs []*data.LogData) (map[string]*MarshalledObject, error) {
+	if len(logs) == 0 {
+		return nil, nil
+	}
+
+	marshalledLogs := make(map[string]*MarshalledObject, len(logs))
+	for _, logData := range logs {
+		if logData == nil || check.IfNil(logData.LogHandler) {
+			continue
+		}
+
+		marshalledLog, err := dq.marshal(logData.LogHandler)
+		if err != nil {
+			return nil, err
+		}
+
+		marshalledLogs[hex.EncodeToString([]byte(logData.TxHash))] = marshalledLog
+	}
+
+	return marshalledLogs, nil
+}
+
+func (dq *diskQuarantine) marshal(obj interface{}) (*MarshalledObject, error) {
+	buff, err := dq.marshaller.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MarshalledObject{
+		Type: fmt.Sprintf("%T", obj),
+		Data: buff,
+	}, nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (dq *diskQuarantine) IsInterfaceNil() bool {
+	return dq == nil
+}
@@ -0,0 +1,128 @@
+package quarantine_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/quarantine"
+	"github.com/numbatx/gn-coval-index/testscommon/mock"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/numbatx/gn-core/data"
+	"github.com/numbatx/gn-core/data/block"
+	"github.com/numbatx/gn-core/data/indexer"
+	"github.com/numbatx/gn-core/data/transaction"
+	"github.com/numbatx/gn-core/marshal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDiskQuarantine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *quarantine.ArgsDiskQuarantine
+		expectedErr error
+	}{
+		{
+			args: func() *quarantine.ArgsDiskQuarantine {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args: func() *quarantine.ArgsDiskQuarantine {
+				return &quarantine.ArgsDiskQuarantine{Directory: "", Marshaller: &marshal.JsonMarshalizer{}}
+			},
+			expectedErr: covalent.ErrEmptyQuarantineDirectory,
+		},
+		{
+			args: func() *quarantine.ArgsDiskQuarantine {
+				return &quarantine.ArgsDiskQuarantine{Directory: t.TempDir(), Marshaller: nil}
+			},
+			expectedErr: covalent.ErrNilMarshaller,
+		},
+		{
+			args: func() *quarantine.ArgsDiskQuarantine {
+				return &quarantine.ArgsDiskQuarantine{Directory: t.TempDir(), Marshaller: &marshal.JsonMarshalizer{}}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := quarantine.NewDiskQuarantine(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(instance))
+	}
+}
+
+func TestDiskQuarantine_Store_ExpectBlockInputWrittenToFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	marshaller := &marshal.JsonMarshalizer{}
+	dq, _ := quarantine.NewDiskQuarantine(&quarantine.ArgsDiskQuarantine{Directory: dir, Marshaller: marshaller})
+
+	header := &block.Header{Nonce: 4, Round: 5, Epoch: 6}
+	body := &block.Body{MiniBlocks: []*block.MiniBlock{{SenderShardID: 1}}}
+	tx := &transaction.Transaction{Nonce: 7, Data: []byte("data")}
+	args := &indexer.ArgsSaveBlockData{
+		HeaderHash: []byte{0xaa, 0xbb},
+		Header:     header,
+		Body:       body,
+		TransactionsPool: &indexer.Pool{
+			Txs: map[string]data.TransactionHandler{"txHash": tx},
+		},
+	}
+
+	require.Nil(t, dq.Store(args, errors.New("processing error")))
+	require.Equal(t, covalent.ErrNilArguments, dq.Store(nil, errors.New("processing error")))
+
+	buff, err := os.ReadFile(filepath.Join(dir, "block-00000000000000000004-aabb.json"))
+	require.Nil(t, err)
+
+	quarantinedBlock := &quarantine.QuarantinedBlock{}
+	require.Nil(t, json.Unmarshal(buff, quarantinedBlock))
+	require.Equal(t, args.HeaderHash, quarantinedBlock.HeaderHash)
+	require.Equal(t, uint64(4), quarantinedBlock.Nonce)
+	require.Equal(t, uint64(5), quarantinedBlock.Round)
+	require.Equal(t, uint32(6), quarantinedBlock.Epoch)
+	require.Equal(t, "processing error", quarantinedBlock.Error)
+
+	require.Equal(t, "*block.Header", quarantinedBlock.Header.Type)
+	storedHeader := &block.Header{}
+	require.Nil(t, marshaller.Unmarshal(storedHeader, quarantinedBlock.Header.Data))
+	require.Equal(t, header, storedHeader)
+
+	require.Equal(t, "*block.Body", quarantinedBlock.Body.Type)
+	storedBody := &block.Body{}
+	require.Nil(t, marshaller.Unmarshal(storedBody, quarantinedBlock.Body.Data))
+	require.Equal(t, body, storedBody)
+
+	storedTx := quarantinedBlock.Transactions["txs"][hex.EncodeToString([]byte("txHash"))]
+	require.Equal(t, "*transaction.Transaction", storedTx.Type)
+	unmarshalledTx := &transaction.Transaction{}
+	require.Nil(t, marshaller.Unmarshal(unmarshalledTx, storedTx.Data))
+	require.Equal(t, tx, unmarshalledTx)
+}
+
+func TestDiskQuarantine_Store_MarshalError_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	errMarshal := errors.New("marshal error")
+	dq, _ := quarantine.NewDiskQuarantine(&quarantine.ArgsDiskQuarantine{
+		Directory: t.TempDir(),
+		Marshaller: &mock.MarshallerStub{
+			MarshalCalled: func(obj interface{}) ([]byte, error) {
+				return nil, errMarshal
+			},
+		},
+	})
+
+	err := dq.Store(&indexer.ArgsSaveBlockData{Header: &block.Header{}}, errors.New("processing error"))
+	require.Equal(t, errMarshal, err)
+}
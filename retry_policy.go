@@ -0,0 +1,197 @@
+package covalent
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RetryExhaustedAction defines what happens with data which could not be delivered after all retries allowed by
+// a retry policy were made
+type RetryExhaustedAction string
+
+const (
+	// RetryExhaustedWait keeps retrying, with delays increasing up to the maximum one. It is the default action
+	RetryExhaustedWait RetryExhaustedAction = "wait"
+	// RetryExhaustedError gives up and returns ErrRetriesExhausted
+	RetryExhaustedError RetryExhaustedAction = "error"
+	// RetryExhaustedSpill gives up and publishes the data to a spill sink, e.g. a file sink
+	RetryExhaustedSpill RetryExhaustedAction = "spill"
+)
+
+// ArgsRetryPolicy holds all input dependencies required by retry policy in order to create a new instance.
+// The delay before the n-th retry is InitialDelay * Multiplier^(n-1), capped at MaxDelay, and randomly changed
+// by at most Jitter * delay in both directions. MaxDelay, MaxAttempts and MaxElapsedTime are not used if zero.
+// MaxAttempts includes the first attempt and MaxElapsedTime is measured from the first attempt
+type ArgsRetryPolicy struct {
+	InitialDelay   time.Duration
+	Multiplier     float64
+	Jitter         float64
+	MaxDelay       time.Duration
+	MaxAttempts    int
+	MaxElapsedTime time.Duration
+	OnExhausted    RetryExhaustedAction
+}
+
+// maxRetryDelay bounds the delay of policies without a maximum delay, so that it does not overflow
+const maxRetryDelay = 24 * time.Hour
+
+type retryPolicy struct {
+	initialDelay   time.Duration
+	multiplier     float64
+	jitter         float64
+	maxDelay       time.Duration
+	maxAttempts    int
+	maxElapsedTime time.Duration
+	onExhausted    RetryExhaustedAction
+	retries        uint64
+	exhausted      uint64
+	mutRand        sync.Mutex
+	rand           *rand.Rand
+}
+
+// NewRetryPolicy creates a new retry policy
+func NewRetryPolicy(args *ArgsRetryPolicy) (*retryPolicy, error) {
+	if args == nil {
+		return nil, ErrNilArguments
+	}
+	if args.InitialDelay <= 0 {
+		return nil, ErrInvalidRetryDelay
+	}
+	if args.Multiplier < 1 {
+		return nil, ErrInvalidRetryMultiplier
+	}
+	if args.Jitter < 0 || args.Jitter > 1 {
+		return nil, ErrInvalidRetryJitter
+	}
+	if args.MaxDelay < 0 || (args.MaxDelay > 0 && args.MaxDelay < args.InitialDelay) {
+		return nil, ErrInvalidRetryDelay
+	}
+	if args.MaxAttempts < 0 {
+		return nil, ErrInvalidMaxAttempts
+	}
+	if args.MaxElapsedTime < 0 {
+		return nil, ErrInvalidMaxElapsedTime
+	}
+	if !IsRetryExhaustedActionSupported(args.OnExhausted) {
+		return nil, ErrUnsupportedRetryExhaustedAction
+	}
+
+	onExhausted := args.OnExhausted
+	if len(onExhausted) == 0 {
+		onExhausted = RetryExhaustedWait
+	}
+
+	return &retryPolicy{
+		initialDelay:   args.InitialDelay,
+		multiplier:     args.Multiplier,
+		jitter:         args.Jitter,
+		maxDelay:       args.MaxDelay,
+		maxAttempts:    args.MaxAttempts,
+		maxElapsedTime: args.MaxElapsedTime,
+		onExhausted:    onExhausted,
+		rand:           rand.New(rand.NewSource(time.Now().UnixNano())),
+	}, nil
+}
+
+// IsRetryExhaustedActionSupported returns true if the action is known. An empty action means RetryExhaustedWait
+func IsRetryExhaustedActionSupported(action RetryExhaustedAction) bool {
+	switch action {
+	case "", RetryExhaustedWait, RetryExhaustedError, RetryExhaustedSpill:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewBackoff returns the retry state of a new operation, whose first attempt is made now
+func (rp *retryPolicy) NewBackoff() Backoff {
+	return &backoff{
+		policy: rp,
+		start:  time.Now(),
+	}
+}
+
+// OnExhausted returns what shall be done with data which could not be delivered
+func (rp *retryPolicy) OnExhausted() RetryExhaustedAction {
+	return rp.onExhausted
+}
+
+// Retries returns the number of retries made by all operations using this policy
+func (rp *retryPolicy) Retries() uint64 {
+	return atomic.LoadUint64(&rp.retries)
+}
+
+// ExhaustedRetries returns the number of operations using this policy which ran out of retries
+func (rp *retryPolicy) ExhaustedRetries() uint64 {
+	return atomic.LoadUint64(&rp.exhausted)
+}
+
+// delay returns the delay before the given retry, jitter included
+func (rp *retryPolicy) delay(retry int) time.Duration {
+	delay := float64(rp.initialDelay) * math.Pow(rp.multiplier, float64(retry-1))
+	if rp.maxDelay > 0 {
+		delay = math.Min(delay, float64(rp.maxDelay))
+	}
+	delay = math.Min(delay, float64(maxRetryDelay))
+
+	if rp.jitter > 0 {
+		rp.mutRand.Lock()
+		random := rp.rand.Float64()
+		rp.mutRand.Unlock()
+
+		delay += delay * rp.jitter * (2*random - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (rp *retryPolicy) IsInterfaceNil() bool {
+	return rp == nil
+}
+
+type backoff struct {
+	policy    *retryPolicy
+	start     time.Time
+	retries   int
+	exhausted bool
+}
+
+// NextDelay returns the time to wait before the next attempt. It returns false if no other attempt shall be
+// made, which only happens if the policy does not keep waiting once retries are exhausted. Waiting retries keep
+// increasing the delay, up to the maximum one
+func (b *backoff) NextDelay() (time.Duration, bool) {
+	delay := b.policy.delay(b.retries + 1)
+	if !b.exhausted && b.isExhausted(delay) {
+		b.exhausted = true
+		atomic.AddUint64(&b.policy.exhausted, 1)
+
+		if b.policy.onExhausted == RetryExhaustedWait {
+			log.Warn("retries exhausted, waiting indefinitely", "retries", b.retries, "elapsed", time.Since(b.start))
+		}
+	}
+	if b.exhausted && b.policy.onExhausted != RetryExhaustedWait {
+		return 0, false
+	}
+
+	b.retries++
+	atomic.AddUint64(&b.policy.retries, 1)
+
+	return delay, true
+}
+
+// Retries returns the number of retries made until now
+func (b *backoff) Retries() int {
+	return b.retries
+}
+
+func (b *backoff) isExhausted(nextDelay time.Duration) bool {
+	if b.policy.maxAttempts > 0 && b.retries+1 >= b.policy.maxAttempts {
+		return true
+	}
+
+	return b.policy.maxElapsedTime > 0 && time.Since(b.start)+nextDelay > b.policy.maxElapsedTime
+}
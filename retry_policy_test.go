@@ -0,0 +1,203 @@
+package covalent_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *covalent.ArgsRetryPolicy
+		expectedErr error
+	}{
+		{
+			args: func() *covalent.ArgsRetryPolicy {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args: func() *covalent.ArgsRetryPolicy {
+				return &covalent.ArgsRetryPolicy{InitialDelay: 0, Multiplier: 1}
+			},
+			expectedErr: covalent.ErrInvalidRetryDelay,
+		},
+		{
+			args: func() *covalent.ArgsRetryPolicy {
+				return &covalent.ArgsRetryPolicy{InitialDelay: time.Second, Multiplier: 0.5}
+			},
+			expectedErr: covalent.ErrInvalidRetryMultiplier,
+		},
+		{
+			args: func() *covalent.ArgsRetryPolicy {
+				return &covalent.ArgsRetryPolicy{InitialDelay: time.Second, Multiplier: 1, Jitter: 1.5}
+			},
+			expectedErr: covalent.ErrInvalidRetryJitter,
+		},
+		{
+			args: func() *covalent.ArgsRetryPolicy {
+				return &covalent.ArgsRetryPolicy{InitialDelay: time.Second, Multiplier: 1, MaxDelay: time.Millisecond}
+			},
+			expectedErr: covalent.ErrInvalidRetryDelay,
+		},
+		{
+			args: func() *covalent.ArgsRetryPolicy {
+				return &covalent.ArgsRetryPolicy{InitialDelay: time.Second, Multiplier: 1, MaxAttempts: -1}
+			},
+			expectedErr: covalent.ErrInvalidMaxAttempts,
+		},
+		{
+			args: func() *covalent.ArgsRetryPolicy {
+				return &covalent.ArgsRetryPolicy{InitialDelay: time.Second, Multiplier: 1, MaxElapsedTime: -1}
+			},
+			expectedErr: covalent.ErrInvalidMaxElapsedTime,
+		},
+		{
+			args: func() *covalent.ArgsRetryPolicy {
+				return &covalent.ArgsRetryPolicy{InitialDelay: time.Second, Multiplier: 1, OnExhausted: "drop"}
+			},
+			expectedErr: covalent.ErrUnsupportedRetryExhaustedAction,
+		},
+		{
+			args: func() *covalent.ArgsRetryPolicy {
+				return &covalent.ArgsRetryPolicy{InitialDelay: time.Second, Multiplier: 2, Jitter: 0.2}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := covalent.NewRetryPolicy(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(instance))
+	}
+}
+
+func TestRetryPolicy_NextDelay_ExpectExponentialDelaysUpToMaxDelay(t *testing.T) {
+	t.Parallel()
+
+	rp, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+		InitialDelay: time.Millisecond * 100,
+		Multiplier:   2,
+		MaxDelay:     time.Millisecond * 500,
+	})
+	require.Equal(t, covalent.RetryExhaustedWait, rp.OnExhausted())
+
+	backoff := rp.NewBackoff()
+	expectedDelays := []time.Duration{100, 200, 400, 500, 500}
+	for _, expectedDelay := range expectedDelays {
+		delay, canRetry := backoff.NextDelay()
+		require.True(t, canRetry)
+		require.Equal(t, expectedDelay*time.Millisecond, delay)
+	}
+
+	require.Equal(t, len(expectedDelays), backoff.Retries())
+	require.Equal(t, uint64(len(expectedDelays)), rp.Retries())
+	require.Equal(t, uint64(0), rp.ExhaustedRetries())
+}
+
+func TestRetryPolicy_NextDelay_WithJitter_ExpectDelaysWithinBounds(t *testing.T) {
+	t.Parallel()
+
+	rp, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+		InitialDelay: time.Second,
+		Multiplier:   1,
+		Jitter:       0.25,
+	})
+
+	backoff := rp.NewBackoff()
+	differentDelays := make(map[time.Duration]struct{})
+	for i := 0; i < 100; i++ {
+		delay, _ := backoff.NextDelay()
+		require.GreaterOrEqual(t, delay, time.Millisecond*750)
+		require.LessOrEqual(t, delay, time.Millisecond*1250)
+		differentDelays[delay] = struct{}{}
+	}
+
+	require.Greater(t, len(differentDelays), 1)
+}
+
+func TestRetryPolicy_NextDelay_MaxAttemptsReached(t *testing.T) {
+	t.Parallel()
+
+	for _, action := range []covalent.RetryExhaustedAction{covalent.RetryExhaustedError, covalent.RetryExhaustedSpill} {
+		rp, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+			InitialDelay: time.Millisecond,
+			Multiplier:   1,
+			MaxAttempts:  3,
+			OnExhausted:  action,
+		})
+
+		for i := 0; i < 2; i++ {
+			backoff := rp.NewBackoff()
+
+			// first attempt is not a retry, so only two delays are allowed
+			_, canRetry := backoff.NextDelay()
+			require.True(t, canRetry)
+			_, canRetry = backoff.NextDelay()
+			require.True(t, canRetry)
+			_, canRetry = backoff.NextDelay()
+			require.False(t, canRetry)
+			_, canRetry = backoff.NextDelay()
+			require.False(t, canRetry)
+			require.Equal(t, 2, backoff.Retries())
+		}
+
+		require.Equal(t, uint64(4), rp.Retries())
+		require.Equal(t, uint64(2), rp.ExhaustedRetries())
+	}
+}
+
+func TestRetryPolicy_NextDelay_MaxElapsedTimeReached(t *testing.T) {
+	t.Parallel()
+
+	rp, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+		InitialDelay:   time.Millisecond * 40,
+		Multiplier:     1,
+		MaxElapsedTime: time.Millisecond * 100,
+		OnExhausted:    covalent.RetryExhaustedError,
+	})
+
+	backoff := rp.NewBackoff()
+	retries := 0
+	for {
+		delay, canRetry := backoff.NextDelay()
+		if !canRetry {
+			break
+		}
+		retries++
+		time.Sleep(delay)
+	}
+
+	require.Equal(t, 2, retries)
+	require.Equal(t, uint64(1), rp.ExhaustedRetries())
+}
+
+func TestRetryPolicy_NextDelay_WaitWhenExhausted_ExpectRetriesContinued(t *testing.T) {
+	t.Parallel()
+
+	rp, _ := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+		InitialDelay: time.Millisecond,
+		Multiplier:   10,
+		MaxDelay:     time.Millisecond * 50,
+		MaxAttempts:  2,
+		OnExhausted:  covalent.RetryExhaustedWait,
+	})
+
+	backoff := rp.NewBackoff()
+	expectedDelays := []time.Duration{1, 10, 50, 50}
+	for _, expectedDelay := range expectedDelays {
+		delay, canRetry := backoff.NextDelay()
+		require.True(t, canRetry)
+		require.Equal(t, expectedDelay*time.Millisecond, delay)
+	}
+
+	require.Equal(t, uint64(4), rp.Retries())
+	require.Equal(t, uint64(1), rp.ExhaustedRetries())
+}
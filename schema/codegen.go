@@ -1,2 +1,3 @@
-//go:generate codegen --schema block.numbat.avsc --out schema.go
+//go:generate go run ./streamgen --out stream.numbat.avsc block.numbat.avsc revert.numbat.avsc finalized.numbat.avsc failure.numbat.avsc rounds.numbat.avsc validators.numbat.avsc ratings.numbat.avsc accounts.numbat.avsc
+//go:generate codegen --schema block.numbat.avsc --schema envelope.numbat.avsc --schema failure.numbat.avsc --schema revert.numbat.avsc --schema finalized.numbat.avsc --schema rounds.numbat.avsc --schema validators.numbat.avsc --schema ratings.numbat.avsc --schema accounts.numbat.avsc --schema stream.numbat.avsc --out schema.go
 package schema
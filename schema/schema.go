@@ -49,16 +49,18 @@ type Block struct {
 	DeveloperFees         []byte
 	EpochStartBlock       bool
 	EpochStartInfo        *EpochStartInfo
+	ValidatorsPubKeys     [][]byte
 }
 
 func NewBlock() *Block {
 	return &Block{
-		Hash:            make([]byte, 32),
-		Validators:      make([]int64, 0),
-		PubKeysBitmap:   []byte{},
-		StateRootHash:   make([]byte, 32),
-		AccumulatedFees: []byte{},
-		DeveloperFees:   []byte{},
+		Hash:              make([]byte, 32),
+		Validators:        make([]int64, 0),
+		PubKeysBitmap:     []byte{},
+		StateRootHash:     make([]byte, 32),
+		AccumulatedFees:   []byte{},
+		DeveloperFees:     []byte{},
+		ValidatorsPubKeys: make([][]byte, 0),
 	}
 }
 
@@ -293,6 +295,253 @@ func (o *AccountBalanceUpdate) Schema() avro.Schema {
 	return _AccountBalanceUpdate_schema
 }
 
+type Envelope struct {
+	Version           int32
+	SequenceNumber    int64
+	SchemaFingerprint []byte
+	ChainID           []byte
+	ShardID           int32
+	Checksum          []byte
+	Payload           []byte
+}
+
+func NewEnvelope() *Envelope {
+	return &Envelope{
+		SchemaFingerprint: make([]byte, 8),
+		ChainID:           []byte{},
+		Checksum:          make([]byte, 4),
+		Payload:           []byte{},
+	}
+}
+
+func (o *Envelope) Schema() avro.Schema {
+	if _Envelope_schema_err != nil {
+		panic(_Envelope_schema_err)
+	}
+	return _Envelope_schema
+}
+
+type BlockProcessingFailed struct {
+	Hash    []byte
+	Nonce   int64
+	Round   int64
+	Epoch   int32
+	ShardID int32
+	Stage   string
+	Error   string
+}
+
+func NewBlockProcessingFailed() *BlockProcessingFailed {
+	return &BlockProcessingFailed{
+		Hash: []byte{},
+	}
+}
+
+func (o *BlockProcessingFailed) Schema() avro.Schema {
+	if _BlockProcessingFailed_schema_err != nil {
+		panic(_BlockProcessingFailed_schema_err)
+	}
+	return _BlockProcessingFailed_schema
+}
+
+type BlockRevert struct {
+	Hash    []byte
+	Nonce   int64
+	Round   int64
+	ShardID int32
+	Epoch   int32
+}
+
+func NewBlockRevert() *BlockRevert {
+	return &BlockRevert{
+		Hash: []byte{},
+	}
+}
+
+func (o *BlockRevert) Schema() avro.Schema {
+	if _BlockRevert_schema_err != nil {
+		panic(_BlockRevert_schema_err)
+	}
+	return _BlockRevert_schema
+}
+
+type BlockFinalized struct {
+	Hash    []byte
+	Nonce   int64
+	Round   int64
+	ShardID int32
+	Epoch   int32
+}
+
+func NewBlockFinalized() *BlockFinalized {
+	return &BlockFinalized{
+		Hash: []byte{},
+	}
+}
+
+func (o *BlockFinalized) Schema() avro.Schema {
+	if _BlockFinalized_schema_err != nil {
+		panic(_BlockFinalized_schema_err)
+	}
+	return _BlockFinalized_schema
+}
+
+type RoundsInfo struct {
+	Rounds []*RoundInfo
+}
+
+func NewRoundsInfo() *RoundsInfo {
+	return &RoundsInfo{
+		Rounds: make([]*RoundInfo, 0),
+	}
+}
+
+func (o *RoundsInfo) Schema() avro.Schema {
+	if _RoundsInfo_schema_err != nil {
+		panic(_RoundsInfo_schema_err)
+	}
+	return _RoundsInfo_schema
+}
+
+type RoundInfo struct {
+	Round            int64
+	SignersIndexes   []int64
+	BlockWasProposed bool
+	ShardID          int32
+	Epoch            int32
+	Timestamp        int64
+}
+
+func NewRoundInfo() *RoundInfo {
+	return &RoundInfo{
+		SignersIndexes: make([]int64, 0),
+	}
+}
+
+func (o *RoundInfo) Schema() avro.Schema {
+	if _RoundInfo_schema_err != nil {
+		panic(_RoundInfo_schema_err)
+	}
+	return _RoundInfo_schema
+}
+
+type EpochValidators struct {
+	Epoch      int32
+	ShardID    int32
+	PublicKeys [][]byte
+}
+
+func NewEpochValidators() *EpochValidators {
+	return &EpochValidators{
+		PublicKeys: make([][]byte, 0),
+	}
+}
+
+func (o *EpochValidators) Schema() avro.Schema {
+	if _EpochValidators_schema_err != nil {
+		panic(_EpochValidators_schema_err)
+	}
+	return _EpochValidators_schema
+}
+
+type ValidatorsRating struct {
+	Ratings []*ValidatorRating
+}
+
+func NewValidatorsRating() *ValidatorsRating {
+	return &ValidatorsRating{
+		Ratings: make([]*ValidatorRating, 0),
+	}
+}
+
+func (o *ValidatorsRating) Schema() avro.Schema {
+	if _ValidatorsRating_schema_err != nil {
+		panic(_ValidatorsRating_schema_err)
+	}
+	return _ValidatorsRating_schema
+}
+
+type ValidatorRating struct {
+	PublicKey string
+	Rating    float32
+	ShardID   int32
+	Epoch     int32
+}
+
+func NewValidatorRating() *ValidatorRating {
+	return &ValidatorRating{}
+}
+
+func (o *ValidatorRating) Schema() avro.Schema {
+	if _ValidatorRating_schema_err != nil {
+		panic(_ValidatorRating_schema_err)
+	}
+	return _ValidatorRating_schema
+}
+
+type AccountsSnapshot struct {
+	Timestamp int64
+	Accounts  []*AccountSnapshot
+}
+
+func NewAccountsSnapshot() *AccountsSnapshot {
+	return &AccountsSnapshot{
+		Accounts: make([]*AccountSnapshot, 0),
+	}
+}
+
+func (o *AccountsSnapshot) Schema() avro.Schema {
+	if _AccountsSnapshot_schema_err != nil {
+		panic(_AccountsSnapshot_schema_err)
+	}
+	return _AccountsSnapshot_schema
+}
+
+type AccountSnapshot struct {
+	Address         []byte
+	Balance         []byte
+	Nonce           int64
+	UserName        []byte
+	CodeHash        []byte
+	RootHash        []byte
+	Owner           []byte
+	DeveloperReward []byte
+}
+
+func NewAccountSnapshot() *AccountSnapshot {
+	return &AccountSnapshot{
+		Address:         []byte{},
+		Balance:         []byte{},
+		UserName:        []byte{},
+		CodeHash:        []byte{},
+		RootHash:        []byte{},
+		Owner:           []byte{},
+		DeveloperReward: []byte{},
+	}
+}
+
+func (o *AccountSnapshot) Schema() avro.Schema {
+	if _AccountSnapshot_schema_err != nil {
+		panic(_AccountSnapshot_schema_err)
+	}
+	return _AccountSnapshot_schema
+}
+
+type StreamMessage struct {
+	Payload interface{}
+}
+
+func NewStreamMessage() *StreamMessage {
+	return &StreamMessage{}
+}
+
+func (o *StreamMessage) Schema() avro.Schema {
+	if _StreamMessage_schema_err != nil {
+		panic(_StreamMessage_schema_err)
+	}
+	return _StreamMessage_schema
+}
+
 // Generated by codegen. Please do not modify.
 var _BlockResult_schema, _BlockResult_schema_err = avro.ParseSchema(`{
     "type": "record",
@@ -502,6 +751,14 @@ var _BlockResult_schema, _BlockResult_schema_err = avro.ParseSchema(`{
                                 ]
                             }
                         ]
+                    },
+                    {
+                        "name": "ValidatorsPubKeys",
+                        "default": [],
+                        "type": {
+                            "type": "array",
+                            "items": "bytes"
+                        }
                     }
                 ]
             }
@@ -843,6 +1100,7 @@ var _BlockResult_schema, _BlockResult_schema_err = avro.ParseSchema(`{
         },
         {
             "name": "StateChanges",
+            "default": [],
             "type": {
                 "type": "array",
                 "items": {
@@ -1074,6 +1332,14 @@ var _Block_schema, _Block_schema_err = avro.ParseSchema(`{
                     ]
                 }
             ]
+        },
+        {
+            "name": "ValidatorsPubKeys",
+            "default": [],
+            "type": {
+                "type": "array",
+                "items": "bytes"
+            }
         }
     ]
 }`)
@@ -1542,3 +1808,1247 @@ var _AccountBalanceUpdate_schema, _AccountBalanceUpdate_schema_err = avro.ParseS
         }
     ]
 }`)
+
+// Generated by codegen. Please do not modify.
+var _Envelope_schema, _Envelope_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "com.covalenthq.block.schema",
+    "name": "Envelope",
+    "fields": [
+        {
+            "name": "Version",
+            "type": "int"
+        },
+        {
+            "name": "SequenceNumber",
+            "type": "long"
+        },
+        {
+            "name": "SchemaFingerprint",
+            "type": {
+                "type": "fixed",
+                "size": 8,
+                "name": "fingerprint"
+            }
+        },
+        {
+            "name": "ChainID",
+            "type": "bytes"
+        },
+        {
+            "name": "ShardID",
+            "type": "int"
+        },
+        {
+            "name": "Checksum",
+            "type": {
+                "type": "fixed",
+                "size": 4,
+                "name": "checksum"
+            }
+        },
+        {
+            "name": "Payload",
+            "type": "bytes"
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _BlockProcessingFailed_schema, _BlockProcessingFailed_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "com.covalenthq.block.schema",
+    "name": "BlockProcessingFailed",
+    "fields": [
+        {
+            "name": "Hash",
+            "type": "bytes"
+        },
+        {
+            "name": "Nonce",
+            "type": "long"
+        },
+        {
+            "name": "Round",
+            "type": "long"
+        },
+        {
+            "name": "Epoch",
+            "type": "int"
+        },
+        {
+            "name": "ShardID",
+            "type": "int"
+        },
+        {
+            "name": "Stage",
+            "type": "string"
+        },
+        {
+            "name": "Error",
+            "type": "string"
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _BlockRevert_schema, _BlockRevert_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "com.covalenthq.block.schema",
+    "name": "BlockRevert",
+    "fields": [
+        {
+            "name": "Hash",
+            "type": "bytes"
+        },
+        {
+            "name": "Nonce",
+            "type": "long"
+        },
+        {
+            "name": "Round",
+            "type": "long"
+        },
+        {
+            "name": "ShardID",
+            "type": "int"
+        },
+        {
+            "name": "Epoch",
+            "type": "int"
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _BlockFinalized_schema, _BlockFinalized_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "com.covalenthq.block.schema",
+    "name": "BlockFinalized",
+    "fields": [
+        {
+            "name": "Hash",
+            "type": "bytes"
+        },
+        {
+            "name": "Nonce",
+            "type": "long"
+        },
+        {
+            "name": "Round",
+            "type": "long"
+        },
+        {
+            "name": "ShardID",
+            "type": "int"
+        },
+        {
+            "name": "Epoch",
+            "type": "int"
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _RoundsInfo_schema, _RoundsInfo_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "com.covalenthq.block.schema",
+    "name": "RoundsInfo",
+    "fields": [
+        {
+            "name": "Rounds",
+            "type": {
+                "type": "array",
+                "items": {
+                    "type": "record",
+                    "name": "RoundInfo",
+                    "fields": [
+                        {
+                            "name": "Round",
+                            "type": "long"
+                        },
+                        {
+                            "name": "SignersIndexes",
+                            "type": {
+                                "type": "array",
+                                "items": "long"
+                            }
+                        },
+                        {
+                            "name": "BlockWasProposed",
+                            "type": "boolean"
+                        },
+                        {
+                            "name": "ShardID",
+                            "type": "int"
+                        },
+                        {
+                            "name": "Epoch",
+                            "type": "int"
+                        },
+                        {
+                            "name": "Timestamp",
+                            "type": "long"
+                        }
+                    ]
+                }
+            }
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _RoundInfo_schema, _RoundInfo_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "name": "RoundInfo",
+    "fields": [
+        {
+            "name": "Round",
+            "type": "long"
+        },
+        {
+            "name": "SignersIndexes",
+            "type": {
+                "type": "array",
+                "items": "long"
+            }
+        },
+        {
+            "name": "BlockWasProposed",
+            "type": "boolean"
+        },
+        {
+            "name": "ShardID",
+            "type": "int"
+        },
+        {
+            "name": "Epoch",
+            "type": "int"
+        },
+        {
+            "name": "Timestamp",
+            "type": "long"
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _EpochValidators_schema, _EpochValidators_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "com.covalenthq.block.schema",
+    "name": "EpochValidators",
+    "fields": [
+        {
+            "name": "Epoch",
+            "type": "int"
+        },
+        {
+            "name": "ShardID",
+            "type": "int"
+        },
+        {
+            "name": "PublicKeys",
+            "type": {
+                "type": "array",
+                "items": "bytes"
+            }
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _ValidatorsRating_schema, _ValidatorsRating_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "com.covalenthq.block.schema",
+    "name": "ValidatorsRating",
+    "fields": [
+        {
+            "name": "Ratings",
+            "type": {
+                "type": "array",
+                "items": {
+                    "type": "record",
+                    "name": "ValidatorRating",
+                    "fields": [
+                        {
+                            "name": "PublicKey",
+                            "type": "string"
+                        },
+                        {
+                            "name": "Rating",
+                            "type": "float"
+                        },
+                        {
+                            "name": "ShardID",
+                            "type": "int"
+                        },
+                        {
+                            "name": "Epoch",
+                            "type": "int"
+                        }
+                    ]
+                }
+            }
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _ValidatorRating_schema, _ValidatorRating_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "name": "ValidatorRating",
+    "fields": [
+        {
+            "name": "PublicKey",
+            "type": "string"
+        },
+        {
+            "name": "Rating",
+            "type": "float"
+        },
+        {
+            "name": "ShardID",
+            "type": "int"
+        },
+        {
+            "name": "Epoch",
+            "type": "int"
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _AccountsSnapshot_schema, _AccountsSnapshot_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "com.covalenthq.block.schema",
+    "name": "AccountsSnapshot",
+    "fields": [
+        {
+            "name": "Timestamp",
+            "type": "long"
+        },
+        {
+            "name": "Accounts",
+            "type": {
+                "type": "array",
+                "items": {
+                    "type": "record",
+                    "name": "AccountSnapshot",
+                    "fields": [
+                        {
+                            "name": "Address",
+                            "type": "bytes"
+                        },
+                        {
+                            "name": "Balance",
+                            "type": "bytes"
+                        },
+                        {
+                            "name": "Nonce",
+                            "type": "long"
+                        },
+                        {
+                            "name": "UserName",
+                            "type": "bytes"
+                        },
+                        {
+                            "name": "CodeHash",
+                            "type": "bytes"
+                        },
+                        {
+                            "name": "RootHash",
+                            "type": "bytes"
+                        },
+                        {
+                            "name": "Owner",
+                            "type": "bytes"
+                        },
+                        {
+                            "name": "DeveloperReward",
+                            "type": "bytes"
+                        }
+                    ]
+                }
+            }
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _AccountSnapshot_schema, _AccountSnapshot_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "name": "AccountSnapshot",
+    "fields": [
+        {
+            "name": "Address",
+            "type": "bytes"
+        },
+        {
+            "name": "Balance",
+            "type": "bytes"
+        },
+        {
+            "name": "Nonce",
+            "type": "long"
+        },
+        {
+            "name": "UserName",
+            "type": "bytes"
+        },
+        {
+            "name": "CodeHash",
+            "type": "bytes"
+        },
+        {
+            "name": "RootHash",
+            "type": "bytes"
+        },
+        {
+            "name": "Owner",
+            "type": "bytes"
+        },
+        {
+            "name": "DeveloperReward",
+            "type": "bytes"
+        }
+    ]
+}`)
+
+// Generated by codegen. Please do not modify.
+var _StreamMessage_schema, _StreamMessage_schema_err = avro.ParseSchema(`{
+    "type": "record",
+    "namespace": "com.covalenthq.block.schema",
+    "name": "StreamMessage",
+    "fields": [
+        {
+            "name": "Payload",
+            "type": [
+                {
+                    "type": "record",
+                    "name": "BlockResult",
+                    "fields": [
+                        {
+                            "name": "Block",
+                            "type": {
+                                "type": "record",
+                                "name": "Block",
+                                "fields": [
+                                    {
+                                        "name": "Nonce",
+                                        "type": "long"
+                                    },
+                                    {
+                                        "name": "Round",
+                                        "type": "long"
+                                    },
+                                    {
+                                        "name": "Epoch",
+                                        "type": "int"
+                                    },
+                                    {
+                                        "name": "Hash",
+                                        "type": {
+                                            "type": "fixed",
+                                            "size": 32,
+                                            "name": "hash"
+                                        }
+                                    },
+                                    {
+                                        "name": "MiniBlocks",
+                                        "default": null,
+                                        "type": [
+                                            "null",
+                                            {
+                                                "type": "array",
+                                                "items": {
+                                                    "type": "record",
+                                                    "name": "MiniBlock",
+                                                    "fields": [
+                                                        {
+                                                            "name": "Hash",
+                                                            "type": {
+                                                                "type": "fixed",
+                                                                "size": 32,
+                                                                "name": "hash"
+                                                            }
+                                                        },
+                                                        {
+                                                            "name": "SenderShardID",
+                                                            "type": "int"
+                                                        },
+                                                        {
+                                                            "name": "ReceiverShardID",
+                                                            "type": "int"
+                                                        },
+                                                        {
+                                                            "name": "Type",
+                                                            "type": "int"
+                                                        },
+                                                        {
+                                                            "name": "Timestamp",
+                                                            "type": "long"
+                                                        },
+                                                        {
+                                                            "name": "TxHashes",
+                                                            "type": {
+                                                                "type": "array",
+                                                                "items": "bytes"
+                                                            }
+                                                        }
+                                                    ]
+                                                }
+                                            }
+                                        ]
+                                    },
+                                    {
+                                        "name": "NotarizedBlocksHashes",
+                                        "default": null,
+                                        "type": [
+                                            "null",
+                                            {
+                                                "type": "array",
+                                                "items": {
+                                                    "type": "fixed",
+                                                    "size": 32,
+                                                    "name": "hash"
+                                                }
+                                            }
+                                        ]
+                                    },
+                                    {
+                                        "name": "Proposer",
+                                        "type": "long"
+                                    },
+                                    {
+                                        "name": "Validators",
+                                        "type": {
+                                            "type": "array",
+                                            "items": "long"
+                                        }
+                                    },
+                                    {
+                                        "name": "PubKeysBitmap",
+                                        "type": "bytes"
+                                    },
+                                    {
+                                        "name": "Size",
+                                        "type": "long"
+                                    },
+                                    {
+                                        "name": "Timestamp",
+                                        "type": "long"
+                                    },
+                                    {
+                                        "name": "StateRootHash",
+                                        "type": {
+                                            "type": "fixed",
+                                            "size": 32,
+                                            "name": "hash"
+                                        }
+                                    },
+                                    {
+                                        "name": "PrevHash",
+                                        "default": null,
+                                        "type": [
+                                            "null",
+                                            {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        ]
+                                    },
+                                    {
+                                        "name": "ShardID",
+                                        "type": "int"
+                                    },
+                                    {
+                                        "name": "TxCount",
+                                        "type": "int"
+                                    },
+                                    {
+                                        "name": "AccumulatedFees",
+                                        "type": "bytes"
+                                    },
+                                    {
+                                        "name": "DeveloperFees",
+                                        "type": "bytes"
+                                    },
+                                    {
+                                        "name": "EpochStartBlock",
+                                        "type": "boolean"
+                                    },
+                                    {
+                                        "name": "EpochStartInfo",
+                                        "default": null,
+                                        "type": [
+                                            "null",
+                                            {
+                                                "type": "record",
+                                                "name": "EpochStartInfo",
+                                                "fields": [
+                                                    {
+                                                        "name": "TotalSupply",
+                                                        "type": "bytes"
+                                                    },
+                                                    {
+                                                        "name": "TotalToDistribute",
+                                                        "type": "bytes"
+                                                    },
+                                                    {
+                                                        "name": "TotalNewlyMinted",
+                                                        "type": "bytes"
+                                                    },
+                                                    {
+                                                        "name": "RewardsPerBlock",
+                                                        "type": "bytes"
+                                                    },
+                                                    {
+                                                        "name": "RewardsForProtocolSustainability",
+                                                        "type": "bytes"
+                                                    },
+                                                    {
+                                                        "name": "NodePrice",
+                                                        "type": "bytes"
+                                                    },
+                                                    {
+                                                        "name": "PrevEpochStartRound",
+                                                        "type": "int"
+                                                    },
+                                                    {
+                                                        "name": "PrevEpochStartHash",
+                                                        "default": null,
+                                                        "type": [
+                                                            "null",
+                                                            {
+                                                                "type": "fixed",
+                                                                "size": 32,
+                                                                "name": "hash"
+                                                            }
+                                                        ]
+                                                    }
+                                                ]
+                                            }
+                                        ]
+                                    },
+                                    {
+                                        "name": "ValidatorsPubKeys",
+                                        "default": [],
+                                        "type": {
+                                            "type": "array",
+                                            "items": "bytes"
+                                        }
+                                    }
+                                ]
+                            }
+                        },
+                        {
+                            "name": "Transactions",
+                            "type": {
+                                "type": "array",
+                                "items": {
+                                    "type": "record",
+                                    "name": "Transaction",
+                                    "fields": [
+                                        {
+                                            "name": "Hash",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        },
+                                        {
+                                            "name": "MiniBlockHash",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        },
+                                        {
+                                            "name": "BlockHash",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        },
+                                        {
+                                            "name": "Nonce",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "Round",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "Value",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Receiver",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 62,
+                                                "name": "address"
+                                            }
+                                        },
+                                        {
+                                            "name": "Sender",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 62,
+                                                "name": "address"
+                                            }
+                                        },
+                                        {
+                                            "name": "ReceiverShard",
+                                            "type": "int"
+                                        },
+                                        {
+                                            "name": "SenderShard",
+                                            "type": "int"
+                                        },
+                                        {
+                                            "name": "GasPrice",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "GasLimit",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "Data",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Signature",
+                                            "default": null,
+                                            "type": [
+                                                "null",
+                                                {
+                                                    "type": "fixed",
+                                                    "size": 64,
+                                                    "name": "signature"
+                                                }
+                                            ]
+                                        },
+                                        {
+                                            "name": "Timestamp",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "SenderUserName",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "ReceiverUserName",
+                                            "type": "bytes"
+                                        }
+                                    ]
+                                }
+                            }
+                        },
+                        {
+                            "name": "SCResults",
+                            "type": {
+                                "type": "array",
+                                "items": {
+                                    "type": "record",
+                                    "name": "SCResult",
+                                    "fields": [
+                                        {
+                                            "name": "Hash",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        },
+                                        {
+                                            "name": "Nonce",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "GasLimit",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "GasPrice",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "Value",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Sender",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 62,
+                                                "name": "address"
+                                            }
+                                        },
+                                        {
+                                            "name": "Receiver",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 62,
+                                                "name": "address"
+                                            }
+                                        },
+                                        {
+                                            "name": "RelayerAddr",
+                                            "default": null,
+                                            "type": [
+                                                "null",
+                                                {
+                                                    "type": "fixed",
+                                                    "size": 62,
+                                                    "name": "address"
+                                                }
+                                            ]
+                                        },
+                                        {
+                                            "name": "RelayedValue",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Code",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Data",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "PrevTxHash",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        },
+                                        {
+                                            "name": "OriginalTxHash",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        },
+                                        {
+                                            "name": "CallType",
+                                            "type": "int"
+                                        },
+                                        {
+                                            "name": "CodeMetadata",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "ReturnMessage",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Timestamp",
+                                            "type": "long"
+                                        }
+                                    ]
+                                }
+                            }
+                        },
+                        {
+                            "name": "Receipts",
+                            "type": {
+                                "type": "array",
+                                "items": {
+                                    "type": "record",
+                                    "name": "Receipt",
+                                    "fields": [
+                                        {
+                                            "name": "Hash",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        },
+                                        {
+                                            "name": "Value",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Sender",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 62,
+                                                "name": "address"
+                                            }
+                                        },
+                                        {
+                                            "name": "Data",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "TxHash",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        },
+                                        {
+                                            "name": "Timestamp",
+                                            "type": "long"
+                                        }
+                                    ]
+                                }
+                            }
+                        },
+                        {
+                            "name": "Logs",
+                            "type": {
+                                "type": "array",
+                                "items": {
+                                    "type": "record",
+                                    "name": "Log",
+                                    "fields": [
+                                        {
+                                            "name": "ID",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 32,
+                                                "name": "hash"
+                                            }
+                                        },
+                                        {
+                                            "name": "Address",
+                                            "default": null,
+                                            "type": [
+                                                "null",
+                                                {
+                                                    "type": "fixed",
+                                                    "size": 62,
+                                                    "name": "address"
+                                                }
+                                            ]
+                                        },
+                                        {
+                                            "name": "Events",
+                                            "type": {
+                                                "type": "array",
+                                                "items": {
+                                                    "type": "record",
+                                                    "name": "Event",
+                                                    "fields": [
+                                                        {
+                                                            "name": "Address",
+                                                            "default": null,
+                                                            "type": [
+                                                                "null",
+                                                                {
+                                                                    "type": "fixed",
+                                                                    "size": 62,
+                                                                    "name": "address"
+                                                                }
+                                                            ]
+                                                        },
+                                                        {
+                                                            "name": "Identifier",
+                                                            "type": "bytes"
+                                                        },
+                                                        {
+                                                            "name": "Topics",
+                                                            "type": {
+                                                                "type": "array",
+                                                                "items": "bytes"
+                                                            }
+                                                        },
+                                                        {
+                                                            "name": "Data",
+                                                            "type": "bytes"
+                                                        }
+                                                    ]
+                                                }
+                                            }
+                                        }
+                                    ]
+                                }
+                            }
+                        },
+                        {
+                            "name": "StateChanges",
+                            "default": [],
+                            "type": {
+                                "type": "array",
+                                "items": {
+                                    "type": "record",
+                                    "name": "AccountBalanceUpdate",
+                                    "fields": [
+                                        {
+                                            "name": "Address",
+                                            "type": {
+                                                "type": "fixed",
+                                                "size": 62,
+                                                "name": "address"
+                                            }
+                                        },
+                                        {
+                                            "name": "Balance",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Nonce",
+                                            "type": "long"
+                                        }
+                                    ]
+                                }
+                            }
+                        }
+                    ]
+                },
+                {
+                    "type": "record",
+                    "name": "BlockRevert",
+                    "fields": [
+                        {
+                            "name": "Hash",
+                            "type": "bytes"
+                        },
+                        {
+                            "name": "Nonce",
+                            "type": "long"
+                        },
+                        {
+                            "name": "Round",
+                            "type": "long"
+                        },
+                        {
+                            "name": "ShardID",
+                            "type": "int"
+                        },
+                        {
+                            "name": "Epoch",
+                            "type": "int"
+                        }
+                    ]
+                },
+                {
+                    "type": "record",
+                    "name": "BlockFinalized",
+                    "fields": [
+                        {
+                            "name": "Hash",
+                            "type": "bytes"
+                        },
+                        {
+                            "name": "Nonce",
+                            "type": "long"
+                        },
+                        {
+                            "name": "Round",
+                            "type": "long"
+                        },
+                        {
+                            "name": "ShardID",
+                            "type": "int"
+                        },
+                        {
+                            "name": "Epoch",
+                            "type": "int"
+                        }
+                    ]
+                },
+                {
+                    "type": "record",
+                    "name": "BlockProcessingFailed",
+                    "fields": [
+                        {
+                            "name": "Hash",
+                            "type": "bytes"
+                        },
+                        {
+                            "name": "Nonce",
+                            "type": "long"
+                        },
+                        {
+                            "name": "Round",
+                            "type": "long"
+                        },
+                        {
+                            "name": "Epoch",
+                            "type": "int"
+                        },
+                        {
+                            "name": "ShardID",
+                            "type": "int"
+                        },
+                        {
+                            "name": "Stage",
+                            "type": "string"
+                        },
+                        {
+                            "name": "Error",
+                            "type": "string"
+                        }
+                    ]
+                },
+                {
+                    "type": "record",
+                    "name": "RoundsInfo",
+                    "fields": [
+                        {
+                            "name": "Rounds",
+                            "type": {
+                                "type": "array",
+                                "items": {
+                                    "type": "record",
+                                    "name": "RoundInfo",
+                                    "fields": [
+                                        {
+                                            "name": "Round",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "SignersIndexes",
+                                            "type": {
+                                                "type": "array",
+                                                "items": "long"
+                                            }
+                                        },
+                                        {
+                                            "name": "BlockWasProposed",
+                                            "type": "boolean"
+                                        },
+                                        {
+                                            "name": "ShardID",
+                                            "type": "int"
+                                        },
+                                        {
+                                            "name": "Epoch",
+                                            "type": "int"
+                                        },
+                                        {
+                                            "name": "Timestamp",
+                                            "type": "long"
+                                        }
+                                    ]
+                                }
+                            }
+                        }
+                    ]
+                },
+                {
+                    "type": "record",
+                    "name": "EpochValidators",
+                    "fields": [
+                        {
+                            "name": "Epoch",
+                            "type": "int"
+                        },
+                        {
+                            "name": "ShardID",
+                            "type": "int"
+                        },
+                        {
+                            "name": "PublicKeys",
+                            "type": {
+                                "type": "array",
+                                "items": "bytes"
+                            }
+                        }
+                    ]
+                },
+                {
+                    "type": "record",
+                    "name": "ValidatorsRating",
+                    "fields": [
+                        {
+                            "name": "Ratings",
+                            "type": {
+                                "type": "array",
+                                "items": {
+                                    "type": "record",
+                                    "name": "ValidatorRating",
+                                    "fields": [
+                                        {
+                                            "name": "PublicKey",
+                                            "type": "string"
+                                        },
+                                        {
+                                            "name": "Rating",
+                                            "type": "float"
+                                        },
+                                        {
+                                            "name": "ShardID",
+                                            "type": "int"
+                                        },
+                                        {
+                                            "name": "Epoch",
+                                            "type": "int"
+                                        }
+                                    ]
+                                }
+                            }
+                        }
+                    ]
+                },
+                {
+                    "type": "record",
+                    "name": "AccountsSnapshot",
+                    "fields": [
+                        {
+                            "name": "Timestamp",
+                            "type": "long"
+                        },
+                        {
+                            "name": "Accounts",
+                            "type": {
+                                "type": "array",
+                                "items": {
+                                    "type": "record",
+                                    "name": "AccountSnapshot",
+                                    "fields": [
+                                        {
+                                            "name": "Address",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Balance",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Nonce",
+                                            "type": "long"
+                                        },
+                                        {
+                                            "name": "UserName",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "CodeHash",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "RootHash",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "Owner",
+                                            "type": "bytes"
+                                        },
+                                        {
+                                            "name": "DeveloperReward",
+                                            "type": "bytes"
+                                        }
+                                    ]
+                                }
+                            }
+                        }
+                    ]
+                }
+            ]
+        }
+    ]
+}`)
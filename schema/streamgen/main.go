@@ -0,0 +1,150 @@
+// Command streamgen generates the stream message schema, whose payload is a union over all payload records. The code
+// generator resolves each schema file on its own, so the payload records are inlined from their own schema files, in
+// the given order, instead of being referenced by name
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	namespaceKey  = "namespace"
+	payloadIndent = "     "
+	memberIndent  = payloadIndent + " "
+)
+
+var errInvalidPayloadSchema = errors.New("invalid payload schema")
+
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+func main() {
+	out := flag.String("out", "stream.numbat.avsc", "generated stream message schema file")
+	flag.Parse()
+
+	content, err := generate(flag.Args())
+	if err == nil {
+		err = os.WriteFile(*out, content, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "streamgen:", err)
+		os.Exit(1)
+	}
+}
+
+// generate returns the stream message schema having as payload the records read from the given schema files. All
+// of them must share the same namespace, which becomes the one of the stream message
+func generate(payloadFiles []string) ([]byte, error) {
+	if len(payloadFiles) == 0 {
+		return nil, fmt.Errorf("%w: no payload schema file provided", errInvalidPayloadSchema)
+	}
+
+	namespace := ""
+	payloads := make([]string, 0, len(payloadFiles))
+	for _, fileName := range payloadFiles {
+		members, payloadNamespace, err := readPayload(fileName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fileName, err)
+		}
+		if len(namespace) == 0 {
+			namespace = payloadNamespace
+		}
+		if payloadNamespace != namespace {
+			return nil, fmt.Errorf("%w: %s has namespace %q instead of %q",
+				errInvalidPayloadSchema, fileName, payloadNamespace, namespace)
+		}
+
+		payloads = append(payloads, formatPayload(members))
+	}
+
+	buff := &bytes.Buffer{}
+	buff.WriteString("{\n")
+	buff.WriteString(" \"type\": \"record\",\n")
+	fmt.Fprintf(buff, " \"namespace\": %q,\n", namespace)
+	buff.WriteString(" \"name\": \"StreamMessage\",\n")
+	buff.WriteString(" \"fields\": [\n")
+	buff.WriteString("   {\"name\": \"Payload\", \"type\": [\n")
+	buff.WriteString(strings.Join(payloads, ",\n"))
+	buff.WriteString("\n   ]}\n")
+	buff.WriteString(" ]\n")
+	buff.WriteString("}\n")
+
+	if !json.Valid(buff.Bytes()) {
+		return nil, fmt.Errorf("%w: generated stream schema is not valid json", errInvalidPayloadSchema)
+	}
+
+	return buff.Bytes(), nil
+}
+
+// readPayload reads the members of the record schema from the file, in order and keeping their formatting. The
+// namespace is returned apart, since payload records inherit the one of the stream message
+func readPayload(fileName string) ([]*member, string, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, "", err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, "", err
+	}
+	if token != json.Delim('{') {
+		return nil, "", fmt.Errorf("%w: not a json object", errInvalidPayloadSchema)
+	}
+
+	namespace := ""
+	members := make([]*member, 0)
+	for decoder.More() {
+		token, err = decoder.Token()
+		if err != nil {
+			return nil, "", err
+		}
+		key, ok := token.(string)
+		if !ok {
+			return nil, "", fmt.Errorf("%w: unexpected token %v", errInvalidPayloadSchema, token)
+		}
+
+		var value json.RawMessage
+		err = decoder.Decode(&value)
+		if err != nil {
+			return nil, "", err
+		}
+		if key == namespaceKey {
+			err = json.Unmarshal(value, &namespace)
+			if err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+
+		members = append(members, &member{key: key, value: value})
+	}
+
+	for _, currMember := range members {
+		if currMember.key == "type" && string(currMember.value) == `"record"` {
+			return members, namespace, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("%w: not a record schema", errInvalidPayloadSchema)
+}
+
+// formatPayload writes the members of the payload record indented as a branch of the payload union
+func formatPayload(members []*member) string {
+	formattedMembers := make([]string, 0, len(members))
+	for _, currMember := range members {
+		value := strings.ReplaceAll(string(currMember.value), "\n", "\n"+payloadIndent)
+		formattedMembers = append(formattedMembers, fmt.Sprintf("%s%q: %s", memberIndent, currMember.key, value))
+	}
+
+	return payloadIndent + "{\n" + strings.Join(formattedMembers, ",\n") + "\n" + payloadIndent + "}"
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const generateDirective = "//go:generate go run ./streamgen "
+
+func TestGenerate_ExpectCommittedStreamSchema(t *testing.T) {
+	t.Parallel()
+
+	out, payloadFiles := readGenerateDirective(t)
+
+	generated, err := generate(payloadFiles)
+	require.Nil(t, err)
+
+	committed, err := os.ReadFile(out)
+	require.Nil(t, err)
+	require.Equal(t, string(committed), string(generated), "stream schema out of date, run go generate in schema")
+}
+
+func TestGenerate_InvalidPayloads_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile := func(name string, content string) string {
+		fileName := filepath.Join(dir, name)
+		require.Nil(t, os.WriteFile(fileName, []byte(content), 0644))
+		return fileName
+	}
+
+	first := writeFile("first.avsc", `{"type": "record", "namespace": "first", "name": "First", "fields": []}`)
+	otherNamespace := writeFile("other.avsc", `{"type": "record", "namespace": "other", "name": "Other", "fields": []}`)
+	notRecord := writeFile("enum.avsc", `{"type": "enum", "namespace": "first", "name": "Kind", "symbols": ["A"]}`)
+	notObject := writeFile("array.avsc", `["null", "long"]`)
+
+	tests := []struct {
+		name         string
+		payloadFiles []string
+	}{
+		{name: "no payload", payloadFiles: nil},
+		{name: "different namespaces", payloadFiles: []string{first, otherNamespace}},
+		{name: "not a record", payloadFiles: []string{first, notRecord}},
+		{name: "not an object", payloadFiles: []string{notObject}},
+	}
+
+	for _, tt := range tests {
+		_, err := generate(tt.payloadFiles)
+		require.True(t, errors.Is(err, errInvalidPayloadSchema), tt.name)
+	}
+
+	_, err := generate([]string{filepath.Join(dir, "missing.avsc")})
+	require.NotNil(t, err)
+}
+
+// readGenerateDirective returns the output and the payload files of the go:generate directive running streamgen
+func readGenerateDirective(t *testing.T) (string, []string) {
+	content, err := os.ReadFile(filepath.Join("..", "codegen.go"))
+	require.Nil(t, err)
+
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.HasPrefix(line, generateDirective) {
+			continue
+		}
+
+		args := strings.Fields(strings.TrimPrefix(line, generateDirective))
+		require.True(t, len(args) > 2)
+		require.Equal(t, "--out", args[0])
+
+		payloadFiles := make([]string, 0, len(args)-2)
+		for _, arg := range args[2:] {
+			payloadFiles = append(payloadFiles, filepath.Join("..", arg))
+		}
+
+		return filepath.Join("..", args[1]), payloadFiles
+	}
+
+	require.Fail(t, "streamgen directive not found")
+	return "", nil
+}
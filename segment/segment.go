@@ -0,0 +1,205 @@
+package segment
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	fileSuffix = ".log"
+
+	// RecordHeaderSize = 4 bytes body length + 4 bytes crc32 checksum of the body
+	RecordHeaderSize = 8
+)
+
+// Format defines the segments of a log: the prefix of their file names and the error returned when reading a
+// corrupted record. The encoding of the record bodies is left to the log
+type Format struct {
+	FilePrefix   string
+	ErrCorrupted error
+}
+
+// Segment is an append only file holding consecutive records of a log, named after the position of its first
+// record. Each record is made of its header, the body length and checksum, followed by the body
+type Segment struct {
+	format Format
+	first  uint64
+	file   *os.File
+	size   int64
+}
+
+func fileName(format Format, first uint64) string {
+	return fmt.Sprintf("%s%020d%s", format.FilePrefix, first, fileSuffix)
+}
+
+func parseFileName(format Format, name string) (uint64, bool) {
+	if !strings.HasPrefix(name, format.FilePrefix) || !strings.HasSuffix(name, fileSuffix) {
+		return 0, false
+	}
+
+	first, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, format.FilePrefix), fileSuffix), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return first, true
+}
+
+// List returns, in increasing order, the first positions of all segments of the given format found in the directory
+func List(directory string, format Format) ([]uint64, error) {
+	dirEntries, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	firstPositions := make([]uint64, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() {
+			continue
+		}
+
+		first, ok := parseFileName(format, dirEntry.Name())
+		if ok {
+			firstPositions = append(firstPositions, first)
+		}
+	}
+
+	sort.Slice(firstPositions, func(i, j int) bool {
+		return firstPositions[i] < firstPositions[j]
+	})
+
+	return firstPositions, nil
+}
+
+// Create creates an empty segment, truncating any existing file with the same name
+func Create(directory string, format Format, first uint64) (*Segment, error) {
+	file, err := os.OpenFile(filepath.Join(directory, fileName(format, first)), os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Segment{
+		format: format,
+		first:  first,
+		file:   file,
+	}, nil
+}
+
+// Open opens an existing segment for both reading and appending
+func Open(directory string, format Format, first uint64) (*Segment, error) {
+	file, err := os.OpenFile(filepath.Join(directory, fileName(format, first)), os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &Segment{
+		format: format,
+		first:  first,
+		file:   file,
+		size:   info.Size(),
+	}, nil
+}
+
+// First returns the position of the first record of the segment
+func (s *Segment) First() uint64 {
+	return s.first
+}
+
+// Size returns the size of all records of the segment
+func (s *Segment) Size() int64 {
+	return s.size
+}
+
+// Name returns the file name of the segment
+func (s *Segment) Name() string {
+	return s.file.Name()
+}
+
+// Append writes the body as a new record at the end of the segment and syncs it to disk. It returns the offset
+// of the record
+func (s *Segment) Append(body []byte) (int64, error) {
+	record := make([]byte, RecordHeaderSize+len(body))
+	binary.BigEndian.PutUint32(record[0:4], uint32(len(body)))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(body))
+	copy(record[RecordHeaderSize:], body)
+
+	offset := s.size
+	_, err := s.file.WriteAt(record, offset)
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.file.Sync()
+	if err != nil {
+		return 0, err
+	}
+
+	s.size += int64(len(record))
+	return offset, nil
+}
+
+// Read returns the body of the record found at the given offset, together with the size of the whole record.
+// It returns the corrupted error of the format if the body does not match its checksum
+func (s *Segment) Read(offset int64) ([]byte, int64, error) {
+	header := make([]byte, RecordHeaderSize)
+	_, err := s.file.ReadAt(header, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	bodyLen := int64(binary.BigEndian.Uint32(header[:4]))
+	if offset+RecordHeaderSize+bodyLen > s.size {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	body := make([]byte, bodyLen)
+	_, err = s.file.ReadAt(body, offset+RecordHeaderSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, 0, s.format.ErrCorrupted
+	}
+
+	return body, RecordHeaderSize + bodyLen, nil
+}
+
+// Truncate drops everything written after the given offset, used to discard partially written records
+func (s *Segment) Truncate(offset int64) error {
+	err := s.file.Truncate(offset)
+	if err != nil {
+		return err
+	}
+
+	s.size = offset
+	return s.file.Sync()
+}
+
+// Close closes the segment file
+func (s *Segment) Close() error {
+	return s.file.Close()
+}
+
+// Remove closes the segment file and deletes it
+func (s *Segment) Remove() error {
+	name := s.file.Name()
+	err := s.file.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
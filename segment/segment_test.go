@@ -0,0 +1,140 @@
+package segment_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index/segment"
+	"github.com/stretchr/testify/require"
+)
+
+var errCorrupted = errors.New("corrupted test entry")
+
+var testFormat = segment.Format{
+	FilePrefix:   "test-",
+	ErrCorrupted: errCorrupted,
+}
+
+func TestSegment_AppendAndRead(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	seg, err := segment.Create(directory, testFormat, 7)
+	require.Nil(t, err)
+	require.Equal(t, uint64(7), seg.First())
+	require.Equal(t, filepath.Join(directory, "test-00000000000000000007.log"), seg.Name())
+
+	bodies := [][]byte{[]byte("first"), {}, []byte("third body")}
+	offsets := make([]int64, 0, len(bodies))
+	for _, body := range bodies {
+		offset, errAppend := seg.Append(body)
+		require.Nil(t, errAppend)
+		offsets = append(offsets, offset)
+	}
+	require.Nil(t, seg.Close())
+
+	seg, err = segment.Open(directory, testFormat, 7)
+	require.Nil(t, err)
+	defer func() {
+		_ = seg.Close()
+	}()
+
+	offset := int64(0)
+	for idx, body := range bodies {
+		require.Equal(t, offsets[idx], offset)
+
+		readBody, recordSize, errRead := seg.Read(offset)
+		require.Nil(t, errRead)
+		require.Equal(t, body, readBody)
+		require.Equal(t, int64(segment.RecordHeaderSize+len(body)), recordSize)
+
+		offset += recordSize
+	}
+	require.Equal(t, seg.Size(), offset)
+}
+
+func TestSegment_Read_CorruptedOrPartialRecord(t *testing.T) {
+	t.Parallel()
+
+	t.Run("corrupted body, expect format error", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		seg, _ := segment.Create(directory, testFormat, 0)
+		_, _ = seg.Append([]byte("body"))
+		require.Nil(t, seg.Close())
+
+		name := filepath.Join(directory, "test-00000000000000000000.log")
+		content, _ := os.ReadFile(name)
+		content[len(content)-1] ^= 0xff
+		require.Nil(t, os.WriteFile(name, content, 0644))
+
+		seg, _ = segment.Open(directory, testFormat, 0)
+		defer func() {
+			_ = seg.Close()
+		}()
+		_, _, err := seg.Read(0)
+		require.Equal(t, errCorrupted, err)
+	})
+
+	t.Run("partially written record, expect unexpected EOF and tail discarded by truncate", func(t *testing.T) {
+		t.Parallel()
+
+		directory := t.TempDir()
+		seg, _ := segment.Create(directory, testFormat, 0)
+		_, _ = seg.Append([]byte("complete"))
+		completeSize := seg.Size()
+		_, _ = seg.Append([]byte("partial"))
+		require.Nil(t, seg.Close())
+
+		name := filepath.Join(directory, "test-00000000000000000000.log")
+		require.Nil(t, os.Truncate(name, completeSize+segment.RecordHeaderSize+3))
+
+		seg, _ = segment.Open(directory, testFormat, 0)
+		defer func() {
+			_ = seg.Close()
+		}()
+		_, _, err := seg.Read(completeSize)
+		require.Equal(t, io.ErrUnexpectedEOF, err)
+
+		require.Nil(t, seg.Truncate(completeSize))
+		require.Equal(t, completeSize, seg.Size())
+		offset, err := seg.Append([]byte("next"))
+		require.Nil(t, err)
+		require.Equal(t, completeSize, offset)
+	})
+}
+
+func TestList_ExpectSortedSegmentsOfFormatOnly(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	for _, first := range []uint64{20, 3, 100} {
+		seg, err := segment.Create(directory, testFormat, first)
+		require.Nil(t, err)
+		require.Nil(t, seg.Close())
+	}
+	other, _ := segment.Create(directory, segment.Format{FilePrefix: "other-"}, 1)
+	require.Nil(t, other.Close())
+	require.Nil(t, os.WriteFile(filepath.Join(directory, "cursor"), []byte{0x1}, 0644))
+	require.Nil(t, os.Mkdir(filepath.Join(directory, "test-00000000000000000005.log"), 0755))
+
+	firstPositions, err := segment.List(directory, testFormat)
+	require.Nil(t, err)
+	require.Equal(t, []uint64{3, 20, 100}, firstPositions)
+}
+
+func TestSegment_Remove(t *testing.T) {
+	t.Parallel()
+
+	directory := t.TempDir()
+	seg, _ := segment.Create(directory, testFormat, 0)
+	_, _ = seg.Append([]byte("body"))
+
+	require.Nil(t, seg.Remove())
+	_, err := os.Stat(seg.Name())
+	require.True(t, os.IsNotExist(err))
+}
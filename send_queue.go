@@ -0,0 +1,157 @@
+package covalent
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/numbatx/gn-core/core/check"
+)
+
+// QueueFullPolicy defines what SaveBlock does when the send queue is full
+type QueueFullPolicy string
+
+const (
+	// QueueFullBlock waits until the sender takes a block out of the queue. It is the default policy
+	QueueFullBlock QueueFullPolicy = "block"
+	// QueueFullError returns ErrSendQueueFull to the node, without queueing the block
+	QueueFullError QueueFullPolicy = "error"
+	// QueueFullSpill publishes the block to the spill sink instead of queueing it
+	QueueFullSpill QueueFullPolicy = "spill"
+)
+
+// IsQueueFullPolicySupported returns true if the policy is known. An empty policy means QueueFullBlock
+func IsQueueFullPolicySupported(policy QueueFullPolicy) bool {
+	switch policy {
+	case "", QueueFullBlock, QueueFullError, QueueFullSpill:
+		return true
+	default:
+		return false
+	}
+}
+
+func checkSendQueueArgs(args *ArgsCovalentDataIndexer) error {
+	if args.SendQueueSize < 0 {
+		return ErrInvalidSendQueueSize
+	}
+	if !IsQueueFullPolicySupported(args.QueueFullPolicy) {
+		return ErrUnsupportedQueueFullPolicy
+	}
+	if args.QueueFullPolicy == QueueFullSpill && check.IfNil(args.SpillSink) {
+		return ErrNilSpillSink
+	}
+
+	return nil
+}
+
+// startSendQueue creates the send queue and starts the sender, if a queue size is provided
+func (ci *covalentIndexer) startSendQueue(args *ArgsCovalentDataIndexer) {
+	if args.SendQueueSize == 0 {
+		return
+	}
+
+	ci.queueFullPolicy = args.QueueFullPolicy
+	if len(ci.queueFullPolicy) == 0 {
+		ci.queueFullPolicy = QueueFullBlock
+	}
+	ci.spillSink = args.SpillSink
+	ci.sendQueue = make(chan *outgoingRecord, args.SendQueueSize)
+	ci.sendQueueLoopDone = make(chan struct{})
+	ci.sendQueueHalted = make(chan struct{})
+
+	go ci.processSendQueue()
+}
+
+// enqueue adds the processed record to the send queue, applying the queue full policy if there is no room left. It
+// returns the error which halted the send queue, if any
+func (ci *covalentIndexer) enqueue(item *outgoingRecord) error {
+	select {
+	case <-ci.sendQueueHalted:
+		return ci.sendQueueErr
+	default:
+	}
+
+	atomic.AddInt64(&ci.queuedBlocks, 1)
+	select {
+	case ci.sendQueue <- item:
+		return nil
+	default:
+	}
+
+	switch ci.queueFullPolicy {
+	case QueueFullError:
+		atomic.AddInt64(&ci.queuedBlocks, -1)
+		log.Warn("send queue is full, block rejected", "nonce", item.nonce)
+		return ErrSendQueueFull
+	case QueueFullSpill:
+		atomic.AddInt64(&ci.queuedBlocks, -1)
+		return ci.spillQueueOverflow(item)
+	default:
+		log.Debug("send queue is full, waiting for the sender", "nonce", item.nonce)
+	}
+
+	select {
+	case ci.sendQueue <- item:
+		return nil
+	case <-ci.sendQueueHalted:
+		atomic.AddInt64(&ci.queuedBlocks, -1)
+		return ci.sendQueueErr
+	case <-ci.ctx.Done():
+		atomic.AddInt64(&ci.queuedBlocks, -1)
+		return ErrIndexerClosed
+	}
+}
+
+// spillQueueOverflow publishes a block which does not fit in the send queue to the spill sink. Spilled blocks are
+// not part of the stream, so they are encoded with the sequence number of the next streamed block
+func (ci *covalentIndexer) spillQueueOverflow(item *outgoingRecord) error {
+	message, err := ci.createMessage(item.record, item.hash, item.nonce, item.round, item.epoch)
+	if err != nil {
+		return ci.encodeFailed(item, err, ci.spillQueueOverflow)
+	}
+
+	err = ci.spillSink.Publish(message)
+	if err != nil {
+		log.Error("could not spill block which does not fit in send queue", "error", err, "nonce", message.Nonce)
+		return err
+	}
+
+	log.Warn("send queue is full, block spilled", "nonce", message.Nonce)
+	return nil
+}
+
+// processSendQueue encodes and publishes queued records, in order, until the indexer is closed. Errors can not be
+// returned to the node anymore, so they are only logged, except for a block halting the delivery with the halt
+// failure policy: the sender stops and the error is returned by all following enqueues
+func (ci *covalentIndexer) processSendQueue() {
+	defer close(ci.sendQueueLoopDone)
+
+	for {
+		select {
+		case item := <-ci.sendQueue:
+			err := ci.send(item)
+			atomic.AddInt64(&ci.queuedBlocks, -1)
+			if isHaltError(err) {
+				ci.haltSendQueue(err, item)
+				return
+			}
+			if err != nil {
+				log.Error("could not deliver queued record", "error", err, "nonce", item.nonce)
+			}
+		case <-ci.ctx.Done():
+			return
+		}
+	}
+}
+
+// haltSendQueue stops delivering queued records. The remaining ones are kept in the send queue, so that they are
+// reported as unsent
+func (ci *covalentIndexer) haltSendQueue(err error, item *outgoingRecord) {
+	log.Error("send queue halted", "error", err, "nonce", item.nonce, "unsent blocks", len(ci.sendQueue))
+	ci.sendQueueErr = fmt.Errorf("%w: %s", ErrSendQueueHalted, err.Error())
+	close(ci.sendQueueHalted)
+}
+
+// sendQueueDepth returns the number of blocks waiting in the send queue
+func (ci *covalentIndexer) sendQueueDepth() int {
+	return len(ci.sendQueue)
+}
@@ -0,0 +1,318 @@
+package covalent_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-coval-index/testscommon/mock"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/numbatx/gn-core/data/block"
+	"github.com/numbatx/gn-core/data/indexer"
+	"github.com/elodina/go-avro"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewCovalentDataIndexer_SendQueue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *covalent.ArgsCovalentDataIndexer
+		expectedErr error
+	}{
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				args := createSendQueueArgs(&mock.SinkStub{}, &mock.DataHandlerStub{})
+				args.SendQueueSize = -1
+				return args
+			},
+			expectedErr: covalent.ErrInvalidSendQueueSize,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				args := createSendQueueArgs(&mock.SinkStub{}, &mock.DataHandlerStub{})
+				args.QueueFullPolicy = "drop"
+				return args
+			},
+			expectedErr: covalent.ErrUnsupportedQueueFullPolicy,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				args := createSendQueueArgs(&mock.SinkStub{}, &mock.DataHandlerStub{})
+				args.QueueFullPolicy = covalent.QueueFullSpill
+				return args
+			},
+			expectedErr: covalent.ErrNilSpillSink,
+		},
+		{
+			args: func() *covalent.ArgsCovalentDataIndexer {
+				args := createSendQueueArgs(&mock.SinkStub{}, &mock.DataHandlerStub{})
+				args.QueueFullPolicy = covalent.QueueFullSpill
+				args.SpillSink = &mock.SinkStub{}
+				return args
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		ci, err := covalent.NewCovalentDataIndexer(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(ci))
+		if err == nil {
+			require.Nil(t, ci.Close())
+		}
+	}
+}
+
+func TestCovalentIndexer_SaveBlock_WithSendQueue_ExpectReturnBeforePublishAndDeliveredInOrder(t *testing.T) {
+	t.Parallel()
+
+	stalledSink := newStalledSink()
+	ci, _ := covalent.NewCovalentDataIndexer(createSendQueueArgs(stalledSink, newNonceProcessor()))
+
+	for nonce := 1; nonce <= 3; nonce++ {
+		require.Nil(t, ci.SaveBlock(createArgsSaveBlockWithNonce(uint64(nonce))))
+	}
+
+	require.Eventually(t, func() bool {
+		return ci.Status().SendQueueDepth == 2
+	}, time.Second, time.Millisecond*10)
+	status := ci.Status()
+	require.Equal(t, 3, status.QueuedBlocks)
+	require.Equal(t, 3, status.SendQueueCapacity)
+
+	stalledSink.release()
+	require.Eventually(t, func() bool {
+		return ci.Status().QueuedBlocks == 0
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, []uint64{1, 2, 3}, stalledSink.publishedNonces())
+	require.Nil(t, ci.Close())
+}
+
+func TestCovalentIndexer_SaveBlock_SendQueueFull(t *testing.T) {
+	t.Parallel()
+
+	t.Run("error policy, expect block rejected", func(t *testing.T) {
+		t.Parallel()
+
+		stalledSink := newStalledSink()
+		args := createSendQueueArgs(stalledSink, newNonceProcessor())
+		args.QueueFullPolicy = covalent.QueueFullError
+		ci, _ := covalent.NewCovalentDataIndexer(args)
+
+		fillSendQueue(t, ci)
+		require.Equal(t, covalent.ErrSendQueueFull, ci.SaveBlock(createArgsSaveBlockWithNonce(5)))
+
+		stalledSink.release()
+		require.Nil(t, ci.Close())
+		require.Equal(t, []uint64{1, 2, 3, 4}, stalledSink.publishedNonces())
+	})
+
+	t.Run("spill policy, expect block spilled", func(t *testing.T) {
+		t.Parallel()
+
+		spilledNonces := make(chan uint64, 1)
+		stalledSink := newStalledSink()
+		args := createSendQueueArgs(stalledSink, newNonceProcessor())
+		args.QueueFullPolicy = covalent.QueueFullSpill
+		args.SpillSink = &mock.SinkStub{
+			PublishCalled: func(message *covalent.SinkMessage) error {
+				spilledNonces <- message.Nonce
+				return nil
+			},
+		}
+		ci, _ := covalent.NewCovalentDataIndexer(args)
+
+		fillSendQueue(t, ci)
+		require.Nil(t, ci.SaveBlock(createArgsSaveBlockWithNonce(5)))
+		require.Equal(t, uint64(5), <-spilledNonces)
+
+		stalledSink.release()
+		require.Nil(t, ci.Close())
+		require.Equal(t, []uint64{1, 2, 3, 4}, stalledSink.publishedNonces())
+	})
+
+	t.Run("block policy, expect SaveBlock waiting for room", func(t *testing.T) {
+		t.Parallel()
+
+		stalledSink := newStalledSink()
+		ci, _ := covalent.NewCovalentDataIndexer(createSendQueueArgs(stalledSink, newNonceProcessor()))
+
+		fillSendQueue(t, ci)
+		saveBlockErr := make(chan error, 1)
+		go func() {
+			saveBlockErr <- ci.SaveBlock(createArgsSaveBlockWithNonce(5))
+		}()
+
+		select {
+		case <-saveBlockErr:
+			require.Fail(t, "SaveBlock should wait for room in the send queue")
+		case <-time.After(time.Millisecond * 100):
+		}
+
+		stalledSink.release()
+		select {
+		case err := <-saveBlockErr:
+			require.Nil(t, err)
+		case <-time.After(time.Second):
+			require.Fail(t, "SaveBlock still blocked after the queue was emptied")
+		}
+
+		require.Eventually(t, func() bool {
+			return ci.Status().QueuedBlocks == 0
+		}, time.Second, time.Millisecond*10)
+		require.Nil(t, ci.Close())
+		require.Equal(t, []uint64{1, 2, 3, 4, 5}, stalledSink.publishedNonces())
+	})
+
+	t.Run("block policy, expect SaveBlock unblocked by Close", func(t *testing.T) {
+		t.Parallel()
+
+		stalledSink := newStalledSink()
+		args := createSendQueueArgs(stalledSink, newNonceProcessor())
+		args.DrainTimeout = 0
+		ci, _ := covalent.NewCovalentDataIndexer(args)
+
+		fillSendQueue(t, ci)
+		saveBlockErr := make(chan error, 1)
+		go func() {
+			saveBlockErr <- ci.SaveBlock(createArgsSaveBlockWithNonce(5))
+		}()
+		time.Sleep(time.Millisecond * 50)
+
+		closeErr := make(chan error, 1)
+		go func() {
+			closeErr <- ci.Close()
+		}()
+		select {
+		case err := <-saveBlockErr:
+			require.Equal(t, covalent.ErrIndexerClosed, err)
+		case <-time.After(time.Second):
+			require.Fail(t, "SaveBlock still blocked after Close")
+		}
+
+		stalledSink.release()
+		require.Nil(t, <-closeErr)
+	})
+}
+
+func TestCovalentIndexer_SaveBlock_WithSendQueue_SkipPolicy_ExpectMarkerAfterQueuedBlocks(t *testing.T) {
+	t.Parallel()
+
+	errProcess := errors.New("process error")
+	processor := newNonceProcessor()
+	processBlock := processor.ProcessDataCalled
+	processor.ProcessDataCalled = func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+		if args.Header.GetNonce() == 4 {
+			return nil, errProcess
+		}
+		return processBlock(args)
+	}
+
+	records := make(chan avro.AvroRecord, 4)
+	stalledSink := newStalledSink()
+	stalledSink.PublishCalled = func(message *covalent.SinkMessage) error {
+		<-stalledSink.released
+		records <- message.Record
+		return nil
+	}
+	args := createSendQueueArgs(stalledSink, processor)
+	args.FailurePolicy = covalent.FailurePolicySkip
+	ci, _ := covalent.NewCovalentDataIndexer(args)
+
+	for nonce := 1; nonce <= 4; nonce++ {
+		require.Nil(t, ci.SaveBlock(createArgsSaveBlockWithNonce(uint64(nonce))))
+	}
+	stalledSink.release()
+
+	for nonce := 1; nonce <= 3; nonce++ {
+		blockResult, ok := (<-records).(*schema.BlockResult)
+		require.True(t, ok)
+		require.Equal(t, int64(nonce), blockResult.Block.Nonce)
+	}
+	marker, ok := (<-records).(*schema.BlockProcessingFailed)
+	require.True(t, ok)
+	require.Equal(t, int64(4), marker.Nonce)
+	require.Equal(t, errProcess.Error(), marker.Error)
+	require.Nil(t, ci.Close())
+}
+
+func createSendQueueArgs(sink covalent.Sink, processor covalent.DataHandler) *covalent.ArgsCovalentDataIndexer {
+	return &covalent.ArgsCovalentDataIndexer{
+		Processor:            processor,
+		Sinks:                []covalent.Sink{sink},
+		DisableWebSocketSink: true,
+		SendQueueSize:        3,
+		DrainTimeout:         time.Second,
+	}
+}
+
+func createArgsSaveBlockWithNonce(nonce uint64) *indexer.ArgsSaveBlockData {
+	return &indexer.ArgsSaveBlockData{
+		Header: &block.Header{Nonce: nonce},
+	}
+}
+
+// fillSendQueue saves a block which blocks the sender, then fills the send queue
+func fillSendQueue(t *testing.T, ci covalent.Driver) {
+	for nonce := 1; nonce <= 4; nonce++ {
+		require.Nil(t, ci.SaveBlock(createArgsSaveBlockWithNonce(uint64(nonce))))
+		if nonce == 1 {
+			require.Eventually(t, func() bool {
+				return ci.Status().SendQueueDepth == 0
+			}, time.Second, time.Millisecond*10)
+		}
+	}
+}
+
+// newNonceProcessor creates a processor which returns block results having the nonce of the saved header
+func newNonceProcessor() *mock.DataHandlerStub {
+	return &mock.DataHandlerStub{
+		ProcessDataCalled: func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
+			blockResult := generateRandomValidBlockResult()
+			blockResult.Block.Nonce = int64(args.Header.GetNonce())
+			return blockResult, nil
+		},
+	}
+}
+
+type stalledSink struct {
+	*mock.SinkStub
+	mut      sync.Mutex
+	released chan struct{}
+	nonces   []uint64
+}
+
+// newStalledSink creates a sink whose publishing waits until it is released
+func newStalledSink() *stalledSink {
+	ss := &stalledSink{
+		released: make(chan struct{}),
+	}
+	ss.SinkStub = &mock.SinkStub{
+		PublishCalled: func(message *covalent.SinkMessage) error {
+			<-ss.released
+
+			ss.mut.Lock()
+			ss.nonces = append(ss.nonces, message.Nonce)
+			ss.mut.Unlock()
+			return nil
+		},
+	}
+
+	return ss
+}
+
+func (ss *stalledSink) release() {
+	close(ss.released)
+}
+
+func (ss *stalledSink) publishedNonces() []uint64 {
+	ss.mut.Lock()
+	defer ss.mut.Unlock()
+
+	return ss.nonces
+}
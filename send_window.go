@@ -0,0 +1,251 @@
+package covalent
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+
+	"github.com/numbatx/gn-coval-index/process"
+	"github.com/gorilla/websocket"
+)
+
+type inFlightEntry struct {
+	entry *OutboxEntry
+	sent  bool
+	acked bool
+}
+
+// acknowledgeReader reads acknowledges from a receiver websocket on its own goroutine, one for each request, so that
+// blocks keep being written while an acknowledge is awaited
+type acknowledgeReader struct {
+	wsr      process.WSConn
+	requests chan struct{}
+	results  chan *acknowledgeResult
+	stop     chan struct{}
+}
+
+type acknowledgeResult struct {
+	msgType int
+	ackData []byte
+	err     error
+}
+
+// processOutboxWithWindow sends up to sendWindowSize outbox entries without waiting for their acknowledgement.
+// Entries are written as soon as they fit in the window, while acknowledges are read on their own goroutine.
+// Entries are removed from outbox in order, once all entries before them were also acknowledged. An acknowledge
+// received for an entry while older entries are still waiting means those older acknowledges were missed,
+// so only the older entries are sent again
+func (ci *covalentIndexer) processOutboxWithWindow() {
+	inFlight := make([]*inFlightEntry, 0, ci.sendWindowSize)
+	var reader *acknowledgeReader
+	readPending := false
+	defer func() {
+		reader.close()
+	}()
+
+	for {
+		select {
+		case <-ci.ctx.Done():
+			return
+		default:
+		}
+
+		inFlight = ci.fillWindow(inFlight)
+		wss, wsr := ci.getConnections()
+		if reader != nil && reader.wsr != wsr {
+			// acknowledges of entries sent before are lost together with the previous receiver websocket
+			reader.close()
+			reader = nil
+			readPending = false
+			markUnacknowledgedAsNotSent(inFlight)
+		}
+
+		if wss != nil && wsr != nil {
+			if reader == nil {
+				reader = ci.startAcknowledgeReader(wsr)
+			}
+			ci.sendWindow(inFlight, wss)
+			if !readPending && hasUnacknowledgedSent(inFlight) {
+				reader.requests <- struct{}{}
+				readPending = true
+			}
+		}
+
+		select {
+		case result := <-reader.resultsChan():
+			readPending = false
+			if result.err != nil {
+				log.Warn("could not receive acknowledge data from covalent, waiting for new connection", "error", result.err)
+				markUnacknowledgedAsNotSent(inFlight)
+				reader.close()
+				reader = nil
+				continue
+			}
+			if result.msgType != websocket.BinaryMessage {
+				continue
+			}
+
+			ci.acknowledge(inFlight, result.ackData)
+			inFlight = ci.removeAcknowledged(inFlight)
+		case <-ci.newOutboxEntry:
+		case <-ci.newConnectionWSS:
+		case <-ci.newConnectionWSR:
+		case <-ci.ctx.Done():
+			return
+		}
+	}
+}
+
+// startAcknowledgeReader starts reading acknowledges from the receiver websocket, one for each request. A failed read
+// ends the reader, as the websocket is marked as disconnected
+func (ci *covalentIndexer) startAcknowledgeReader(wsr process.WSConn) *acknowledgeReader {
+	reader := &acknowledgeReader{
+		wsr:      wsr,
+		requests: make(chan struct{}, 1),
+		// a single read is requested at a time, so its result never waits for the delivery loop
+		results: make(chan *acknowledgeResult, 1),
+		stop:    make(chan struct{}),
+	}
+
+	go func() {
+		for {
+			select {
+			case <-reader.requests:
+			case <-reader.stop:
+				return
+			}
+
+			msgType, ackData, err := ci.readAcknowledge(wsr)
+			reader.results <- &acknowledgeResult{msgType: msgType, ackData: ackData, err: err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return reader
+}
+
+// resultsChan returns the channel of read acknowledges, nil if there is no reader, so that selecting on it waits
+// for other events
+func (reader *acknowledgeReader) resultsChan() chan *acknowledgeResult {
+	if reader == nil {
+		return nil
+	}
+
+	return reader.results
+}
+
+// close stops the reader once its pending read, if any, returns
+func (reader *acknowledgeReader) close() {
+	if reader == nil {
+		return
+	}
+
+	close(reader.stop)
+}
+
+// fillWindow appends outbox entries which were not yet picked, until the window is full
+func (ci *covalentIndexer) fillWindow(inFlight []*inFlightEntry) []*inFlightEntry {
+	for len(inFlight) < ci.sendWindowSize && len(inFlight) < ci.outbox.Len() {
+		entry, err := ci.outbox.Get(len(inFlight))
+		if err != nil {
+			log.Error("could not read entry from outbox", "error", err, "index", len(inFlight))
+			break
+		}
+
+		inFlight = append(inFlight, &inFlightEntry{entry: entry})
+	}
+
+	return inFlight
+}
+
+// sendWindow writes all entries from the window which were not sent yet. If the sender websocket fails, it is marked
+// as disconnected and all unacknowledged entries will be sent again on the new connection
+func (ci *covalentIndexer) sendWindow(inFlight []*inFlightEntry, wss process.WSConn) {
+	for _, currEntry := range inFlight {
+		if currEntry.sent || currEntry.acked {
+			continue
+		}
+
+		err := ci.writeData(wss, currEntry.entry.Payload)
+		if err != nil {
+			log.Warn("could not send block data to covalent, waiting for new connection", "error", err)
+			markUnacknowledgedAsNotSent(inFlight)
+			return
+		}
+
+		currEntry.sent = true
+	}
+}
+
+func (ci *covalentIndexer) acknowledge(inFlight []*inFlightEntry, ackData []byte) {
+	ackedIdx := -1
+	for idx, currEntry := range inFlight {
+		// a block saved again after being reverted shares the acknowledge data of its first occurrence
+		if !currEntry.acked && isAcknowledgeFor(currEntry.entry, ackData) {
+			ackedIdx = idx
+			break
+		}
+	}
+	if ackedIdx < 0 {
+		log.Debug("received acknowledge data which does not match any block in flight")
+		return
+	}
+
+	inFlight[ackedIdx].acked = true
+	for idx := 0; idx < ackedIdx; idx++ {
+		if !inFlight[idx].acked && inFlight[idx].sent {
+			log.Debug("acknowledge missing or out of order, block will be sent again",
+				"nonce", inFlight[idx].entry.Nonce, "id", inFlight[idx].entry.ID)
+			inFlight[idx].sent = false
+		}
+	}
+}
+
+// removeAcknowledged removes from outbox all consecutive acknowledged entries, starting with the oldest one
+func (ci *covalentIndexer) removeAcknowledged(inFlight []*inFlightEntry) []*inFlightEntry {
+	for len(inFlight) > 0 && inFlight[0].acked {
+		err := ci.outbox.RemoveHead()
+		if err != nil {
+			log.Error("could not remove acknowledged entry from outbox", "error", err, "id", inFlight[0].entry.ID)
+			time.Sleep(time.Millisecond * RetrialTimeoutMS)
+			break
+		}
+
+		log.Trace("outbox entry acknowledged", "id", inFlight[0].entry.ID, "nonce", inFlight[0].entry.Nonce)
+		ci.blockAcknowledged(inFlight[0].entry.AckData, inFlight[0].entry.Nonce)
+		inFlight[0] = nil
+		inFlight = inFlight[1:]
+	}
+
+	return inFlight
+}
+
+func hasUnacknowledgedSent(inFlight []*inFlightEntry) bool {
+	for _, currEntry := range inFlight {
+		if currEntry.sent && !currEntry.acked {
+			return true
+		}
+	}
+
+	return false
+}
+
+func markUnacknowledgedAsNotSent(inFlight []*inFlightEntry) {
+	for _, currEntry := range inFlight {
+		if !currEntry.acked {
+			currEntry.sent = false
+		}
+	}
+}
+
+// isAcknowledgeFor checks if the acknowledge data is either the block hash or the outbox sequence number
+// (8 bytes, big endian) of the entry
+func isAcknowledgeFor(entry *OutboxEntry, ackData []byte) bool {
+	if bytes.Equal(entry.AckData, ackData) {
+		return true
+	}
+
+	return len(ackData) == 8 && binary.BigEndian.Uint64(ackData) == entry.ID
+}
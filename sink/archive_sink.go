@@ -0,0 +1,232 @@
+package sink
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/ocf"
+	"github.com/numbatx/gn-coval-index/process/utility"
+	"github.com/numbatx/gn-coval-index/schema"
+)
+
+const (
+	archiveFileSuffix = ".avro"
+
+	// DefaultMaxArchiveFileSize is the archive file size used if none is provided
+	DefaultMaxArchiveFileSize = 1024 * 1024 * 1024
+	// DefaultRecordsPerBlock is the number of block results written in each data block, if none is provided
+	DefaultRecordsPerBlock = 1
+)
+
+// ArgsArchiveSink holds all input dependencies required by archive sink in order to create a new instance.
+// An empty Codec means no compression
+type ArgsArchiveSink struct {
+	Directory       string
+	MaxFileSize     int64
+	Codec           ocf.Codec
+	RecordsPerBlock int
+}
+
+type archiveSink struct {
+	mut             sync.Mutex
+	directory       string
+	maxFileSize     int64
+	codec           ocf.Codec
+	recordsPerBlock int
+	file            *os.File
+	writer          ocfWriter
+	epoch           int32
+	fileRecords     int
+	pendingRecords  int
+	closed          bool
+}
+
+type ocfWriter interface {
+	Append(datum []byte)
+	Flush() error
+	WrittenSize() int64
+	PendingSize() int
+}
+
+// NewArchiveSink creates a new sink which archives block results in avro object container files, holding the
+// block result schema in their header. A new file is started for each epoch, as well as when the current file
+// would exceed the maximum file size. Files are named after the epoch and the nonce of their first block
+func NewArchiveSink(args *ArgsArchiveSink) (*archiveSink, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if len(args.Directory) == 0 {
+		return nil, covalent.ErrEmptySinkDirectory
+	}
+	if args.MaxFileSize < 0 {
+		return nil, covalent.ErrInvalidSinkFileSize
+	}
+	if args.RecordsPerBlock < 0 {
+		return nil, covalent.ErrInvalidRecordsPerBlock
+	}
+
+	codec := args.Codec
+	if len(codec) == 0 {
+		codec = ocf.CodecNull
+	}
+	if !ocf.IsCodecSupported(codec) {
+		return nil, covalent.ErrUnsupportedCodec
+	}
+
+	maxFileSize := args.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = DefaultMaxArchiveFileSize
+	}
+	recordsPerBlock := args.RecordsPerBlock
+	if recordsPerBlock == 0 {
+		recordsPerBlock = DefaultRecordsPerBlock
+	}
+
+	err := os.MkdirAll(args.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &archiveSink{
+		directory:       args.Directory,
+		maxFileSize:     maxFileSize,
+		codec:           codec,
+		recordsPerBlock: recordsPerBlock,
+	}, nil
+}
+
+// Publish appends the block result to the current archive file, rotating it if needed. Other records are not archived
+func (as *archiveSink) Publish(message *covalent.SinkMessage) error {
+	if message == nil {
+		return covalent.ErrNilSinkMessage
+	}
+	blockResult, ok := message.Record.(*schema.BlockResult)
+	if !ok {
+		return nil
+	}
+	if blockResult.Block == nil {
+		return covalent.ErrNilSinkMessage
+	}
+
+	datum, err := utility.Encode(blockResult)
+	if err != nil {
+		return err
+	}
+
+	as.mut.Lock()
+	defer as.mut.Unlock()
+
+	if as.closed {
+		return covalent.ErrSinkClosed
+	}
+
+	err = as.rotateIfNeeded(blockResult.Block, len(datum))
+	if err != nil {
+		return err
+	}
+
+	as.writer.Append(datum)
+	as.fileRecords++
+	as.pendingRecords++
+	if as.pendingRecords < as.recordsPerBlock {
+		return nil
+	}
+
+	as.pendingRecords = 0
+	return as.writer.Flush()
+}
+
+func (as *archiveSink) rotateIfNeeded(block *schema.Block, datumSize int) error {
+	if as.file != nil && as.epoch == block.Epoch {
+		fileSize := as.writer.WrittenSize() + int64(as.writer.PendingSize()) + int64(datumSize)
+		if as.fileRecords == 0 || fileSize <= as.maxFileSize {
+			return nil
+		}
+	}
+
+	if as.file != nil {
+		err := as.closeFile()
+		if err != nil {
+			return err
+		}
+	}
+
+	file, err := as.createFile(block)
+	if err != nil {
+		return err
+	}
+
+	writer, err := ocf.NewWriter(file, schema.NewBlockResult().Schema(), as.codec)
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	log.Debug("archive sink opened new file", "file", file.Name(), "epoch", block.Epoch)
+	as.file = file
+	as.writer = writer
+	as.epoch = block.Epoch
+	as.fileRecords = 0
+	as.pendingRecords = 0
+
+	return nil
+}
+
+// createFile creates a new archive file. If a file with the same name already exists (e.g. the same block is
+// archived again after a restart), a numeric suffix is added to the new file name
+func (as *archiveSink) createFile(block *schema.Block) (*os.File, error) {
+	baseName := fmt.Sprintf("blocks-epoch-%010d-nonce-%020d", block.Epoch, block.Nonce)
+	fileName := filepath.Join(as.directory, baseName+archiveFileSuffix)
+
+	for suffix := 1; ; suffix++ {
+		file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if !os.IsExist(err) {
+			return file, err
+		}
+
+		fileName = filepath.Join(as.directory, fmt.Sprintf("%s-%d%s", baseName, suffix, archiveFileSuffix))
+	}
+}
+
+func (as *archiveSink) closeFile() error {
+	err := as.writer.Flush()
+	if err != nil {
+		return err
+	}
+
+	err = as.file.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = as.file.Close()
+	as.file = nil
+	as.writer = nil
+
+	return err
+}
+
+// Close writes all pending block results and closes the current archive file
+func (as *archiveSink) Close() error {
+	as.mut.Lock()
+	defer as.mut.Unlock()
+
+	if as.closed {
+		return nil
+	}
+	as.closed = true
+
+	if as.file == nil {
+		return nil
+	}
+
+	return as.closeFile()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (as *archiveSink) IsInterfaceNil() bool {
+	return as == nil
+}
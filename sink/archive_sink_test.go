@@ -0,0 +1,189 @@
+package sink_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/ocf"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-coval-index/sink"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/elodina/go-avro"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewArchiveSink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *sink.ArgsArchiveSink
+		expectedErr error
+	}{
+		{
+			args: func() *sink.ArgsArchiveSink {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args: func() *sink.ArgsArchiveSink {
+				return &sink.ArgsArchiveSink{Directory: ""}
+			},
+			expectedErr: covalent.ErrEmptySinkDirectory,
+		},
+		{
+			args: func() *sink.ArgsArchiveSink {
+				return &sink.ArgsArchiveSink{Directory: t.TempDir(), MaxFileSize: -1}
+			},
+			expectedErr: covalent.ErrInvalidSinkFileSize,
+		},
+		{
+			args: func() *sink.ArgsArchiveSink {
+				return &sink.ArgsArchiveSink{Directory: t.TempDir(), RecordsPerBlock: -1}
+			},
+			expectedErr: covalent.ErrInvalidRecordsPerBlock,
+		},
+		{
+			args: func() *sink.ArgsArchiveSink {
+				return &sink.ArgsArchiveSink{Directory: t.TempDir(), Codec: "bzip2"}
+			},
+			expectedErr: covalent.ErrUnsupportedCodec,
+		},
+		{
+			args: func() *sink.ArgsArchiveSink {
+				return &sink.ArgsArchiveSink{Directory: t.TempDir(), Codec: ocf.CodecSnappy}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := sink.NewArchiveSink(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(instance))
+	}
+}
+
+func TestArchiveSink_Publish_NewEpoch_ExpectNewFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	as, _ := sink.NewArchiveSink(&sink.ArgsArchiveSink{
+		Directory:       dir,
+		Codec:           ocf.CodecDeflate,
+		RecordsPerBlock: 2,
+	})
+
+	messages := generateMessages(5, 10)
+	for idx, message := range messages {
+		message.Epoch = uint32(idx / 3)
+		message.Record.(*schema.BlockResult).Block.Epoch = int32(message.Epoch)
+		require.Nil(t, as.Publish(message))
+	}
+	require.Nil(t, as.Close())
+	require.Equal(t, covalent.ErrSinkClosed, as.Publish(messages[0]))
+	require.Equal(t, covalent.ErrNilSinkMessage, as.Publish(nil))
+
+	firstEpoch := readArchivedHashes(t, filepath.Join(dir, "blocks-epoch-0000000000-nonce-00000000000000000100.avro"))
+	require.Equal(t, blockHashes(messages[:3]), firstEpoch)
+
+	secondEpoch := readArchivedHashes(t, filepath.Join(dir, "blocks-epoch-0000000001-nonce-00000000000000000103.avro"))
+	require.Equal(t, blockHashes(messages[3:]), secondEpoch)
+}
+
+func TestArchiveSink_Publish_MaxFileSizeReached_ExpectNewFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	// The header alone has more than 100 bytes, so every file holds only one block result
+	as, _ := sink.NewArchiveSink(&sink.ArgsArchiveSink{Directory: dir, MaxFileSize: 100})
+
+	messages := generateMessages(2, 10)
+	for _, message := range messages {
+		require.Nil(t, as.Publish(message))
+	}
+	require.Nil(t, as.Close())
+
+	firstFile := readArchivedHashes(t, filepath.Join(dir, "blocks-epoch-0000000000-nonce-00000000000000000100.avro"))
+	require.Equal(t, blockHashes(messages[:1]), firstFile)
+
+	secondFile := readArchivedHashes(t, filepath.Join(dir, "blocks-epoch-0000000000-nonce-00000000000000000101.avro"))
+	require.Equal(t, blockHashes(messages[1:]), secondFile)
+}
+
+func TestArchiveSink_Restart_ExpectExistingFileKept(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	message := generateMessages(1, 10)[0]
+
+	for i := 0; i < 2; i++ {
+		as, _ := sink.NewArchiveSink(&sink.ArgsArchiveSink{Directory: dir})
+		require.Nil(t, as.Publish(message))
+		require.Nil(t, as.Close())
+	}
+
+	firstFile := readArchivedHashes(t, filepath.Join(dir, "blocks-epoch-0000000000-nonce-00000000000000000100.avro"))
+	require.Equal(t, [][]byte{message.Hash}, firstFile)
+
+	secondFile := readArchivedHashes(t, filepath.Join(dir, "blocks-epoch-0000000000-nonce-00000000000000000100-1.avro"))
+	require.Equal(t, [][]byte{message.Hash}, secondFile)
+}
+
+func TestArchiveSink_Publish_NotBlockResult_ExpectNotArchived(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	as, _ := sink.NewArchiveSink(&sink.ArgsArchiveSink{Directory: dir})
+
+	message := generateMessages(1, 10)[0]
+	message.Record = &schema.BlockProcessingFailed{Hash: message.Hash}
+	require.Nil(t, as.Publish(message))
+	require.Nil(t, as.Close())
+
+	dirEntries, err := os.ReadDir(dir)
+	require.Nil(t, err)
+	require.Empty(t, dirEntries)
+}
+
+func blockHashes(messages []*covalent.SinkMessage) [][]byte {
+	hashes := make([][]byte, len(messages))
+	for idx, message := range messages {
+		hashes[idx] = message.Hash
+	}
+
+	return hashes
+}
+
+func readArchivedHashes(t *testing.T, fileName string) [][]byte {
+	file, err := os.Open(fileName)
+	require.Nil(t, err)
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader, err := ocf.NewReader(file)
+	require.Nil(t, err)
+
+	datumReader := avro.NewSpecificDatumReader()
+	datumReader.SetSchema(schema.NewBlockResult().Schema())
+
+	hashes := make([][]byte, 0)
+	for {
+		count, data, errNext := reader.NextBlock()
+		if errNext == io.EOF {
+			return hashes
+		}
+		require.Nil(t, errNext)
+
+		decoder := avro.NewBinaryDecoder(data)
+		for i := int64(0); i < count; i++ {
+			blockResult := &schema.BlockResult{}
+			require.Nil(t, datumReader.Read(blockResult, decoder))
+			hashes = append(hashes, blockResult.Block.Hash)
+		}
+	}
+}
@@ -0,0 +1,554 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/process"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/gorilla/websocket"
+)
+
+// ConsumerPolicy defines what a consumer sink does when its consumer does not keep up with the stream
+type ConsumerPolicy string
+
+const (
+	// ConsumerPolicyDrop drops the oldest block not yet acknowledged by the consumer, so that a slow consumer
+	// never blocks the others. It is the default policy
+	ConsumerPolicyDrop ConsumerPolicy = "drop"
+	// ConsumerPolicyBlock waits until the consumer acknowledges a block, blocking SaveBlock and all other sinks
+	ConsumerPolicyBlock ConsumerPolicy = "block"
+)
+
+// DefaultConsumerQueueSize is the number of blocks waiting to be acknowledged by a consumer, if none is provided
+const DefaultConsumerQueueSize = 1000
+
+// ArgsConsumerSink holds all input dependencies required by consumer sink in order to create a new instance.
+// QueueSize and RetryPolicy are optional, a block not acknowledged being sent again every
+// covalent.RetrialTimeoutMS if no retry policy is provided. A block which was not acknowledged within the retries
+// allowed by the retry policy is dropped. WriteTimeout and AckTimeout are optional deadlines for writing a block
+// and for receiving its acknowledge, the connection being closed if they expire
+type ArgsConsumerSink struct {
+	Name         string
+	Policy       ConsumerPolicy
+	QueueSize    int
+	RetryPolicy  covalent.RetryPolicy
+	WriteTimeout time.Duration
+	AckTimeout   time.Duration
+}
+
+type consumerSink struct {
+	name               string
+	policy             ConsumerPolicy
+	queueSize          int
+	retryPolicy        covalent.RetryPolicy
+	writeTimeout       time.Duration
+	ackTimeout         time.Duration
+	mut                sync.Mutex
+	mutResume          sync.Mutex
+	cond               *sync.Cond
+	queue              []*covalent.SinkMessage
+	conn               process.WSConn
+	lastReplayed       *covalent.SinkMessage
+	lastPublished      *covalent.SinkMessage
+	resuming           bool
+	publishedMeanwhile []*covalent.SinkMessage
+	lastAckedSequence  uint64
+	acknowledged       uint64
+	dropped            uint64
+	closed             bool
+	closeChan          chan struct{}
+	loopDone           chan struct{}
+}
+
+// ConsumerStatus holds the acknowledgement cursor of a consumer. LastAckedSequence is only meaningful if
+// Acknowledged is not zero
+type ConsumerStatus struct {
+	Name              string `json:"name"`
+	Connected         bool   `json:"connected"`
+	LastAckedSequence uint64 `json:"lastAckedSequence"`
+	Acknowledged      uint64 `json:"acknowledged"`
+	Dropped           uint64 `json:"dropped"`
+	Queued            int    `json:"queued"`
+}
+
+// NewConsumerSink creates a new sink which delivers block results to a named consumer, over its own websocket,
+// independently of all other consumers. Blocks are kept in a bounded queue until the consumer acknowledges them
+// with either the block hash or the sequence number(8 bytes, big endian), a reconnected consumer receiving
+// again all blocks it did not acknowledge
+func NewConsumerSink(args *ArgsConsumerSink) (*consumerSink, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if len(args.Name) == 0 {
+		return nil, covalent.ErrEmptyConsumerName
+	}
+	if !IsConsumerPolicySupported(args.Policy) {
+		return nil, covalent.ErrUnsupportedConsumerPolicy
+	}
+	if args.QueueSize < 0 {
+		return nil, covalent.ErrInvalidConsumerQueueSize
+	}
+	if args.WriteTimeout < 0 {
+		return nil, covalent.ErrInvalidWriteTimeout
+	}
+	if args.AckTimeout < 0 {
+		return nil, covalent.ErrInvalidAckTimeout
+	}
+
+	policy := args.Policy
+	if len(policy) == 0 {
+		policy = ConsumerPolicyDrop
+	}
+	queueSize := args.QueueSize
+	if queueSize == 0 {
+		queueSize = DefaultConsumerQueueSize
+	}
+	retryPolicy := args.RetryPolicy
+	if check.IfNil(retryPolicy) {
+		var err error
+		retryPolicy, err = covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+			InitialDelay: time.Millisecond * covalent.RetrialTimeoutMS,
+			Multiplier:   1,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cs := &consumerSink{
+		name:         args.Name,
+		policy:       policy,
+		queueSize:    queueSize,
+		retryPolicy:  retryPolicy,
+		writeTimeout: args.WriteTimeout,
+		ackTimeout:   args.AckTimeout,
+		queue:        make([]*covalent.SinkMessage, 0, queueSize),
+		closeChan:    make(chan struct{}),
+		loopDone:     make(chan struct{}),
+	}
+	cs.cond = sync.NewCond(&cs.mut)
+
+	go cs.deliver()
+
+	return cs, nil
+}
+
+// IsConsumerPolicySupported returns true if the policy is known. An empty policy means ConsumerPolicyDrop
+func IsConsumerPolicySupported(policy ConsumerPolicy) bool {
+	switch policy {
+	case "", ConsumerPolicyDrop, ConsumerPolicyBlock:
+		return true
+	default:
+		return false
+	}
+}
+
+// Name returns the consumer name
+func (cs *consumerSink) Name() string {
+	return cs.name
+}
+
+// SetConnection sets the websocket used both to send data to the consumer and to receive its acknowledge data,
+// closing the previous one(if it exists). Blocks which were not acknowledged are sent again on the new connection
+func (cs *consumerSink) SetConnection(ws process.WSConn) {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	if cs.closed {
+		closeConnection(ws)
+		return
+	}
+
+	closeConnection(cs.conn)
+	cs.conn = ws
+	cs.cond.Broadcast()
+
+	log.Debug("consumer connected", "consumer", cs.name)
+}
+
+// Resume resumes the stream of a consumer from a position retained by the block log. The block log is read and the
+// consumer connection is obtained by connect without holding the consumer lock, so that a slow handshake never blocks
+// publishing. Messages published meanwhile are kept and, once connected, the queued messages are replaced with the
+// replayed ones followed by those which were not replayed, so that none is lost. The new connection is used for all
+// replayed messages, before any newly published one. It returns the error of reading the block log, in which case
+// connect is not called and the queue is left unchanged
+func (cs *consumerSink) Resume(
+	blockLog covalent.BlockLog,
+	position *covalent.ResumePosition,
+	connect func() (process.WSConn, bool),
+) error {
+	cs.mutResume.Lock()
+	defer cs.mutResume.Unlock()
+
+	cs.mut.Lock()
+	if cs.closed {
+		cs.mut.Unlock()
+		return covalent.ErrSinkClosed
+	}
+	lastPublished := cs.lastPublished
+	cs.resuming = true
+	cs.mut.Unlock()
+	defer cs.stopResuming()
+
+	replayed, err := blockLog.ReadAfter(position)
+	if err != nil {
+		return err
+	}
+
+	ws, ok := connect()
+	if !ok {
+		return nil
+	}
+
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	if cs.closed {
+		closeConnection(ws)
+		return covalent.ErrSinkClosed
+	}
+
+	closeConnection(cs.conn)
+	cs.conn = ws
+	cs.queue = resumeQueue(replayed, cs.publishedMeanwhile)
+	cs.lastReplayed = nil
+	if len(replayed) > 0 {
+		lastReplayed := replayed[len(replayed)-1]
+		if !isSameMessage(lastPublished, lastReplayed) && indexOf(cs.publishedMeanwhile, lastReplayed) < 0 {
+			// the last replayed message was retained by the block log, but not yet published to this sink
+			cs.lastReplayed = lastReplayed
+		}
+	}
+	cs.cond.Broadcast()
+
+	log.Debug("consumer resumed", "consumer", cs.name,
+		"replayed blocks", len(replayed), "published meanwhile", len(cs.publishedMeanwhile))
+	return nil
+}
+
+func (cs *consumerSink) stopResuming() {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	cs.resuming = false
+	cs.publishedMeanwhile = nil
+}
+
+// resumeQueue returns the replayed messages followed by the messages published while resuming which were not
+// replayed. Each message is published to the block log right before being published to this sink, so the replayed
+// messages published meanwhile can only be among the last replayed ones
+func resumeQueue(replayed []*covalent.SinkMessage, publishedMeanwhile []*covalent.SinkMessage) []*covalent.SinkMessage {
+	lastReplayed := replayed
+	if len(lastReplayed) > len(publishedMeanwhile)+1 {
+		lastReplayed = lastReplayed[len(lastReplayed)-len(publishedMeanwhile)-1:]
+	}
+
+	queue := append(make([]*covalent.SinkMessage, 0, len(replayed)+len(publishedMeanwhile)), replayed...)
+	for _, message := range publishedMeanwhile {
+		if indexOf(lastReplayed, message) < 0 {
+			queue = append(queue, message)
+		}
+	}
+
+	return queue
+}
+
+func indexOf(messages []*covalent.SinkMessage, message *covalent.SinkMessage) int {
+	for idx, currMessage := range messages {
+		if isSameMessage(currMessage, message) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// isSameMessage checks if both messages are the same stream message, e.g. a message published to this sink and the
+// copy of it read back from the block log
+func isSameMessage(first *covalent.SinkMessage, second *covalent.SinkMessage) bool {
+	if first == nil || second == nil {
+		return false
+	}
+
+	return first.SequenceNumber == second.SequenceNumber &&
+		first.Nonce == second.Nonce &&
+		bytes.Equal(first.Hash, second.Hash)
+}
+
+// Publish queues the message until the consumer acknowledges it. If the queue is full, the oldest message is
+// dropped or publishing waits, according to the consumer policy
+func (cs *consumerSink) Publish(message *covalent.SinkMessage) error {
+	if message == nil {
+		return covalent.ErrNilSinkMessage
+	}
+
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	cs.lastPublished = message
+	if cs.isReplayed(message) {
+		return nil
+	}
+
+	for !cs.closed && len(cs.queue) >= cs.queueSize {
+		if cs.policy == ConsumerPolicyDrop {
+			cs.dropHead()
+			break
+		}
+
+		cs.cond.Wait()
+	}
+	if cs.closed {
+		return covalent.ErrSinkClosed
+	}
+
+	cs.queue = append(cs.queue, message)
+	cs.cond.Broadcast()
+	if cs.resuming {
+		cs.keepPublishedMeanwhile(message)
+	}
+
+	return nil
+}
+
+// keepPublishedMeanwhile keeps a message published while resuming, so that it is queued after the replayed ones.
+// At most a queue of them is kept, the oldest being dropped
+func (cs *consumerSink) keepPublishedMeanwhile(message *covalent.SinkMessage) {
+	if len(cs.publishedMeanwhile) >= cs.queueSize {
+		cs.publishedMeanwhile[0] = nil
+		cs.publishedMeanwhile = cs.publishedMeanwhile[1:]
+	}
+
+	cs.publishedMeanwhile = append(cs.publishedMeanwhile, message)
+}
+
+// isReplayed checks if the message is the last one replayed on resume, which could have been retained by the block
+// log before being published to this sink. Only the first message published after a resume is checked
+func (cs *consumerSink) isReplayed(message *covalent.SinkMessage) bool {
+	lastReplayed := cs.lastReplayed
+	cs.lastReplayed = nil
+
+	return isSameMessage(lastReplayed, message)
+}
+
+func (cs *consumerSink) dropHead() {
+	dropped := cs.queue[0]
+	cs.queue[0] = nil
+	cs.queue = cs.queue[1:]
+	cs.dropped++
+
+	log.Warn("consumer does not keep up, block dropped",
+		"consumer", cs.name,
+		"nonce", dropped.Nonce,
+		"sequence number", dropped.SequenceNumber,
+		"dropped", cs.dropped)
+}
+
+// deliver sends the oldest queued message to the consumer until it is acknowledged, then continues with the next one
+func (cs *consumerSink) deliver() {
+	defer close(cs.loopDone)
+
+	for {
+		message, conn, ok := cs.waitForDelivery()
+		if !ok {
+			return
+		}
+
+		backoff := cs.retryPolicy.NewBackoff()
+		for !cs.send(message, conn) {
+			if !cs.isHead(message) || !cs.isConnection(conn) {
+				break
+			}
+
+			delay, canRetry := backoff.NextDelay()
+			if !canRetry {
+				log.Warn("consumer did not acknowledge block, retries exhausted, block dropped",
+					"consumer", cs.name, "nonce", message.Nonce)
+				cs.remove(message, false)
+				break
+			}
+
+			select {
+			case <-time.After(delay):
+			case <-cs.closeChan:
+				return
+			}
+		}
+	}
+}
+
+// waitForDelivery waits until a message is queued and the consumer is connected. It returns false if the sink
+// was closed meanwhile
+func (cs *consumerSink) waitForDelivery() (*covalent.SinkMessage, process.WSConn, bool) {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	for !cs.closed && (len(cs.queue) == 0 || cs.conn == nil) {
+		cs.cond.Wait()
+	}
+	if cs.closed {
+		return nil, nil, false
+	}
+
+	return cs.queue[0], cs.conn, true
+}
+
+// send writes the message and reads the acknowledge data. It returns true if the message was acknowledged or
+// the connection failed, in which case the message is sent again once the consumer reconnects
+func (cs *consumerSink) send(message *covalent.SinkMessage, conn process.WSConn) bool {
+	err := setDeadline(conn.SetWriteDeadline, cs.writeTimeout)
+	if err == nil {
+		err = conn.WriteMessage(websocket.BinaryMessage, message.Data)
+	}
+	if err != nil {
+		log.Debug("could not send block data to consumer, waiting for new connection", "consumer", cs.name, "error", err)
+		cs.connectionFailed(conn)
+		return true
+	}
+
+	err = setDeadline(conn.SetReadDeadline, cs.ackTimeout)
+	if err != nil {
+		log.Debug("could not set acknowledge deadline, waiting for new connection", "consumer", cs.name, "error", err)
+		cs.connectionFailed(conn)
+		return true
+	}
+
+	msgType, ackData, err := conn.ReadMessage()
+	if err != nil {
+		log.Debug("could not receive acknowledge data from consumer, waiting for new connection", "consumer", cs.name, "error", err)
+		cs.connectionFailed(conn)
+		return true
+	}
+	if msgType != websocket.BinaryMessage || !isAcknowledgeFor(message, ackData) {
+		return false
+	}
+
+	cs.remove(message, true)
+	return true
+}
+
+// remove removes the message from the queue, if it was not dropped meanwhile, advancing the cursor if it was acknowledged
+func (cs *consumerSink) remove(message *covalent.SinkMessage, acknowledged bool) {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	if acknowledged {
+		cs.lastAckedSequence = message.SequenceNumber
+		cs.acknowledged++
+	}
+	if len(cs.queue) == 0 || cs.queue[0] != message {
+		return
+	}
+
+	cs.queue[0] = nil
+	cs.queue = cs.queue[1:]
+	cs.cond.Broadcast()
+}
+
+func (cs *consumerSink) isHead(message *covalent.SinkMessage) bool {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	return len(cs.queue) > 0 && cs.queue[0] == message
+}
+
+func (cs *consumerSink) isConnection(conn process.WSConn) bool {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	return cs.conn == conn
+}
+
+// ConnectionLost closes the websocket, found dead while waiting for blocks, and marks the consumer as
+// disconnected until it reconnects
+func (cs *consumerSink) ConnectionLost(ws process.WSConn) {
+	log.Debug("consumer connection lost", "consumer", cs.name)
+	cs.connectionFailed(ws)
+}
+
+func (cs *consumerSink) connectionFailed(conn process.WSConn) {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	if cs.conn != conn {
+		return
+	}
+
+	closeConnection(cs.conn)
+	cs.conn = nil
+}
+
+// Status returns the acknowledgement cursor of the consumer
+func (cs *consumerSink) Status() *ConsumerStatus {
+	cs.mut.Lock()
+	defer cs.mut.Unlock()
+
+	return &ConsumerStatus{
+		Name:              cs.name,
+		Connected:         cs.conn != nil,
+		LastAckedSequence: cs.lastAckedSequence,
+		Acknowledged:      cs.acknowledged,
+		Dropped:           cs.dropped,
+		Queued:            len(cs.queue),
+	}
+}
+
+// Close stops the delivery, unblocks all pending publishes and closes the consumer connection. Blocks which
+// were not acknowledged are lost
+func (cs *consumerSink) Close() error {
+	cs.mut.Lock()
+	if cs.closed {
+		cs.mut.Unlock()
+		return nil
+	}
+
+	cs.closed = true
+	close(cs.closeChan)
+	closeConnection(cs.conn)
+	cs.conn = nil
+	if len(cs.queue) > 0 {
+		log.Warn("consumer sink closed with unacknowledged blocks", "consumer", cs.name, "blocks", len(cs.queue))
+	}
+	cs.cond.Broadcast()
+	cs.mut.Unlock()
+
+	<-cs.loopDone
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (cs *consumerSink) IsInterfaceNil() bool {
+	return cs == nil
+}
+
+func closeConnection(conn process.WSConn) {
+	if conn == nil {
+		return
+	}
+
+	err := conn.Close()
+	log.LogIfError(err)
+}
+
+// setDeadline sets the deadline after the given timeout, if it is not zero
+func setDeadline(setDeadlineHandler func(t time.Time) error, timeout time.Duration) error {
+	if timeout == 0 {
+		return nil
+	}
+
+	return setDeadlineHandler(time.Now().Add(timeout))
+}
+
+// isAcknowledgeFor checks if the acknowledge data is either the block hash or the sequence number
+// (8 bytes, big endian) of the message. Messages without a hash(e.g. reverts) are only acknowledged by sequence number
+func isAcknowledgeFor(message *covalent.SinkMessage, ackData []byte) bool {
+	if len(message.Hash) > 0 && bytes.Equal(message.Hash, ackData) {
+		return true
+	}
+
+	return len(ackData) == 8 && binary.BigEndian.Uint64(ackData) == message.SequenceNumber
+}
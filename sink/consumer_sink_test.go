@@ -0,0 +1,517 @@
+package sink_test
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/process"
+	"github.com/numbatx/gn-coval-index/sink"
+	"github.com/numbatx/gn-coval-index/testscommon/mock"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConsumerSink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *sink.ArgsConsumerSink
+		expectedErr error
+	}{
+		{
+			args: func() *sink.ArgsConsumerSink {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args: func() *sink.ArgsConsumerSink {
+				return &sink.ArgsConsumerSink{Name: ""}
+			},
+			expectedErr: covalent.ErrEmptyConsumerName,
+		},
+		{
+			args: func() *sink.ArgsConsumerSink {
+				return &sink.ArgsConsumerSink{Name: "staging", Policy: "wait"}
+			},
+			expectedErr: covalent.ErrUnsupportedConsumerPolicy,
+		},
+		{
+			args: func() *sink.ArgsConsumerSink {
+				return &sink.ArgsConsumerSink{Name: "staging", QueueSize: -1}
+			},
+			expectedErr: covalent.ErrInvalidConsumerQueueSize,
+		},
+		{
+			args: func() *sink.ArgsConsumerSink {
+				return &sink.ArgsConsumerSink{Name: "staging", WriteTimeout: -time.Second}
+			},
+			expectedErr: covalent.ErrInvalidWriteTimeout,
+		},
+		{
+			args: func() *sink.ArgsConsumerSink {
+				return &sink.ArgsConsumerSink{Name: "staging", AckTimeout: -time.Second}
+			},
+			expectedErr: covalent.ErrInvalidAckTimeout,
+		},
+		{
+			args: func() *sink.ArgsConsumerSink {
+				return &sink.ArgsConsumerSink{Name: "staging", Policy: sink.ConsumerPolicyBlock}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := sink.NewConsumerSink(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(instance))
+		if instance != nil {
+			require.Nil(t, instance.Close())
+		}
+	}
+}
+
+func TestConsumerSink_Publish_ExpectDeliveredInOrderAndCursorAdvanced(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "production"})
+	defer func() {
+		_ = cs.Close()
+	}()
+
+	messages := generateMessages(3, 10)
+	consumer := newAckingConsumer(messages)
+	cs.SetConnection(consumer)
+
+	for _, message := range messages {
+		require.Nil(t, cs.Publish(message))
+	}
+
+	require.Eventually(t, func() bool {
+		return cs.Status().Acknowledged == 3
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, [][]byte{messages[0].Data, messages[1].Data, messages[2].Data}, consumer.received())
+	require.Equal(t, &sink.ConsumerStatus{
+		Name:              "production",
+		Connected:         true,
+		LastAckedSequence: messages[2].SequenceNumber,
+		Acknowledged:      3,
+	}, cs.Status())
+}
+
+func TestConsumerSink_Publish_StalledConsumerWithDropPolicy_ExpectOthersNotBlocked(t *testing.T) {
+	t.Parallel()
+
+	stalled, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "staging", QueueSize: 2})
+	production, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "production"})
+	defer func() {
+		_ = stalled.Close()
+		_ = production.Close()
+	}()
+
+	messages := generateMessages(5, 10)
+	stalled.SetConnection(newStalledConsumer())
+	production.SetConnection(newAckingConsumer(messages))
+
+	for _, message := range messages {
+		require.Nil(t, stalled.Publish(message))
+		require.Nil(t, production.Publish(message))
+	}
+
+	require.Eventually(t, func() bool {
+		return production.Status().Acknowledged == 5
+	}, time.Second, time.Millisecond*10)
+
+	status := stalled.Status()
+	require.Equal(t, uint64(0), status.Acknowledged)
+	require.Equal(t, uint64(3), status.Dropped)
+	require.Equal(t, 2, status.Queued)
+}
+
+func TestConsumerSink_Publish_StalledConsumerWithBlockPolicy_ExpectPublishBlocked(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{
+		Name:      "staging",
+		Policy:    sink.ConsumerPolicyBlock,
+		QueueSize: 1,
+	})
+
+	messages := generateMessages(2, 10)
+	require.Nil(t, cs.Publish(messages[0]))
+
+	publishErr := make(chan error, 1)
+	go func() {
+		publishErr <- cs.Publish(messages[1])
+	}()
+
+	select {
+	case <-publishErr:
+		require.Fail(t, "publish should wait for the consumer")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	cs.SetConnection(newAckingConsumer(messages))
+	select {
+	case err := <-publishErr:
+		require.Nil(t, err)
+	case <-time.After(time.Second):
+		require.Fail(t, "publish still blocked after the consumer acknowledged")
+	}
+
+	require.Nil(t, cs.Close())
+}
+
+func TestConsumerSink_Close_ExpectBlockedPublishReleased(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{
+		Name:      "staging",
+		Policy:    sink.ConsumerPolicyBlock,
+		QueueSize: 1,
+	})
+
+	messages := generateMessages(2, 10)
+	require.Nil(t, cs.Publish(messages[0]))
+
+	publishErr := make(chan error, 1)
+	go func() {
+		publishErr <- cs.Publish(messages[1])
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	require.Nil(t, cs.Close())
+	require.Equal(t, covalent.ErrSinkClosed, <-publishErr)
+	require.Equal(t, covalent.ErrSinkClosed, cs.Publish(messages[0]))
+}
+
+func TestConsumerSink_ConnectionFailed_ExpectUnacknowledgedResentOnReconnect(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "staging"})
+	defer func() {
+		_ = cs.Close()
+	}()
+
+	messages := generateMessages(1, 10)
+	cs.SetConnection(&mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			return 0, nil, errors.New("connection reset")
+		},
+	})
+	require.Nil(t, cs.Publish(messages[0]))
+
+	require.Eventually(t, func() bool {
+		return !cs.Status().Connected
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, 1, cs.Status().Queued)
+
+	consumer := newAckingConsumer(messages)
+	cs.SetConnection(consumer)
+	require.Eventually(t, func() bool {
+		return cs.Status().Acknowledged == 1
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, [][]byte{messages[0].Data}, consumer.received())
+}
+
+func TestConsumerSink_AcknowledgeTimeout_ExpectConnectionClosedAndBlockResent(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{
+		Name:       "staging",
+		AckTimeout: time.Millisecond * 100,
+	})
+	defer func() {
+		_ = cs.Close()
+	}()
+
+	readDeadlineSet := make(chan struct{}, 1)
+	stalled := newStalledConsumer()
+	stalled.SetReadDeadlineCalled = func(deadline time.Time) error {
+		readDeadlineSet <- struct{}{}
+		return nil
+	}
+	cs.SetConnection(stalled)
+
+	messages := generateMessages(1, 10)
+	require.Nil(t, cs.Publish(messages[0]))
+	select {
+	case <-readDeadlineSet:
+	case <-time.After(time.Second):
+		require.Fail(t, "acknowledge deadline not set")
+	}
+
+	// the stalled consumer fails reading only once closed, as a timed out websocket does
+	require.Nil(t, stalled.Close())
+	require.Eventually(t, func() bool {
+		return !cs.Status().Connected
+	}, time.Second, time.Millisecond*10)
+
+	consumer := newAckingConsumer(messages)
+	cs.SetConnection(consumer)
+	require.Eventually(t, func() bool {
+		return cs.Status().Acknowledged == 1
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, [][]byte{messages[0].Data}, consumer.received())
+}
+
+func TestConsumerSink_ConnectionLost_ExpectDisconnected(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "staging"})
+	defer func() {
+		_ = cs.Close()
+	}()
+
+	stalled := newStalledConsumer()
+	cs.SetConnection(stalled)
+	require.True(t, cs.Status().Connected)
+
+	cs.ConnectionLost(newStalledConsumer())
+	require.True(t, cs.Status().Connected)
+
+	cs.ConnectionLost(stalled)
+	require.False(t, cs.Status().Connected)
+	_, _, err := stalled.ReadMessage()
+	require.NotNil(t, err)
+}
+
+func TestConsumerSink_Resume_ExpectReplayedBeforePublishedAndNoDuplicates(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "staging"})
+	defer func() {
+		_ = cs.Close()
+	}()
+
+	messages := generateMessages(4, 10)
+	require.Nil(t, cs.Publish(messages[0]))
+	require.Nil(t, cs.Publish(messages[1]))
+
+	// the last replayed message is published afterwards, as it was retained by the block log before reaching the sink
+	consumer := newAckingConsumer(messages)
+	err := cs.Resume(replayingBlockLog(messages[1:3]), &covalent.ResumePosition{Hash: messages[0].Hash}, connectTo(consumer))
+	require.Nil(t, err)
+	require.Nil(t, cs.Publish(messages[2]))
+	require.Nil(t, cs.Publish(messages[3]))
+
+	require.Eventually(t, func() bool {
+		return cs.Status().Acknowledged == 3
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, [][]byte{messages[1].Data, messages[2].Data, messages[3].Data}, consumer.received())
+	require.Equal(t, 0, cs.Status().Queued)
+}
+
+func TestConsumerSink_Resume_PublishedWhileReadingBlockLog_ExpectNothingLost(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "staging"})
+	defer func() {
+		_ = cs.Close()
+	}()
+
+	messages := generateMessages(4, 10)
+	require.Nil(t, cs.Publish(messages[0]))
+
+	published := make(chan struct{})
+	blockLog := &mock.BlockLogStub{
+		ReadAfterCalled: func(position *covalent.ResumePosition) ([]*covalent.SinkMessage, error) {
+			// the block log already retained messages[2], which reaches the sink while reading, followed by messages[3]
+			go func() {
+				_ = cs.Publish(messages[2])
+				_ = cs.Publish(messages[3])
+				close(published)
+			}()
+			time.Sleep(time.Millisecond * 50)
+
+			return messages[1:3], nil
+		},
+	}
+
+	consumer := newAckingConsumer(messages)
+	require.Nil(t, cs.Resume(blockLog, &covalent.ResumePosition{Hash: messages[0].Hash}, connectTo(consumer)))
+	<-published
+
+	require.Eventually(t, func() bool {
+		return cs.Status().Acknowledged == 3
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, [][]byte{messages[1].Data, messages[2].Data, messages[3].Data}, consumer.received())
+	require.Equal(t, 0, cs.Status().Queued)
+}
+
+func TestConsumerSink_Resume_SlowConnect_ExpectPublishNotBlockedAndNothingLost(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "staging"})
+	defer func() {
+		_ = cs.Close()
+	}()
+
+	messages := generateMessages(4, 10)
+	require.Nil(t, cs.Publish(messages[0]))
+
+	consumer := newAckingConsumer(messages)
+	connecting := make(chan struct{})
+	handshakeDone := make(chan struct{})
+	var handshakeOnce sync.Once
+	finishHandshake := func() {
+		handshakeOnce.Do(func() {
+			close(handshakeDone)
+		})
+	}
+	defer finishHandshake()
+	resumed := make(chan error, 1)
+	go func() {
+		resumed <- cs.Resume(replayingBlockLog(messages[1:2]), &covalent.ResumePosition{Hash: messages[0].Hash}, func() (process.WSConn, bool) {
+			close(connecting)
+			<-handshakeDone
+			return consumer, true
+		})
+	}()
+
+	<-connecting
+	published := make(chan struct{})
+	go func() {
+		_ = cs.Publish(messages[2])
+		_ = cs.Publish(messages[3])
+		close(published)
+	}()
+
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		require.Fail(t, "publish blocked by the consumer handshake")
+	}
+
+	finishHandshake()
+	require.Nil(t, <-resumed)
+
+	require.Eventually(t, func() bool {
+		return cs.Status().Acknowledged == 3
+	}, time.Second, time.Millisecond*10)
+	require.Equal(t, [][]byte{messages[1].Data, messages[2].Data, messages[3].Data}, consumer.received())
+	require.Equal(t, 0, cs.Status().Queued)
+}
+
+func TestConsumerSink_Resume_ReadFailed_ExpectErrorAndQueueKept(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "staging"})
+	defer func() {
+		_ = cs.Close()
+	}()
+
+	require.Nil(t, cs.Publish(generateMessages(1, 10)[0]))
+
+	connected := false
+	blockLog := &mock.BlockLogStub{
+		ReadAfterCalled: func(position *covalent.ResumePosition) ([]*covalent.SinkMessage, error) {
+			return nil, covalent.ErrResumePositionNotFound
+		},
+	}
+	err := cs.Resume(blockLog, &covalent.ResumePosition{Nonce: 1}, func() (process.WSConn, bool) {
+		connected = true
+		return newStalledConsumer(), true
+	})
+
+	require.Equal(t, covalent.ErrResumePositionNotFound, err)
+	require.False(t, connected)
+	require.False(t, cs.Status().Connected)
+	require.Equal(t, 1, cs.Status().Queued)
+}
+
+func TestConsumerSink_Resume_Closed_ExpectNotConnected(t *testing.T) {
+	t.Parallel()
+
+	cs, _ := sink.NewConsumerSink(&sink.ArgsConsumerSink{Name: "staging"})
+	require.Nil(t, cs.Close())
+
+	connected := false
+	err := cs.Resume(replayingBlockLog(generateMessages(1, 10)), &covalent.ResumePosition{Nonce: 1}, func() (process.WSConn, bool) {
+		connected = true
+		return newStalledConsumer(), true
+	})
+
+	require.Equal(t, covalent.ErrSinkClosed, err)
+	require.False(t, connected)
+	require.Equal(t, 0, cs.Status().Queued)
+}
+
+func replayingBlockLog(replayed []*covalent.SinkMessage) *mock.BlockLogStub {
+	return &mock.BlockLogStub{
+		ReadAfterCalled: func(position *covalent.ResumePosition) ([]*covalent.SinkMessage, error) {
+			return replayed, nil
+		},
+	}
+}
+
+func connectTo(conn process.WSConn) func() (process.WSConn, bool) {
+	return func() (process.WSConn, bool) {
+		return conn, true
+	}
+}
+
+type ackingConsumer struct {
+	*mock.WSConnStub
+	mut          sync.Mutex
+	receivedData [][]byte
+}
+
+// newAckingConsumer creates a consumer connection which acknowledges each received message with its hash
+func newAckingConsumer(messages []*covalent.SinkMessage) *ackingConsumer {
+	ac := &ackingConsumer{}
+	ac.WSConnStub = &mock.WSConnStub{
+		WriteMessageCalled: func(messageType int, data []byte) error {
+			ac.mut.Lock()
+			ac.receivedData = append(ac.receivedData, data)
+			ac.mut.Unlock()
+			return nil
+		},
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			ac.mut.Lock()
+			defer ac.mut.Unlock()
+
+			lastReceived := ac.receivedData[len(ac.receivedData)-1]
+			for _, message := range messages {
+				if bytes.Equal(message.Data, lastReceived) {
+					return websocket.BinaryMessage, message.Hash, nil
+				}
+			}
+			return websocket.BinaryMessage, nil, nil
+		},
+	}
+
+	return ac
+}
+
+func (ac *ackingConsumer) received() [][]byte {
+	ac.mut.Lock()
+	defer ac.mut.Unlock()
+
+	return ac.receivedData
+}
+
+// newStalledConsumer creates a consumer connection which never acknowledges anything, until closed
+func newStalledConsumer() *mock.WSConnStub {
+	closed := make(chan struct{})
+	closeOnce := sync.Once{}
+
+	return &mock.WSConnStub{
+		ReadMessageCalled: func() (messageType int, p []byte, err error) {
+			<-closed
+			return 0, nil, errors.New("connection closed")
+		},
+		CloseCalled: func() error {
+			closeOnce.Do(func() {
+				close(closed)
+			})
+			return nil
+		},
+	}
+}
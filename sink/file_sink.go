@@ -0,0 +1,182 @@
+package sink
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/numbatx/gn-coval-index"
+	logger "github.com/numbatx/gn-logger"
+)
+
+var log = logger.GetOrCreate("covalent/sink")
+
+const (
+	fileSinkPrefix = "blocks-"
+	fileSinkSuffix = ".bin"
+
+	// frameHeaderSize = 4 bytes, big endian, data length
+	frameHeaderSize = 4
+
+	// DefaultMaxFileSize is the file size used by the file sink if none is provided
+	DefaultMaxFileSize = 256 * 1024 * 1024
+)
+
+// ArgsFileSink holds all input dependencies required by file sink in order to create a new instance
+type ArgsFileSink struct {
+	Directory   string
+	MaxFileSize int64
+}
+
+type fileSink struct {
+	mut         sync.Mutex
+	directory   string
+	maxFileSize int64
+	file        *os.File
+	size        int64
+	closed      bool
+}
+
+// NewFileSink creates a new sink which appends published data to files from the provided directory. Each data
+// is written as a frame made of its length(4 bytes, big endian) followed by the data itself. A new file, named after
+// the sequence number of its first message, is created once the current one would exceed the maximum file size
+func NewFileSink(args *ArgsFileSink) (*fileSink, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if len(args.Directory) == 0 {
+		return nil, covalent.ErrEmptySinkDirectory
+	}
+	if args.MaxFileSize < 0 {
+		return nil, covalent.ErrInvalidSinkFileSize
+	}
+
+	maxFileSize := args.MaxFileSize
+	if maxFileSize == 0 {
+		maxFileSize = DefaultMaxFileSize
+	}
+
+	err := os.MkdirAll(args.Directory, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileSink{
+		directory:   args.Directory,
+		maxFileSize: maxFileSize,
+	}, nil
+}
+
+// Publish appends the message data to the current file, rotating it if needed
+func (fs *fileSink) Publish(message *covalent.SinkMessage) error {
+	if message == nil {
+		return covalent.ErrNilSinkMessage
+	}
+
+	fs.mut.Lock()
+	defer fs.mut.Unlock()
+
+	if fs.closed {
+		return covalent.ErrSinkClosed
+	}
+
+	frame := make([]byte, frameHeaderSize+len(message.Data))
+	binary.BigEndian.PutUint32(frame, uint32(len(message.Data)))
+	copy(frame[frameHeaderSize:], message.Data)
+
+	err := fs.rotateIfNeeded(message.SequenceNumber, int64(len(frame)))
+	if err != nil {
+		return err
+	}
+
+	_, err = fs.file.Write(frame)
+	if err != nil {
+		fs.discardPartialFrame()
+		return err
+	}
+
+	fs.size += int64(len(frame))
+	return nil
+}
+
+// discardPartialFrame truncates the current file back to its last complete frame, after a failed write, so that
+// next frames stay aligned. If that is not possible, the file is abandoned and next frames are written in a new file
+func (fs *fileSink) discardPartialFrame() {
+	err := fs.file.Truncate(fs.size)
+	if err == nil {
+		return
+	}
+
+	log.Error("could not discard partially written frame, rotating file", "error", err)
+	_ = fs.file.Close()
+	fs.file = nil
+	fs.size = 0
+}
+
+func (fs *fileSink) rotateIfNeeded(sequenceNumber uint64, frameSize int64) error {
+	if fs.file != nil && (fs.size == 0 || fs.size+frameSize <= fs.maxFileSize) {
+		return nil
+	}
+
+	if fs.file != nil {
+		err := fs.closeFile()
+		if err != nil {
+			return err
+		}
+	}
+
+	fileName := filepath.Join(fs.directory, fmt.Sprintf("%s%020d%s", fileSinkPrefix, sequenceNumber, fileSinkSuffix))
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	log.Debug("file sink opened new file", "file", fileName)
+	fs.file = file
+	fs.size = info.Size()
+
+	return nil
+}
+
+func (fs *fileSink) closeFile() error {
+	err := fs.file.Sync()
+	if err != nil {
+		return err
+	}
+
+	err = fs.file.Close()
+	fs.file = nil
+	fs.size = 0
+
+	return err
+}
+
+// Close syncs and closes the current file
+func (fs *fileSink) Close() error {
+	fs.mut.Lock()
+	defer fs.mut.Unlock()
+
+	if fs.closed {
+		return nil
+	}
+	fs.closed = true
+
+	if fs.file == nil {
+		return nil
+	}
+
+	return fs.closeFile()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (fs *fileSink) IsInterfaceNil() bool {
+	return fs == nil
+}
@@ -0,0 +1,130 @@
+package sink_test
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-coval-index/sink"
+	"github.com/numbatx/gn-coval-index/testscommon"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFileSink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *sink.ArgsFileSink
+		expectedErr error
+	}{
+		{
+			args: func() *sink.ArgsFileSink {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args: func() *sink.ArgsFileSink {
+				return &sink.ArgsFileSink{Directory: ""}
+			},
+			expectedErr: covalent.ErrEmptySinkDirectory,
+		},
+		{
+			args: func() *sink.ArgsFileSink {
+				return &sink.ArgsFileSink{Directory: t.TempDir(), MaxFileSize: -1}
+			},
+			expectedErr: covalent.ErrInvalidSinkFileSize,
+		},
+		{
+			args: func() *sink.ArgsFileSink {
+				return &sink.ArgsFileSink{Directory: t.TempDir()}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := sink.NewFileSink(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(instance))
+	}
+}
+
+func TestFileSink_Publish_ExpectFramesWrittenAndFilesRotated(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fs, _ := sink.NewFileSink(&sink.ArgsFileSink{Directory: dir, MaxFileSize: 100})
+
+	messages := generateMessages(3, 40)
+	for _, message := range messages {
+		require.Nil(t, fs.Publish(message))
+	}
+	require.Nil(t, fs.Close())
+	require.Equal(t, covalent.ErrSinkClosed, fs.Publish(messages[0]))
+
+	// Each frame has 44 bytes, so only two of them fit in a file
+	firstFile := readFrames(t, filepath.Join(dir, "blocks-00000000000000000000.bin"))
+	require.Equal(t, [][]byte{messages[0].Data, messages[1].Data}, firstFile)
+
+	secondFile := readFrames(t, filepath.Join(dir, "blocks-00000000000000000002.bin"))
+	require.Equal(t, [][]byte{messages[2].Data}, secondFile)
+}
+
+func TestFileSink_Restart_ExpectAppendedToExistingFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	messages := generateMessages(2, 10)
+	messages[1].SequenceNumber = 0
+
+	for _, message := range messages {
+		fs, _ := sink.NewFileSink(&sink.ArgsFileSink{Directory: dir})
+		require.Nil(t, fs.Publish(message))
+		require.Nil(t, fs.Close())
+	}
+
+	frames := readFrames(t, filepath.Join(dir, "blocks-00000000000000000000.bin"))
+	require.Equal(t, [][]byte{messages[0].Data, messages[1].Data}, frames)
+}
+
+func generateMessages(n int, dataSize int) []*covalent.SinkMessage {
+	messages := make([]*covalent.SinkMessage, n)
+	for i := 0; i < n; i++ {
+		block := &schema.Block{
+			Nonce:         int64(i + 100),
+			Hash:          testscommon.GenerateRandomFixedBytes(32),
+			StateRootHash: testscommon.GenerateRandomFixedBytes(32),
+		}
+		messages[i] = &covalent.SinkMessage{
+			SequenceNumber: uint64(i),
+			Nonce:          uint64(block.Nonce),
+			Hash:           block.Hash,
+			Record:         &schema.BlockResult{Block: block},
+			Data:           testscommon.GenerateRandomFixedBytes(dataSize),
+		}
+	}
+
+	return messages
+}
+
+func readFrames(t *testing.T, fileName string) [][]byte {
+	buff, err := os.ReadFile(fileName)
+	require.Nil(t, err)
+
+	frames := make([][]byte, 0)
+	for len(buff) > 0 {
+		require.GreaterOrEqual(t, len(buff), 4)
+		frameSize := int(binary.BigEndian.Uint32(buff))
+		require.GreaterOrEqual(t, len(buff), 4+frameSize)
+
+		frames = append(frames, buff[4:4+frameSize])
+		buff = buff[4+frameSize:]
+	}
+
+	return frames
+}
@@ -0,0 +1,155 @@
+package sink
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-core/core/check"
+)
+
+const (
+	// HeaderSequenceNumber is the http header holding the sequence number of the posted data
+	HeaderSequenceNumber = "X-Covalent-Sequence-Number"
+	// HeaderBlockNonce is the http header holding the nonce of the posted block
+	HeaderBlockNonce = "X-Covalent-Block-Nonce"
+	// HeaderBlockHash is the http header holding the hex encoded hash of the posted block
+	HeaderBlockHash = "X-Covalent-Block-Hash"
+
+	// DefaultRequestTimeout is the http request timeout used if no http client is provided
+	DefaultRequestTimeout = 10 * time.Second
+	// DefaultRetryInterval is the time waited between two requests of the same data, if no retry delay is configured
+	DefaultRetryInterval = time.Second
+)
+
+// ArgsHTTPSink holds all input dependencies required by http sink in order to create a new instance.
+// Client and RetryPolicy are optional, failed requests not being retried if no retry policy is provided
+type ArgsHTTPSink struct {
+	URL         string
+	Client      *http.Client
+	RetryPolicy covalent.RetryPolicy
+}
+
+type httpSink struct {
+	url         string
+	client      *http.Client
+	retryPolicy covalent.RetryPolicy
+	closeChan   chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewHTTPSink creates a new sink which posts each published data to the provided url. A request which fails or
+// is not answered with a 2xx status code is retried as allowed by the retry policy. Once retries are exhausted,
+// the error of the last request is returned
+func NewHTTPSink(args *ArgsHTTPSink) (*httpSink, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+	if len(args.URL) == 0 {
+		return nil, covalent.ErrEmptySinkURL
+	}
+
+	client := args.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultRequestTimeout}
+	}
+	retryPolicy := args.RetryPolicy
+	if check.IfNil(retryPolicy) {
+		var err error
+		retryPolicy, err = covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+			InitialDelay: DefaultRetryInterval,
+			Multiplier:   1,
+			MaxAttempts:  1,
+			OnExhausted:  covalent.RetryExhaustedError,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &httpSink{
+		url:         args.URL,
+		client:      client,
+		retryPolicy: retryPolicy,
+		closeChan:   make(chan struct{}),
+	}, nil
+}
+
+// Publish posts the message data, retrying if needed
+func (hs *httpSink) Publish(message *covalent.SinkMessage) error {
+	if message == nil {
+		return covalent.ErrNilSinkMessage
+	}
+
+	backoff := hs.retryPolicy.NewBackoff()
+	for {
+		select {
+		case <-hs.closeChan:
+			return covalent.ErrSinkClosed
+		default:
+		}
+
+		err := hs.post(message)
+		if err == nil {
+			return nil
+		}
+
+		delay, canRetry := backoff.NextDelay()
+		if !canRetry {
+			log.Warn("could not post record, retries exhausted", "url", hs.url, "retries", backoff.Retries(), "error", err)
+			return err
+		}
+		log.Debug("could not post record, retrying", "url", hs.url, "retry", backoff.Retries(), "delay", delay, "error", err)
+
+		select {
+		case <-time.After(delay):
+		case <-hs.closeChan:
+			return covalent.ErrSinkClosed
+		}
+	}
+}
+
+func (hs *httpSink) post(message *covalent.SinkMessage) error {
+	request, err := http.NewRequest(http.MethodPost, hs.url, bytes.NewReader(message.Data))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/octet-stream")
+	request.Header.Set(HeaderSequenceNumber, strconv.FormatUint(message.SequenceNumber, 10))
+	request.Header.Set(HeaderBlockNonce, strconv.FormatUint(message.Nonce, 10))
+	request.Header.Set(HeaderBlockHash, hex.EncodeToString(message.Hash))
+
+	response, err := hs.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %d", covalent.ErrUnexpectedHTTPStatus, response.StatusCode)
+	}
+
+	return nil
+}
+
+// Close stops all pending retries
+func (hs *httpSink) Close() error {
+	hs.closeOnce.Do(func() {
+		close(hs.closeChan)
+	})
+
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (hs *httpSink) IsInterfaceNil() bool {
+	return hs == nil
+}
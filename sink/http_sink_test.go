@@ -0,0 +1,136 @@
+package sink_test
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/sink"
+	"github.com/numbatx/gn-core/core/atomic"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPSink(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args        func() *sink.ArgsHTTPSink
+		expectedErr error
+	}{
+		{
+			args: func() *sink.ArgsHTTPSink {
+				return nil
+			},
+			expectedErr: covalent.ErrNilArguments,
+		},
+		{
+			args: func() *sink.ArgsHTTPSink {
+				return &sink.ArgsHTTPSink{URL: ""}
+			},
+			expectedErr: covalent.ErrEmptySinkURL,
+		},
+		{
+			args: func() *sink.ArgsHTTPSink {
+				return &sink.ArgsHTTPSink{URL: "http://localhost"}
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, currTest := range tests {
+		instance, err := sink.NewHTTPSink(currTest.args())
+		require.Equal(t, currTest.expectedErr, err)
+		require.Equal(t, err != nil, check.IfNil(instance))
+	}
+}
+
+func TestHTTPSink_Publish_FailedRequests_ExpectRetried(t *testing.T) {
+	t.Parallel()
+
+	message := generateMessages(1, 20)[0]
+	requestsCt := atomic.Counter{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsCt.Increment()
+
+		body, err := io.ReadAll(r.Body)
+		require.Nil(t, err)
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, message.Data, body)
+		require.Equal(t, strconv.FormatUint(message.SequenceNumber, 10), r.Header.Get(sink.HeaderSequenceNumber))
+		require.Equal(t, strconv.FormatUint(message.Nonce, 10), r.Header.Get(sink.HeaderBlockNonce))
+		require.Equal(t, hex.EncodeToString(message.Hash), r.Header.Get(sink.HeaderBlockHash))
+
+		if requestsCt.Get() < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	hs, _ := sink.NewHTTPSink(&sink.ArgsHTTPSink{
+		URL:         server.URL,
+		RetryPolicy: createRetryPolicy(t, 3),
+	})
+
+	require.Nil(t, hs.Publish(message))
+	require.Equal(t, int64(3), requestsCt.Get())
+}
+
+func TestHTTPSink_Publish_MaxRetriesReached_ExpectError(t *testing.T) {
+	t.Parallel()
+
+	requestsCt := atomic.Counter{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestsCt.Increment()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	hs, _ := sink.NewHTTPSink(&sink.ArgsHTTPSink{
+		URL:         server.URL,
+		RetryPolicy: createRetryPolicy(t, 2),
+	})
+
+	err := hs.Publish(generateMessages(1, 20)[0])
+	require.True(t, errors.Is(err, covalent.ErrUnexpectedHTTPStatus))
+	require.Equal(t, int64(2), requestsCt.Get())
+}
+
+func TestHTTPSink_Close_ExpectRetriesStopped(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	hs, _ := sink.NewHTTPSink(&sink.ArgsHTTPSink{
+		URL:         server.URL,
+		RetryPolicy: createRetryPolicy(t, 1000),
+	})
+
+	go func() {
+		time.Sleep(time.Millisecond * 50)
+		_ = hs.Close()
+	}()
+
+	require.Equal(t, covalent.ErrSinkClosed, hs.Publish(generateMessages(1, 20)[0]))
+}
+
+func createRetryPolicy(t *testing.T, maxAttempts int) covalent.RetryPolicy {
+	retryPolicy, err := covalent.NewRetryPolicy(&covalent.ArgsRetryPolicy{
+		InitialDelay: time.Millisecond * 10,
+		Multiplier:   1,
+		MaxAttempts:  maxAttempts,
+		OnExhausted:  covalent.RetryExhaustedError,
+	})
+	require.Nil(t, err)
+
+	return retryPolicy
+}
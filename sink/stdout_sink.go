@@ -0,0 +1,72 @@
+package sink
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/numbatx/gn-coval-index"
+)
+
+// ArgsStdoutSink holds all input dependencies required by stdout sink in order to create a new instance.
+// Writer is optional, os.Stdout being used if none is provided
+type ArgsStdoutSink struct {
+	Writer       io.Writer
+	WritePayload bool
+}
+
+type stdoutSink struct {
+	mut          sync.Mutex
+	writer       io.Writer
+	writePayload bool
+}
+
+// NewStdoutSink creates a new debug sink which writes a human readable line for each published record.
+// If WritePayload is set, the hex encoded data is written as well
+func NewStdoutSink(args *ArgsStdoutSink) (*stdoutSink, error) {
+	if args == nil {
+		return nil, covalent.ErrNilArguments
+	}
+
+	writer := args.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	return &stdoutSink{
+		writer:       writer,
+		writePayload: args.WritePayload,
+	}, nil
+}
+
+// Publish writes a summary of the message
+func (ss *stdoutSink) Publish(message *covalent.SinkMessage) error {
+	if message == nil || message.Record == nil {
+		return covalent.ErrNilSinkMessage
+	}
+
+	line := fmt.Sprintf("sequence: %d, record: %s, nonce: %d, round: %d, epoch: %d, hash: %s, size: %d",
+		message.SequenceNumber, message.Record.Schema().GetName(), message.Nonce, message.Round, message.Epoch,
+		hex.EncodeToString(message.Hash), len(message.Data))
+	if ss.writePayload {
+		line += ", data: " + hex.EncodeToString(message.Data)
+	}
+
+	ss.mut.Lock()
+	defer ss.mut.Unlock()
+
+	_, err := fmt.Fprintln(ss.writer, line)
+	return err
+}
+
+// Close returns nil
+func (ss *stdoutSink) Close() error {
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ss *stdoutSink) IsInterfaceNil() bool {
+	return ss == nil
+}
@@ -0,0 +1,45 @@
+package sink_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/numbatx/gn-coval-index"
+	"github.com/numbatx/gn-coval-index/sink"
+	"github.com/numbatx/gn-core/core/check"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStdoutSink(t *testing.T) {
+	t.Parallel()
+
+	ss, err := sink.NewStdoutSink(nil)
+	require.Equal(t, covalent.ErrNilArguments, err)
+	require.True(t, check.IfNil(ss))
+
+	ss, err = sink.NewStdoutSink(&sink.ArgsStdoutSink{})
+	require.Nil(t, err)
+	require.False(t, check.IfNil(ss))
+}
+
+func TestStdoutSink_Publish(t *testing.T) {
+	t.Parallel()
+
+	message := generateMessages(1, 4)[0]
+	expectedLine := fmt.Sprintf("sequence: 0, record: BlockResult, nonce: 100, round: 0, epoch: 0, hash: %s, size: 4",
+		hex.EncodeToString(message.Hash))
+
+	buff := &bytes.Buffer{}
+	ss, _ := sink.NewStdoutSink(&sink.ArgsStdoutSink{Writer: buff})
+	require.Nil(t, ss.Publish(message))
+	require.Equal(t, expectedLine+"\n", buff.String())
+
+	buff.Reset()
+	ss, _ = sink.NewStdoutSink(&sink.ArgsStdoutSink{Writer: buff, WritePayload: true})
+	require.Nil(t, ss.Publish(message))
+	require.Equal(t, expectedLine+", data: "+hex.EncodeToString(message.Data)+"\n", buff.String())
+
+	require.Equal(t, covalent.ErrNilSinkMessage, ss.Publish(nil))
+}
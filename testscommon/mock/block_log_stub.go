@@ -0,0 +1,34 @@
+package mock
+
+import "github.com/numbatx/gn-coval-index"
+
+type BlockLogStub struct {
+	PublishCalled   func(message *covalent.SinkMessage) error
+	ReadAfterCalled func(position *covalent.ResumePosition) ([]*covalent.SinkMessage, error)
+	CloseCalled     func() error
+}
+
+func (bls *BlockLogStub) Publish(message *covalent.SinkMessage) error {
+	if bls.PublishCalled != nil {
+		return bls.PublishCalled(message)
+	}
+	return nil
+}
+
+func (bls *BlockLogStub) ReadAfter(position *covalent.ResumePosition) ([]*covalent.SinkMessage, error) {
+	if bls.ReadAfterCalled != nil {
+		return bls.ReadAfterCalled(position)
+	}
+	return nil, nil
+}
+
+func (bls *BlockLogStub) Close() error {
+	if bls.CloseCalled != nil {
+		return bls.CloseCalled()
+	}
+	return nil
+}
+
+func (bls *BlockLogStub) IsInterfaceNil() bool {
+	return bls == nil
+}
@@ -2,11 +2,17 @@ package mock
 
 import (
 	"github.com/numbatx/gn-coval-index/schema"
+	"github.com/numbatx/gn-core/data"
 	"github.com/numbatx/gn-core/data/indexer"
 )
 
 type DataHandlerStub struct {
-	ProcessDataCalled func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error)
+	ProcessDataCalled              func(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error)
+	ProcessRevertCalled            func(header data.HeaderHandler) (*schema.BlockRevert, error)
+	ProcessAccountsCalled          func(blockTimestamp uint64, accounts []data.UserAccountHandler) *schema.AccountsSnapshot
+	ProcessRoundsInfoCalled        func(roundsInfo []*indexer.RoundInfo) *schema.RoundsInfo
+	ProcessValidatorsPubKeysCalled func(validatorsPubKeys map[uint32][][]byte, epoch uint32) []*schema.EpochValidators
+	ProcessValidatorsRatingCalled  func(indexID string, ratingsInfo []*indexer.ValidatorRatingInfo) (*schema.ValidatorsRating, error)
 }
 
 func (dhs *DataHandlerStub) ProcessData(args *indexer.ArgsSaveBlockData) (*schema.BlockResult, error) {
@@ -15,3 +21,38 @@ func (dhs *DataHandlerStub) ProcessData(args *indexer.ArgsSaveBlockData) (*schem
 	}
 	return nil, nil
 }
+
+func (dhs *DataHandlerStub) ProcessRevert(header data.HeaderHandler) (*schema.BlockRevert, error) {
+	if dhs.ProcessRevertCalled != nil {
+		return dhs.ProcessRevertCalled(header)
+	}
+	return nil, nil
+}
+
+func (dhs *DataHandlerStub) ProcessAccounts(blockTimestamp uint64, accounts []data.UserAccountHandler) *schema.AccountsSnapshot {
+	if dhs.ProcessAccountsCalled != nil {
+		return dhs.ProcessAccountsCalled(blockTimestamp, accounts)
+	}
+	return nil
+}
+
+func (dhs *DataHandlerStub) ProcessRoundsInfo(roundsInfo []*indexer.RoundInfo) *schema.RoundsInfo {
+	if dhs.ProcessRoundsInfoCalled != nil {
+		return dhs.ProcessRoundsInfoCalled(roundsInfo)
+	}
+	return nil
+}
+
+func (dhs *DataHandlerStub) ProcessValidatorsPubKeys(validatorsPubKeys map[uint32][][]byte, epoch uint32) []*schema.EpochValidators {
+	if dhs.ProcessValidatorsPubKeysCalled != nil {
+		return dhs.ProcessValidatorsPubKeysCalled(validatorsPubKeys, epoch)
+	}
+	return nil
+}
+
+func (dhs *DataHandlerStub) ProcessValidatorsRating(indexID string, ratingsInfo []*indexer.ValidatorRatingInfo) (*schema.ValidatorsRating, error) {
+	if dhs.ProcessValidatorsRatingCalled != nil {
+		return dhs.ProcessValidatorsRatingCalled(indexID, ratingsInfo)
+	}
+	return nil, nil
+}
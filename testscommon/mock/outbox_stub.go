@@ -0,0 +1,58 @@
+package mock
+
+import "github.com/numbatx/gn-coval-index"
+
+type OutboxStub struct {
+	AppendCalled     func(entry *covalent.OutboxEntry) error
+	GetCalled        func(index int) (*covalent.OutboxEntry, error)
+	RemoveHeadCalled func() error
+	LenCalled        func() int
+	NextIDCalled     func() uint64
+	CloseCalled      func() error
+}
+
+func (os *OutboxStub) Append(entry *covalent.OutboxEntry) error {
+	if os.AppendCalled != nil {
+		return os.AppendCalled(entry)
+	}
+	return nil
+}
+
+func (os *OutboxStub) Get(index int) (*covalent.OutboxEntry, error) {
+	if os.GetCalled != nil {
+		return os.GetCalled(index)
+	}
+	return nil, covalent.ErrOutboxIndexOutOfRange
+}
+
+func (os *OutboxStub) RemoveHead() error {
+	if os.RemoveHeadCalled != nil {
+		return os.RemoveHeadCalled()
+	}
+	return nil
+}
+
+func (os *OutboxStub) Len() int {
+	if os.LenCalled != nil {
+		return os.LenCalled()
+	}
+	return 0
+}
+
+func (os *OutboxStub) NextID() uint64 {
+	if os.NextIDCalled != nil {
+		return os.NextIDCalled()
+	}
+	return 0
+}
+
+func (os *OutboxStub) Close() error {
+	if os.CloseCalled != nil {
+		return os.CloseCalled()
+	}
+	return nil
+}
+
+func (os *OutboxStub) IsInterfaceNil() bool {
+	return os == nil
+}
@@ -0,0 +1,18 @@
+package mock
+
+import "github.com/numbatx/gn-core/data/indexer"
+
+type QuarantineStub struct {
+	StoreCalled func(args *indexer.ArgsSaveBlockData, cause error) error
+}
+
+func (qs *QuarantineStub) Store(args *indexer.ArgsSaveBlockData, cause error) error {
+	if qs.StoreCalled != nil {
+		return qs.StoreCalled(args, cause)
+	}
+	return nil
+}
+
+func (qs *QuarantineStub) IsInterfaceNil() bool {
+	return qs == nil
+}
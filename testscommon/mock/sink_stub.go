@@ -0,0 +1,26 @@
+package mock
+
+import "github.com/numbatx/gn-coval-index"
+
+type SinkStub struct {
+	PublishCalled func(message *covalent.SinkMessage) error
+	CloseCalled   func() error
+}
+
+func (ss *SinkStub) Publish(message *covalent.SinkMessage) error {
+	if ss.PublishCalled != nil {
+		return ss.PublishCalled(message)
+	}
+	return nil
+}
+
+func (ss *SinkStub) Close() error {
+	if ss.CloseCalled != nil {
+		return ss.CloseCalled()
+	}
+	return nil
+}
+
+func (ss *SinkStub) IsInterfaceNil() bool {
+	return ss == nil
+}
@@ -0,0 +1,67 @@
+package mock
+
+import (
+	"math/big"
+)
+
+// UserAccountStub -
+type UserAccountStub struct {
+	Address         []byte
+	Balance         *big.Int
+	Nonce           uint64
+	UserName        []byte
+	CodeHash        []byte
+	RootHash        []byte
+	OwnerAddress    []byte
+	DeveloperReward *big.Int
+}
+
+// AddressBytes -
+func (uas *UserAccountStub) AddressBytes() []byte {
+	return uas.Address
+}
+
+// GetBalance -
+func (uas *UserAccountStub) GetBalance() *big.Int {
+	return uas.Balance
+}
+
+// GetNonce -
+func (uas *UserAccountStub) GetNonce() uint64 {
+	return uas.Nonce
+}
+
+// GetUserName -
+func (uas *UserAccountStub) GetUserName() []byte {
+	return uas.UserName
+}
+
+// GetCodeHash -
+func (uas *UserAccountStub) GetCodeHash() []byte {
+	return uas.CodeHash
+}
+
+// GetRootHash -
+func (uas *UserAccountStub) GetRootHash() []byte {
+	return uas.RootHash
+}
+
+// GetOwnerAddress -
+func (uas *UserAccountStub) GetOwnerAddress() []byte {
+	return uas.OwnerAddress
+}
+
+// GetDeveloperReward -
+func (uas *UserAccountStub) GetDeveloperReward() *big.Int {
+	return uas.DeveloperReward
+}
+
+// RetrieveValueFromDataTrieTracker -
+func (uas *UserAccountStub) RetrieveValueFromDataTrieTracker([]byte) ([]byte, error) {
+	return nil, nil
+}
+
+// IsInterfaceNil returns true if interface is nil, false otherwise
+func (uas *UserAccountStub) IsInterfaceNil() bool {
+	return uas == nil
+}
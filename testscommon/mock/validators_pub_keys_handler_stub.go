@@ -0,0 +1,15 @@
+package mock
+
+// ValidatorsPubKeysHandlerStub that will be used for testing
+type ValidatorsPubKeysHandlerStub struct {
+	GetValidatorsPubKeysCalled func(shardID uint32, epoch uint32) ([][]byte, bool)
+}
+
+// GetValidatorsPubKeys calls a custom lookup function if defined, otherwise returns nil, false
+func (vpkhs *ValidatorsPubKeysHandlerStub) GetValidatorsPubKeys(shardID uint32, epoch uint32) ([][]byte, bool) {
+	if vpkhs.GetValidatorsPubKeysCalled != nil {
+		return vpkhs.GetValidatorsPubKeysCalled(shardID, epoch)
+	}
+
+	return nil, false
+}
@@ -1,12 +1,17 @@
 package mock
 
-import "io"
+import (
+	"io"
+	"time"
+)
 
 type WSConnStub struct {
 	io.Closer
-	WriteMessageCalled func(messageType int, data []byte) error
-	ReadMessageCalled  func() (messageType int, p []byte, err error)
-	CloseCalled        func() error
+	WriteMessageCalled     func(messageType int, data []byte) error
+	ReadMessageCalled      func() (messageType int, p []byte, err error)
+	CloseCalled            func() error
+	SetReadDeadlineCalled  func(t time.Time) error
+	SetWriteDeadlineCalled func(t time.Time) error
 }
 
 func (wsc *WSConnStub) ReadMessage() (messageType int, p []byte, err error) {
@@ -29,3 +34,17 @@ func (wsc *WSConnStub) Close() error {
 	}
 	return nil
 }
+
+func (wsc *WSConnStub) SetReadDeadline(t time.Time) error {
+	if wsc.SetReadDeadlineCalled != nil {
+		return wsc.SetReadDeadlineCalled(t)
+	}
+	return nil
+}
+
+func (wsc *WSConnStub) SetWriteDeadline(t time.Time) error {
+	if wsc.SetWriteDeadlineCalled != nil {
+		return wsc.SetWriteDeadlineCalled(t)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package covalent
+
+import "encoding/binary"
+
+// websocketSink delivers messages to covalent through the websocket connections set on the indexer, publishing
+// waiting until covalent acknowledges the message. It is not used with an outbox, messages being stored in the
+// outbox by the indexer and sent afterwards
+type websocketSink struct {
+	ci *covalentIndexer
+}
+
+// Publish sends the message data to covalent
+func (ws *websocketSink) Publish(message *SinkMessage) error {
+	if message == nil {
+		return ErrNilSinkMessage
+	}
+
+	err := ws.ci.sendWithRetrial(message.Data, acknowledgeData(message))
+	if err == ErrRetriesExhausted {
+		return ws.ci.spill(message)
+	}
+	if err == nil {
+		ws.ci.blockAcknowledged(message.Hash, message.Nonce)
+	}
+
+	return err
+}
+
+// Close returns nil, since websocket connections are closed by the indexer
+func (ws *websocketSink) Close() error {
+	return nil
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ws *websocketSink) IsInterfaceNil() bool {
+	return ws == nil
+}
+
+// acknowledgeData returns the data covalent acknowledges the message with: the hash of the block the message refers
+// to or, for messages which do not refer to a block(e.g. rounds info), the big endian sequence number
+func acknowledgeData(message *SinkMessage) []byte {
+	if len(message.Hash) > 0 {
+		return message.Hash
+	}
+
+	ackData := make([]byte, 8)
+	binary.BigEndian.PutUint64(ackData, message.SequenceNumber)
+
+	return ackData
+}